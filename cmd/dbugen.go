@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/byte4cat/nbx/v2/internal/dbugen"
+	"github.com/byte4cat/nbx/v2/pkg/clog"
+	"github.com/spf13/cobra"
+)
+
+// dbugenCmd represents the dbugen command
+var dbugenCmd = &cobra.Command{
+	Use:   "dbugen [package]",
+	Short: "Generate reflection-free dbu update-map methods for structs",
+	Long: `dbugen generates RDBUpdateMap and MongoUpdateMap methods for struct types.
+They return the same maps as dbu.BuildRDBUpdateMap and dbu.BuildMongoUpdateMap,
+without the cost of reflection. Columns are named by dbu.DefaultSnakeCaseNamer.
+
+The package defaults to the one in the current directory, so dbugen can be run
+by go generate:
+
+  //go:generate nbx dbugen --type User,Travel
+
+Example:
+  nbx dbugen --type User
+  nbx dbugen --type User,Travel --mongo=false ./internal/model
+  nbx dbugen -t User -o user_dbu.go`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		typeNames, _ := cmd.Flags().GetStringSlice("type")
+		output, _ := cmd.Flags().GetString("out")
+		rdb, _ := cmd.Flags().GetBool("rdb")
+		mongo, _ := cmd.Flags().GetBool("mongo")
+
+		cfg := dbugen.Config{
+			Types:  typeNames,
+			Output: output,
+			RDB:    rdb,
+			Mongo:  mongo,
+		}
+		if len(args) > 0 {
+			cfg.Pattern = args[0]
+		}
+
+		path, err := dbugen.Generate(cfg)
+		if err != nil {
+			return err
+		}
+		clog.Info("Generated %s", path)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbugenCmd)
+
+	dbugenCmd.Flags().StringSliceP("type", "t", nil, "Struct types to generate methods for")
+	dbugenCmd.Flags().StringP("out", "o", "", "Generated file (default \""+dbugen.DefaultOutput+"\" in the package directory)")
+	dbugenCmd.Flags().Bool("rdb", true, "Generate RDBUpdateMap methods")
+	dbugenCmd.Flags().Bool("mongo", true, "Generate MongoUpdateMap methods")
+
+	dbugenCmd.MarkFlagRequired("type")
+}
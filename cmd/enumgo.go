@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/byte4cat/nbx/v2/internal/enumgo/generator"
+	"github.com/byte4cat/nbx/v2/pkg/clog"
 	"github.com/spf13/cobra"
 )
 
@@ -53,7 +54,7 @@ Example:
 				ext := strings.ToLower(filepath.Ext(file.Name()))
 				if ext == ".yaml" || ext == ".yml" {
 					inputFile := filepath.Join(input, file.Name())
-					fmt.Printf("Processing file: %s\n", inputFile) // Optional: print progress
+					clog.Info("Processing file: %s", inputFile) // Optional: print progress
 					// Assuming generator.NewEnumFile can return an error
 					if err := generator.NewEnumFile(inputFile, outputDir, pkgName); err != nil {
 						// If processing one file fails, return the error and stop (or collect errors)
@@ -63,15 +64,15 @@ Example:
 			}
 		} else {
 			// If input is a single file, process the file
-			fmt.Printf("Processing file: %s\n", input) // Optional: print progress
+			clog.Info("Processing file: %s", input) // Optional: print progress
 			// Assuming generator.NewEnumFile can return an error
 			if err := generator.NewEnumFile(input, outputDir, pkgName); err != nil {
 				return fmt.Errorf("error generating enum from '%s': %w", input, err)
 			}
 		}
 
-		fmt.Printf("Enum generation complete. Output files are in %s\n", outputDir) // Optional success message
-		return nil                                                                  // Return nil to indicate success
+		clog.Info("Enum generation complete. Output files are in %s", outputDir) // Optional success message
+		return nil                                                               // Return nil to indicate success
 	},
 }
 
@@ -4,8 +4,11 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"os"
 
+	"github.com/byte4cat/nbx/v2/internal/script"
+	"github.com/byte4cat/nbx/v2/pkg/clog"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +17,36 @@ var rootCmd = &cobra.Command{
 	Use:   "nbx",
 	Short: "Neil's tool box.",
 	Long:  `Neil's tool box for everything.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		noColor, _ := cmd.Flags().GetBool("no-color")
+		output, _ := cmd.Flags().GetString("output")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		format, err := clog.ParseFormat(output)
+		if err != nil {
+			return err
+		}
+
+		verbosity := clog.Normal
+		switch {
+		case quiet:
+			verbosity = clog.Quiet
+		case verbose:
+			verbosity = clog.Verbose
+		}
+		// print through the command's writers, so tests can capture the output
+		clog.SetDefault(clog.NewConsole(cmd.OutOrStdout(), &clog.Options{
+			Err:       cmd.ErrOrStderr(),
+			Verbosity: verbosity,
+			NoColor:   noColor,
+			Format:    format,
+			In:        cmd.InOrStdin(),
+			Yes:       yes,
+		}))
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -21,6 +54,11 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		// errors returned by clog.Fail were printed already, and cancelled
+		// scripts were warned about
+		if !clog.Reported(err) && !errors.Is(err, script.ErrCancelled) {
+			clog.Error("Error: %v", err)
+		}
 		os.Exit(1)
 	}
 }
@@ -35,6 +73,14 @@ func init() {
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only print warnings, errors and results")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Print debug messages")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output (also disabled by NO_COLOR or a non-terminal output)")
+	rootCmd.PersistentFlags().String("output", "text", "Output format of results: text, json or yaml")
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "Answer yes to confirmations and use defaults for other prompts (non-interactive)")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
 	// Set the root command to not print usage on error
 	rootCmd.SilenceUsage = true
+	// Execute prints errors through clog
+	rootCmd.SilenceErrors = true
 }
@@ -4,6 +4,9 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"maps"
+	"slices"
+
 	"github.com/byte4cat/nbx/v2/internal/script"
 	"github.com/byte4cat/nbx/v2/pkg/clog"
 	"github.com/spf13/cobra"
@@ -15,7 +18,7 @@ var scriptCmd = &cobra.Command{
 	Short: "Script",
 	Long: `Script is a collection of scripts that can be used to automate tasks.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// 取得 flags
 		platform, _ := cmd.Flags().GetString("platform")
 		scriptName, _ := cmd.Flags().GetString("script")
@@ -24,39 +27,73 @@ var scriptCmd = &cobra.Command{
 		// 查找平台
 		platformScripts, ok := script.Registry[platform]
 		if !ok {
-			clog.Error("Unsupported platform: %s", platform)
+			err := clog.Fail("Unsupported platform: %s", platform)
 			clog.Info("Supported platforms")
 			for p := range script.Registry {
 				clog.Item(p)
 			}
-			clog.Info("Hint: try `--platform <platform> --script <script>`")
-			return
+			clog.Info("Hint: try `--platform <platform> --script <script>` or `nbx script list`")
+			return err
 		}
 
 		// 查找腳本
 		fn, ok := platformScripts[scriptName]
 		if !ok {
-			clog.Error("Unknown script: %s for platform: %s", scriptName, platform)
+			err := clog.Fail("Unknown script: %s for platform: %s", scriptName, platform)
 			clog.Info("Available scripts for platform '%s':", platform)
 			for name := range platformScripts {
 				clog.Item(name)
 			}
 			clog.Info("Hint: try `--platform %s --script <name>`", platform)
-			return
+			return err
 		}
 
 		if dryRun {
 			clog.Info("Dry run script: %s on platform: %s", scriptName, platform)
-			clog.Console(fn.DryRun())
-		} else {
-			clog.Info("Running script: %s on platform: %s", scriptName, platform)
-			fn.Run()
+			clog.Print(fn.DryRun())
+			return nil
+		}
+
+		clog.Info("Running script: %s on platform: %s", scriptName, platform)
+		return fn.Run()
+	},
+}
+
+// scriptListCmd represents the script list command
+var scriptListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the scripts of every platform",
+	Long: `List the scripts of every platform, as a table or with --tree as a tree.
+
+Example:
+  nbx script list
+  nbx script list --tree
+  nbx script list --output json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asTree, _ := cmd.Flags().GetBool("tree")
+
+		table := clog.NewTable("Platform", "Script", "Steps")
+		tree := clog.NewTree("scripts")
+		for _, platform := range slices.Sorted(maps.Keys(script.Registry)) {
+			node := tree.Add(platform)
+			for _, name := range slices.Sorted(maps.Keys(script.Registry[platform])) {
+				table.AddRow(platform, name, script.Registry[platform][name].Steps())
+				node.Add(name)
+			}
 		}
+
+		if asTree {
+			return clog.PrintTree(tree)
+		}
+		return clog.PrintTable(table)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(scriptCmd)
+	scriptCmd.AddCommand(scriptListCmd)
+
+	scriptListCmd.Flags().Bool("tree", false, "Print the scripts as a tree of platforms")
 
 	scriptCmd.Flags().StringP("platform", "p", "", "The platform to run the script on")
 	scriptCmd.Flags().StringP("script", "s", "", "The script to run")
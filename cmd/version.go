@@ -1,9 +1,9 @@
 package cmd
 
 import (
-	"fmt"
 	"runtime"
 
+	"github.com/byte4cat/nbx/v2/pkg/clog"
 	"github.com/spf13/cobra"
 )
 
@@ -18,12 +18,14 @@ var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version number of nbx",
 	Long:  `All software has versions. This is nbx's`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("Nbx version: %s\n", version)
-		fmt.Printf("Git Commit: %s\n", commit)
-		fmt.Printf("Build Date: %s\n", date)
-		fmt.Printf("Go Version: %s\n", runtime.Version())
-		fmt.Printf("OS/Arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return clog.PrintKV(
+			clog.KV{Key: "Nbx Version", Value: version},
+			clog.KV{Key: "Git Commit", Value: commit},
+			clog.KV{Key: "Build Date", Value: date},
+			clog.KV{Key: "Go Version", Value: runtime.Version()},
+			clog.KV{Key: "OS/Arch", Value: runtime.GOOS + "/" + runtime.GOARCH},
+		)
 	},
 }
 
@@ -0,0 +1,73 @@
+// Code generated by nbx dbugen. DO NOT EDIT.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// RDBUpdateMap returns the map of dbu.BuildRDBUpdateMap(x, skip) without
+// reflection, with the columns named by dbu.DefaultSnakeCaseNamer.
+func (x *UpdateTravelRequest) RDBUpdateMap(skip ...string) (map[string]any, error) {
+	m := make(map[string]any)
+	if x == nil {
+		return m, nil
+	}
+	if !slices.Contains(skip, "id") {
+		m["id"] = x.ID
+	}
+	if x.Name != nil && !slices.Contains(skip, "name") {
+		m["name"] = *x.Name
+	}
+	if x.IsPublic != nil && !slices.Contains(skip, "is_public") {
+		m["is_public"] = *x.IsPublic
+	}
+	if !slices.Contains(skip, "tags") {
+		b, err := json.Marshal(x.Tags)
+		if err != nil {
+			return m, fmt.Errorf("failed to marshal field %s (%s) to JSON: %w", "Tags", "tags", err)
+		}
+		m["tags"] = json.RawMessage(b)
+	}
+	if x.Details != nil {
+		if x.Details.Text != nil && !slices.Contains(skip, "details_text") {
+			m["details_text"] = *x.Details.Text
+		}
+		if x.Details.PDF != nil && !slices.Contains(skip, "details_pdf") {
+			m["details_pdf"] = *x.Details.PDF
+		}
+	}
+	return m, nil
+}
+
+// MongoUpdateMap returns the map of dbu.BuildMongoUpdateMap(x, skip)
+// without reflection.
+func (x *UpdateTravelRequest) MongoUpdateMap(skip ...string) map[string]any {
+	m := make(map[string]any)
+	if x == nil {
+		return m
+	}
+	if !slices.Contains(skip, "id") {
+		m["id"] = x.ID
+	}
+	if x.Name != nil && !slices.Contains(skip, "name") {
+		m["name"] = *x.Name
+	}
+	if x.IsPublic != nil && !slices.Contains(skip, "isPublic") {
+		m["isPublic"] = *x.IsPublic
+	}
+	if !slices.Contains(skip, "tags") {
+		m["tags"] = x.Tags
+	}
+	if x.Details != nil && !slices.Contains(skip, "details") {
+		if x.Details.Text != nil && !slices.Contains(skip, "details.text") {
+			m["details.text"] = *x.Details.Text
+		}
+		if x.Details.PDF != nil && !slices.Contains(skip, "details.pdf") {
+			m["details.pdf"] = *x.Details.PDF
+		}
+	}
+	return m
+}
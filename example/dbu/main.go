@@ -1 +1,54 @@
 package main
+
+import (
+	"fmt"
+
+	"github.com/byte4cat/nbx/v2/pkg/dbu"
+)
+
+//go:generate go run ../.. dbugen --type UpdateTravelRequest
+
+type TravelDetails struct {
+	Text *string `json:"text"`
+	PDF  *string `json:"pdf"`
+}
+
+type UpdateTravelRequest struct {
+	ID       string         `json:"id"`
+	Name     *string        `json:"name"`
+	IsPublic *bool          `json:"isPublic"`
+	Tags     []string       `gorm:"type:jsonb" json:"tags"`
+	Details  *TravelDetails `gorm:"embedded;embeddedPrefix:details_" json:"details"`
+}
+
+func main() {
+	name, text := "Taipei Trip", "hello"
+	req := &UpdateTravelRequest{
+		ID:      "123",
+		Name:    &name,
+		Tags:    []string{"city", "food"},
+		Details: &TravelDetails{Text: &text},
+	}
+
+	// reflective, with the tags parsed once per type
+	reflected, err := dbu.BuildRDBUpdateMap(req, []string{"id"})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("BuildRDBUpdateMap:  ", reflected)
+
+	// generated by nbx dbugen, same result without reflection
+	generated, err := req.RDBUpdateMap("id")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("RDBUpdateMap:       ", generated)
+
+	// nested structs are flattened into dotted paths for $set
+	mongo, err := dbu.BuildMongoUpdateMap(req, []string{"id"})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("BuildMongoUpdateMap:", mongo)
+	fmt.Println("MongoUpdateMap:     ", req.MongoUpdateMap("id"))
+}
@@ -0,0 +1,407 @@
+// Package dbugen generates reflection-free update-map methods for structs,
+// producing the same maps as dbu.BuildRDBUpdateMap and
+// dbu.BuildMongoUpdateMap.
+package dbugen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/byte4cat/nbx/v2/internal/dbutag"
+	"github.com/byte4cat/nbx/v2/pkg/dbu"
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultOutput is the file name of the generated code in the package
+// directory.
+const DefaultOutput = "dbu_gen.go"
+
+type Config struct {
+	Dir     string   // directory the package pattern is resolved in, default: the working directory
+	Pattern string   // package to load, default: "."
+	Types   []string // struct types to generate methods for
+	Output  string   // generated file, default: DefaultOutput in the package directory
+	RDB     bool     // generate RDBUpdateMap
+	Mongo   bool     // generate MongoUpdateMap
+}
+
+// Generate writes the update-map methods of the types of cfg to the output
+// file and returns its path.
+func Generate(cfg Config) (string, error) {
+	if len(cfg.Types) == 0 {
+		return "", errors.New("no types to generate")
+	}
+	if !cfg.RDB && !cfg.Mongo {
+		return "", errors.New("neither RDB nor Mongo methods to generate")
+	}
+	if cfg.Pattern == "" {
+		cfg.Pattern = "."
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+		Dir:  cfg.Dir,
+	}, cfg.Pattern)
+	if err != nil {
+		return "", fmt.Errorf("error loading package %s: %w", cfg.Pattern, err)
+	}
+	if len(pkgs) != 1 {
+		return "", fmt.Errorf("pattern %s matches %d packages, want 1", cfg.Pattern, len(pkgs))
+	}
+	pkg := pkgs[0]
+	if err := packageErrors(pkg); err != nil {
+		return "", err
+	}
+	if len(pkg.GoFiles) == 0 {
+		return "", fmt.Errorf("package %s has no Go files", pkg.PkgPath)
+	}
+
+	output := cfg.Output
+	if output == "" {
+		output = filepath.Join(filepath.Dir(pkg.GoFiles[0]), DefaultOutput)
+	}
+
+	src, err := Source(pkg.Types, cfg.Types, cfg.RDB, cfg.Mongo)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(output, src, 0644); err != nil {
+		return "", fmt.Errorf("error writing file %s: %w", output, err)
+	}
+	return output, nil
+}
+
+// packageErrors returns the errors that kept pkg from loading. Type
+// errors are ignored: the structs are usable even when the package does
+// not compile yet, e.g. because it calls the methods to be generated or
+// the generated code is stale.
+func packageErrors(pkg *packages.Package) error {
+	var errs []error
+	for _, e := range pkg.Errors {
+		if e.Kind != packages.TypeError {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error loading package %s: %w", pkg.PkgPath, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Source returns the formatted source of the update-map methods of the
+// named struct types of pkg.
+func Source(pkg *types.Package, typeNames []string, rdb, mongo bool) ([]byte, error) {
+	g := &generator{}
+	for _, name := range typeNames {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Path())
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			return nil, fmt.Errorf("type %s is not a non-generic named type", name)
+		}
+		st, ok := named.Underlying().(*types.Struct)
+		if !ok {
+			return nil, fmt.Errorf("type %s is not a struct", name)
+		}
+		if rdb {
+			g.rdbMethod(name, st)
+			g.checkKeys(name, "column", false)
+		}
+		if mongo {
+			g.mongoMethod(name, st, named)
+			g.checkKeys(name, "path", true)
+		}
+	}
+	if err := errors.Join(g.errs...); err != nil {
+		return nil, err
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by nbx dbugen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg.Name())
+	src.WriteString("import (\n")
+	if g.usesJSON {
+		src.WriteString("\t\"encoding/json\"\n\t\"fmt\"\n")
+	}
+	if g.usesSlices {
+		src.WriteString("\t\"slices\"\n")
+	}
+	src.WriteString(")\n")
+	src.Write(g.buf.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format code: %v", err)
+	}
+	return formatted, nil
+}
+
+type generator struct {
+	buf        bytes.Buffer
+	usesJSON   bool
+	usesSlices bool
+	fields     []string // Go paths of the fields of the current method
+	keys       []string // their columns or paths
+	errs       []error
+}
+
+// key records the column or path of the field at the expression expr, to
+// be checked by checkKeys.
+func (g *generator) key(expr, key string) {
+	g.fields = append(g.fields, strings.TrimPrefix(expr, "x."))
+	g.keys = append(g.keys, key)
+}
+
+// checkKeys records an error for each conflict between the keys of the
+// method of the type name, as the reflective builders reject them, and
+// resets the keys.
+func (g *generator) checkKeys(name, kind string, nested bool) {
+	for _, c := range dbutag.KeyConflicts(g.fields, g.keys, kind, nested) {
+		g.errs = append(g.errs, fmt.Errorf("%s: %s", name, c.Problem))
+	}
+	g.fields, g.keys = nil, nil
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// rdbMethod writes the RDBUpdateMap method of the struct type name.
+func (g *generator) rdbMethod(name string, st *types.Struct) {
+	g.printf("\n// RDBUpdateMap returns the map of dbu.BuildRDBUpdateMap(x, skip) without\n")
+	g.printf("// reflection, with the columns named by dbu.DefaultSnakeCaseNamer.\n")
+	g.printf("func (x *%s) RDBUpdateMap(skip ...string) (map[string]any, error) {\n", name)
+	g.printf("m := make(map[string]any)\n")
+	g.printf("if x == nil {\nreturn m, nil\n}\n")
+	g.rdbFields(st, "x", "")
+	g.printf("return m, nil\n}\n")
+}
+
+// rdbFields writes the statements setting the columns of the struct st at
+// the expression expr, flattening embedded structs as BuildRDBUpdateMap.
+func (g *generator) rdbFields(st *types.Struct, expr, prefix string) {
+	for i := range st.NumFields() {
+		field := st.Field(i)
+		if !field.Exported() {
+			continue
+		}
+		tag := reflect.StructTag(st.Tag(i))
+		fieldExpr := expr + "." + field.Name()
+		ptr, elem := pointer(field.Type())
+
+		if embeddedPrefix, ok := dbutag.RDBEmbedded(tag); ok {
+			if inner, ok := elem.Underlying().(*types.Struct); ok {
+				if ptr {
+					g.printf("if %s != nil {\n", fieldExpr)
+					g.rdbFields(inner, fieldExpr, prefix+embeddedPrefix)
+					g.printf("}\n")
+				} else {
+					g.rdbFields(inner, fieldExpr, prefix+embeddedPrefix)
+				}
+				continue
+			}
+		}
+
+		column, jsonb := dbutag.RDBColumn(field.Name(), tag, dbu.DefaultSnakeCaseNamer)
+		if column == "" {
+			continue
+		}
+		column = prefix + column
+		g.key(fieldExpr, column)
+
+		g.usesSlices = true
+		cond := fmt.Sprintf("!slices.Contains(skip, %q)", column)
+		if nullable(elem) {
+			g.printf("if %s {\n", nilCheck(ptr, fieldExpr, fieldExpr+".IsSet() && "+cond))
+			g.printf("if v, ok := %s.Get(); ok {\n", fieldExpr)
+			g.rdbValue(field.Name(), column, "v", jsonb)
+			g.printf("} else {\nm[%q] = nil\n}\n}\n", column)
+			continue
+		}
+		value := fieldExpr
+		if ptr {
+			value = "*" + fieldExpr
+		}
+		g.printf("if %s {\n", nilCheck(ptr, fieldExpr, cond))
+		g.rdbValue(field.Name(), column, value, jsonb)
+		g.printf("}\n")
+	}
+}
+
+// rdbValue writes the statements setting column to the expression value,
+// marshaled to JSON for jsonb columns.
+func (g *generator) rdbValue(fieldName, column, value string, jsonb bool) {
+	if !jsonb {
+		g.printf("m[%q] = %s\n", column, value)
+		return
+	}
+	g.usesJSON = true
+	g.printf("b, err := json.Marshal(%s)\n", value)
+	g.printf("if err != nil {\n")
+	g.printf("return m, fmt.Errorf(\"failed to marshal field %%s (%%s) to JSON: %%w\", %q, %q, err)\n", fieldName, column)
+	g.printf("}\n")
+	g.printf("m[%q] = json.RawMessage(b)\n", column)
+}
+
+// mongoMethod writes the MongoUpdateMap method of the struct type name.
+func (g *generator) mongoMethod(name string, st *types.Struct, named *types.Named) {
+	g.printf("\n// MongoUpdateMap returns the map of dbu.BuildMongoUpdateMap(x, skip)\n")
+	g.printf("// without reflection.\n")
+	g.printf("func (x *%s) MongoUpdateMap(skip ...string) map[string]any {\n", name)
+	g.printf("m := make(map[string]any)\n")
+	g.printf("if x == nil {\nreturn m\n}\n")
+	g.mongoFields(st, "x", "", []types.Type{named})
+	g.printf("return m\n}\n")
+}
+
+// mongoFields writes the statements setting the paths of the struct st at
+// the expression expr, flattening nested structs as BuildMongoUpdateMap.
+// seen holds the struct types being flattened.
+func (g *generator) mongoFields(st *types.Struct, expr, prefix string, seen []types.Type) {
+	for i := range st.NumFields() {
+		field := st.Field(i)
+		if !field.Exported() {
+			continue
+		}
+		tag := reflect.StructTag(st.Tag(i))
+		fieldExpr := expr + "." + field.Name()
+		ptr, elem := pointer(field.Type())
+		inner, document := mongoDocument(elem)
+		document = document && dbutag.MongoOperator(tag) == "" &&
+			!slices.ContainsFunc(seen, func(t types.Type) bool { return types.Identical(t, elem) })
+
+		if document && dbutag.MongoInline(tag) {
+			if ptr {
+				g.printf("if %s != nil {\n", fieldExpr)
+			}
+			g.mongoFields(inner, fieldExpr, prefix, append(seen, elem))
+			if ptr {
+				g.printf("}\n")
+			}
+			continue
+		}
+
+		key := dbutag.MongoKey(field.Name(), tag)
+		if key == "" {
+			continue
+		}
+		path := prefix + key
+		if !document {
+			g.key(fieldExpr, path)
+		}
+
+		g.usesSlices = true
+		cond := fmt.Sprintf("!slices.Contains(skip, %q)", path)
+		switch {
+		case document:
+			g.printf("if %s {\n", nilCheck(ptr, fieldExpr, cond))
+			g.mongoFields(inner, fieldExpr, path+".", append(seen, elem))
+			g.printf("}\n")
+		case nullable(elem):
+			g.printf("if %s {\n", nilCheck(ptr, fieldExpr, fieldExpr+".IsSet() && "+cond))
+			g.printf("if v, ok := %s.Get(); ok {\nm[%q] = v\n} else {\nm[%q] = nil\n}\n}\n", fieldExpr, path, path)
+		default:
+			value := fieldExpr
+			if ptr {
+				value = "*" + fieldExpr
+			}
+			g.printf("if %s {\nm[%q] = %s\n}\n", nilCheck(ptr, fieldExpr, cond), path, value)
+		}
+	}
+}
+
+// mongoValueTypes are the struct types the default bson registry encodes
+// with codecs of their own, which BuildMongoUpdateMap sets as a whole, by
+// package path, with "" for every type of the package.
+var mongoValueTypes = map[string]string{
+	"time":    "Time",
+	"net/url": "URL",
+	"go.mongodb.org/mongo-driver/bson/primitive": "",
+}
+
+// mongoDocument reports whether BuildMongoUpdateMap flattens the fields of
+// values of type t, and returns its struct.
+func mongoDocument(t types.Type) (*types.Struct, bool) {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok || !hasExportedFields(st) {
+		return nil, false
+	}
+	if named, ok := t.(*types.Named); ok {
+		obj := named.Obj()
+		if obj.Pkg() != nil {
+			if name, ok := mongoValueTypes[obj.Pkg().Path()]; ok && (name == "" || name == obj.Name()) {
+				return nil, false
+			}
+		}
+	}
+	methods := types.NewMethodSet(types.NewPointer(t))
+	for _, name := range dbutag.MongoValueMethods {
+		if methods.Lookup(nil, name) != nil {
+			return nil, false
+		}
+	}
+	return st, true
+}
+
+// hasExportedFields reports whether st has exported fields, including the
+// fields promoted from embedded structs, as reflect.VisibleFields lists.
+func hasExportedFields(st *types.Struct) bool {
+	for i := range st.NumFields() {
+		f := st.Field(i)
+		if f.Exported() {
+			return true
+		}
+		if !f.Embedded() {
+			continue
+		}
+		t := f.Type()
+		if p, ok := t.Underlying().(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		if inner, ok := t.Underlying().(*types.Struct); ok && hasExportedFields(inner) {
+			return true
+		}
+	}
+	return false
+}
+
+// nilCheck prepends the check that the pointer expr is not nil to cond if
+// ptr is set.
+func nilCheck(ptr bool, expr, cond string) string {
+	if ptr {
+		return expr + " != nil && " + cond
+	}
+	return cond
+}
+
+// nullablePath is the package of dbu.Nullable.
+var nullablePath = reflect.TypeFor[dbu.Nullable[int]]().PkgPath()
+
+// nullable reports whether t is an instance of dbu.Nullable.
+func nullable(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Origin().Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == nullablePath && obj.Name() == "Nullable"
+}
+
+// pointer reports whether t is a pointer, as reflect.Pointer kinds are, and
+// returns the type it points to, or t.
+func pointer(t types.Type) (bool, types.Type) {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		return true, p.Elem()
+	}
+	return false, t
+}
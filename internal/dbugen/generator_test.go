@@ -0,0 +1,93 @@
+package dbugen_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/byte4cat/nbx/v2/internal/dbugen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// modelsDir holds the structs and the generated code the parity tests run
+// against.
+var modelsDir = filepath.Join("..", "..", "tests", "dbugen", "models")
+
+func TestGenerate(t *testing.T) {
+	t.Run("[SUCCESS] should reproduce the generated code of the parity tests", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "dbu_gen.go")
+
+		path, err := dbugen.Generate(dbugen.Config{
+			Dir:    modelsDir,
+			Types:  []string{"Travel", "Account"},
+			Output: output,
+			RDB:    true,
+			Mongo:  true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, output, path)
+
+		actual, err := os.ReadFile(output)
+		require.NoError(t, err)
+		expected, err := os.ReadFile(filepath.Join(modelsDir, dbugen.DefaultOutput))
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual), "run go generate ./tests/dbugen/...")
+	})
+
+	t.Run("[SUCCESS] should generate only the requested methods", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "account.go")
+
+		_, err := dbugen.Generate(dbugen.Config{Dir: modelsDir, Types: []string{"Account"}, Output: output, Mongo: true})
+		require.NoError(t, err)
+
+		src, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Contains(t, string(src), "func (x *Account) MongoUpdateMap(skip ...string) map[string]any {")
+		assert.NotContains(t, string(src), "RDBUpdateMap")
+		assert.NotContains(t, string(src), "encoding/json")
+	})
+
+	t.Run("[FAILURE] should reject unknown types and empty configs", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "out.go")
+
+		_, err := dbugen.Generate(dbugen.Config{Dir: modelsDir, Types: []string{"Missing"}, Output: output, RDB: true})
+		assert.EqualError(t, err, "type Missing not found in package github.com/byte4cat/nbx/v2/tests/dbugen/models")
+
+		_, err = dbugen.Generate(dbugen.Config{Dir: modelsDir, Types: []string{"Travel"}, Output: output})
+		assert.EqualError(t, err, "neither RDB nor Mongo methods to generate")
+
+		_, err = dbugen.Generate(dbugen.Config{Dir: modelsDir, RDB: true})
+		assert.EqualError(t, err, "no types to generate")
+		assert.NoFileExists(t, output)
+	})
+	t.Run("[FAILURE] should reject types whose keys conflict", func(t *testing.T) {
+		src := `package models
+
+type Audit struct {
+	ID string ` + "`json:\"id\"`" + `
+}
+
+type Order struct {
+	ID    string ` + "`json:\"id\"`" + `
+	Audit Audit ` + "`gorm:\"embedded\" bson:\",inline\"`" + `
+	Geo   string ` + "`bson:\"geo\"`" + `
+	Lat   string ` + "`bson:\"geo.lat\"`" + `
+}
+`
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "models.go", src, 0)
+		require.NoError(t, err)
+		pkg, err := new(types.Config).Check("models", fset, []*ast.File{file}, nil)
+		require.NoError(t, err)
+
+		_, err = dbugen.Source(pkg, []string{"Order"}, true, true)
+		assert.EqualError(t, err, `Order: fields ID and Audit.ID both map to column "id"
+Order: fields ID and Audit.ID both map to path "id"
+Order: field Geo sets path "geo", which holds path "geo.lat" of field Lat`)
+	})
+}
@@ -0,0 +1,261 @@
+// Package dbutag holds the struct tag rules of the dbu update-map builders,
+// shared by the reflective builders in pkg/dbu and the code generated by
+// nbx dbugen so that both name fields the same way.
+package dbutag
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RDBEmbedded reports whether a field is flattened by BuildRDBUpdateMap,
+// i.e. it has the GORM tag `embedded`, and returns the `embeddedPrefix` of
+// its columns.
+func RDBEmbedded(tag reflect.StructTag) (prefix string, ok bool) {
+	gormTag := tag.Get("gorm")
+	if !strings.Contains(gormTag, "embedded") {
+		return "", false
+	}
+	return GormSetting(gormTag, "embeddedPrefix"), true
+}
+
+// RDBColumn returns the column of a field for BuildRDBUpdateMap, or "" if
+// the field has none, and whether its value is stored as jsonb.
+//
+// The column is the first non-empty of: the `gorm:"column:..."` setting,
+// the `bson` tag name, then the `json` tag name for jsonb fields and namer
+// for the others, each falling back to the other.
+func RDBColumn(fieldName string, tag reflect.StructTag, namer func(string) string) (column string, jsonb bool) {
+	column, _, jsonb = RDBColumnSource(fieldName, tag, namer)
+	return column, jsonb
+}
+
+// RDBColumnSource returns the column of a field and whether it is stored as
+// jsonb, as RDBColumn does, and the source of the column: "gorm" for the
+// `column` setting of the GORM tag, "bson", "json", or "namer".
+func RDBColumnSource(fieldName string, tag reflect.StructTag, namer func(string) string) (column, source string, jsonb bool) {
+	jsonb = RDBJSONB(tag)
+	sources := []string{"gorm", "bson", "namer", "json"}
+	if jsonb {
+		sources = []string{"gorm", "bson", "json", "namer"}
+	}
+	for _, source := range sources {
+		if column := rdbName(fieldName, tag, namer, source); column != "" {
+			return column, source, jsonb
+		}
+	}
+	return "", "", jsonb
+}
+
+// RDBColumnByPriority returns the column of a field for RDBUpdateMap with
+// WithTagPriority, and its source as for RDBColumnSource: the name given by
+// the first of the tags in priority order naming the field, where "gorm"
+// stands for the `column` setting of the GORM tag, otherwise
+// namer(fieldName).
+func RDBColumnByPriority(fieldName string, tag reflect.StructTag, namer func(string) string, priority []string) (column, source string) {
+	for _, source := range append(priority[:len(priority):len(priority)], "namer") {
+		if column := rdbName(fieldName, tag, namer, source); column != "" {
+			return column, source
+		}
+	}
+	return "", ""
+}
+
+// rdbName returns the column of a field given by source, or "".
+func rdbName(fieldName string, tag reflect.StructTag, namer func(string) string, source string) string {
+	switch source {
+	case "gorm":
+		return GormSetting(tag.Get("gorm"), "column")
+	case "namer":
+		return namer(fieldName)
+	}
+	return Name(tag.Get(source))
+}
+
+// RDBTagProblems returns the ambiguities of the tags of a field for
+// BuildRDBUpdateMap: a `gorm:"column:..."` setting and a `bson` tag naming
+// different columns, of which the GORM one wins, and a jsonb field without
+// a GORM column, bson or json name, whose column falls back to its Go name.
+func RDBTagProblems(tag reflect.StructTag) []string {
+	var problems []string
+	column, bson := GormSetting(tag.Get("gorm"), "column"), Name(tag.Get("bson"))
+	if column != "" && bson != "" && column != bson {
+		problems = append(problems, fmt.Sprintf("gorm column %q and bson name %q differ, the column is %q", column, bson, column))
+	}
+	if RDBJSONB(tag) && column == "" && bson == "" && Name(tag.Get("json")) == "" {
+		problems = append(problems, "jsonb field has no gorm column, bson or json name, the column is named by the namer")
+	}
+	return problems
+}
+
+// Conflict is a problem between the keys of two fields, see KeyConflicts.
+type Conflict struct {
+	I, J    int // indexes of the fields
+	Problem string
+}
+
+// KeyConflicts returns the conflicts between the keys of fields, named
+// keys[i] for the Go field fields[i], in order: keys shared by two fields,
+// which would overwrite each other in an update map, and with nested set,
+// dotted paths under the path of another field, which MongoDB rejects in
+// one update. kind names the keys in the problems, e.g. "column".
+func KeyConflicts(fields, keys []string, kind string, nested bool) []Conflict {
+	var conflicts []Conflict
+	first := make(map[string]int, len(keys))
+	for j, key := range keys {
+		if i, ok := first[key]; ok {
+			conflicts = append(conflicts, Conflict{I: i, J: j,
+				Problem: fmt.Sprintf("fields %s and %s both map to %s %q", fields[i], fields[j], kind, key),
+			})
+			continue
+		}
+		first[key] = j
+	}
+	if !nested {
+		return conflicts
+	}
+	for j, key := range keys {
+		for parent := key; ; {
+			k := strings.LastIndexByte(parent, '.')
+			if k < 0 {
+				break
+			}
+			parent = parent[:k]
+			if i, ok := first[parent]; ok {
+				conflicts = append(conflicts, Conflict{I: i, J: j,
+					Problem: fmt.Sprintf("field %s sets %s %q, which holds %s %q of field %s", fields[i], kind, parent, kind, key, fields[j]),
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// OmitEmpty reports whether the `json` or `bson` tag of a field has the
+// omitempty option.
+func OmitEmpty(tag reflect.StructTag) bool {
+	for _, key := range []string{"json", "bson"} {
+		_, opts, _ := strings.Cut(tag.Get(key), ",")
+		if slices.Contains(strings.Split(opts, ","), "omitempty") {
+			return true
+		}
+	}
+	return false
+}
+
+// RDBJSONB reports whether a field is stored as jsonb, i.e. its GORM tag
+// contains `jsonb`, so BuildRDBUpdateMap marshals its value to JSON.
+func RDBJSONB(tag reflect.StructTag) bool {
+	return strings.Contains(tag.Get("gorm"), "jsonb")
+}
+
+// MongoKey returns the key of a field for BuildMongoUpdateMap, or "" if the
+// field is skipped: the first of the `bson`, `json` and `form` tags that is
+// set and not "-", otherwise the field name with a lower-case first letter.
+func MongoKey(fieldName string, tag reflect.StructTag) string {
+	key, _ := MongoKeySource(fieldName, tag)
+	return key
+}
+
+// MongoKeySource returns the key of a field as MongoKey does, and its
+// source: the tag "bson", "json" or "form", or "name" for the field name.
+func MongoKeySource(fieldName string, tag reflect.StructTag) (key, source string) {
+	for _, source := range []string{"bson", "json", "form"} {
+		if v := tag.Get(source); v != "" && v != "-" {
+			name, _, _ := strings.Cut(v, ",")
+			return name, source
+		}
+	}
+	return LowerFirst(fieldName), "name"
+}
+
+// MongoInline reports whether a field has the `bson:",inline"` option, so
+// BuildMongoUpdateMap sets the fields of its struct at the level of the
+// struct holding it.
+func MongoInline(tag reflect.StructTag) bool {
+	_, opts, _ := strings.Cut(tag.Get("bson"), ",")
+	return slices.Contains(strings.Split(opts, ","), "inline")
+}
+
+// MongoValueMethods are the methods of the struct types that marshal
+// themselves to BSON. BuildMongoUpdateMap sets such structs, as well as
+// time.Time, as a whole instead of flattening their fields.
+var MongoValueMethods = []string{"MarshalBSON", "MarshalBSONValue"}
+
+// MongoOperator returns the update operator of a field for dbu.MongoUpdate
+// from the options of its `dbu` tag: "$inc" for `dbu:"inc"`, "$push" for
+// `dbu:"push"`, "$setOnInsert" for `dbu:"setOnInsert"`, or "" for $set.
+// Struct fields with an operator are never flattened into dotted paths.
+func MongoOperator(tag reflect.StructTag) string {
+	for opt := range strings.SplitSeq(tag.Get("dbu"), ",") {
+		switch strings.TrimSpace(opt) {
+		case "inc":
+			return "$inc"
+		case "push":
+			return "$push"
+		case "setOnInsert":
+			return "$setOnInsert"
+		}
+	}
+	return ""
+}
+
+// FilterOptions returns the `col` and `op` options of the `dbu` tag of a
+// field of a filter struct for dbu.ApplyFilter and dbu.BuildMongoFilter,
+// e.g. "age" and "gte" for `dbu:"col=age,op=gte"`, or "" for the options
+// that are not set.
+func FilterOptions(tag reflect.StructTag) (column, op string) {
+	for opt := range strings.SplitSeq(tag.Get("dbu"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		switch key {
+		case "col":
+			column = value
+		case "op":
+			op = value
+		}
+	}
+	return column, op
+}
+
+// GormSetting returns the value of the setting key in a GORM tag, e.g.
+// "name" for the key "column" in `gorm:"column:name;not null"`.
+func GormSetting(tag, key string) string {
+	for part := range strings.SplitSeq(tag, ";") {
+		if after, ok := strings.CutPrefix(strings.TrimSpace(part), key+":"); ok {
+			return strings.TrimSpace(after)
+		}
+	}
+	return ""
+}
+
+// Name returns the name of a bson or json tag, or "" if the tag has no name
+// or is "-".
+func Name(tag string) string {
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+// LowerFirst converts the first character of a string to lowercase.
+// It handles empty strings and single-rune strings.
+// This is used as the default key naming fallback for BuildMongoUpdateMap.
+func LowerFirst(s string) string {
+	if s == "" {
+		return ""
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError && size <= 1 {
+		return s // Handle errors or empty/invalid runes
+	}
+	lc := unicode.ToLower(r)
+	if r == lc {
+		return s // First character is already lowercase or not a letter
+	}
+	return string(lc) + s[size:]
+}
@@ -3,7 +3,6 @@ package command
 import (
 	"bufio"
 	"bytes"
-	"fmt"
 	"io"
 	"os/exec"
 	"sync"
@@ -13,9 +12,9 @@ import (
 )
 
 // Run executes a command and prints its output line by line in real-time
-func Run(command string, showCommand bool) {
+func Run(command string, showCommand bool) error {
 	if showCommand {
-		color.HiBlue("$ %v", command)
+		clog.Print(color.HiBlueString("$ %v\n", command))
 	}
 
 	// default shell is bash
@@ -24,17 +23,17 @@ func Run(command string, showCommand bool) {
 	// get the stdout and stderr pipes
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
-		clog.Panic("failed to create stdout pipe: %v", err)
+		return clog.Fail("failed to create stdout pipe: %w", err)
 	}
 	stderrPipe, err := cmd.StderrPipe()
 	if err != nil {
-		clog.Panic("failed to create stderr pipe: %v", err)
+		return clog.Fail("failed to create stderr pipe: %w", err)
 	}
 
 	// start the command
 	err = cmd.Start()
 	if err != nil {
-		clog.Panic("failed to start command: %v", err)
+		return clog.Fail("failed to start command: %w", err)
 	}
 
 	var wg sync.WaitGroup
@@ -47,7 +46,7 @@ func Run(command string, showCommand bool) {
 
 		scanner := bufio.NewScanner(stdoutPipe)
 		for scanner.Scan() {
-			fmt.Println(scanner.Text()) // print each line immediately
+			clog.Print(scanner.Text() + "\n") // print each line immediately
 		}
 		if err := scanner.Err(); err != nil && err != io.EOF {
 			// ignore io.EOF error, it's expected when the command ends
@@ -79,28 +78,29 @@ func Run(command string, showCommand bool) {
 
 	// process the command error, exit code, etc.
 	if cmdErr != nil {
-		clog.Panic("command execution error: %v", cmdErr)
+		return clog.Fail("command execution error: %w", cmdErr)
 	}
+	return nil
 }
 
 // Return function is trickier with real-time printing AND returning a single string.
 // If you need both, you would need to stream and also buffer simultaneously.
 // The original Return function's behavior of printing AFTER buffering is incompatible with real-time printing.
 // If you need to capture the full output AND print real-time, you'd modify a function like this:
-func Return(command string, showCommand bool) string {
+func Return(command string, showCommand bool) (string, error) {
 	if showCommand {
-		color.HiBlue("$ %v", command)
+		clog.Print(color.HiBlueString("$ %v\n", command))
 	}
 
 	cmd := exec.Command("bash", "-c", command)
 
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
-		clog.Panic("failed to create stdout pipe for Return: %v", err)
+		return "", clog.Fail("failed to create stdout pipe for Return: %w", err)
 	}
 	stderrPipe, err := cmd.StderrPipe()
 	if err != nil {
-		clog.Panic("failed to create stderr pipe for Return: %v", err)
+		return "", clog.Fail("failed to create stderr pipe for Return: %w", err)
 	}
 
 	var wg sync.WaitGroup
@@ -112,7 +112,7 @@ func Return(command string, showCommand bool) string {
 	// Start command
 	err = cmd.Start()
 	if err != nil {
-		clog.Panic("failed to start command for Return: %v", err)
+		return "", clog.Fail("failed to start command for Return: %w", err)
 	}
 
 	// Goroutine to read stdout, print, and buffer
@@ -122,7 +122,7 @@ func Return(command string, showCommand bool) string {
 		scanner := bufio.NewScanner(stdoutPipe)
 		for scanner.Scan() {
 			line := scanner.Text()
-			fmt.Println(line)                  // Print line by line immediately
+			clog.Print(line + "\n")            // Print line by line immediately
 			stdoutBuf.WriteString(line + "\n") // Also write to buffer
 		}
 		if err := scanner.Err(); err != nil && err != io.EOF {
@@ -157,11 +157,11 @@ func Return(command string, showCommand bool) string {
 	if cmdErr != nil {
 		// The stderr goroutine already printed warnings.
 		// Here we just check if the command failed.
-		clog.Panic("command execution error for Return: %v", cmdErr)
+		return "", clog.Fail("command execution error for Return: %w", cmdErr)
 	}
 
 	// The original logic prints the buffer if not empty, then returns it.
 	// Since we already printed line by line, this final print might be redundant
 	// but we'll keep the original structure's intent of returning the full string.
-	return stdoutBuf.String()
+	return stdoutBuf.String(), nil
 }
@@ -7,6 +7,7 @@ import (
 // UbuntuInstallDocker installs docker on Ubuntu system
 func UbuntuInstallDocker() ScriptFunc {
 	return ScriptFunc{
+		Confirm:  "This adds the Docker apt key and repository and adds $USERNAME to the docker group. Continue?",
 		StartMsg: "Install Docker ...",
 		EndMsg:   "Docker installed successfully!",
 		ReplaceString: map[string]string{
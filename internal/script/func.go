@@ -1,6 +1,7 @@
 package script
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/byte4cat/nbx/v2/internal/script/command"
@@ -8,10 +9,16 @@ import (
 	"github.com/fatih/color"
 )
 
+// ErrCancelled is returned by Run when the confirmation is declined. The
+// cancellation is warned about already, so it is not printed again.
+var ErrCancelled = errors.New("script: cancelled")
+
 type Func interface {
-	// Run executes the ScriptFunc
-	Run()
+	// Run executes the ScriptFunc and stops at the first failing command
+	Run() error
 	DryRun() string
+	// Steps returns the number of commands of the script
+	Steps() int
 }
 
 type FuncImpl struct {
@@ -40,22 +47,64 @@ func (f *FuncImpl) DryRun() string {
 	return sb.String()
 }
 
+// Steps implements Func.
+func (f *FuncImpl) Steps() int {
+	return len(f.script.Commands)
+}
+
 // Run implements Func.
-func (f *FuncImpl) Run() {
+func (f *FuncImpl) Run() error {
+	if f.script.Confirm != "" {
+		question := f.script.Confirm
+		for k, v := range f.script.ReplaceString {
+			question = strings.ReplaceAll(question, k, v)
+		}
+		ok, err := clog.Confirm(question, false)
+		if err != nil {
+			return clog.Fail("%v (pass --yes to run the script without asking)", err)
+		}
+		if !ok {
+			clog.Warn("Cancelled")
+			return ErrCancelled
+		}
+	}
+
 	if f.script.StartMsg != "" {
 		clog.Line(f.script.StartMsg)
 	}
 
+	steps := clog.Default().Steps()
+	defer steps.Summary()
+
 	for _, cmd := range f.script.Commands {
 		for k, v := range f.script.ReplaceString {
 			cmd = strings.ReplaceAll(cmd, k, v)
 		}
-		command.Run(cmd, false)
+		// commands may prompt on the terminal, e.g. sudo for a password
+		step := steps.StartInteractive(stepName(cmd))
+		if err := command.Run(cmd, false); err != nil {
+			step.Fail(err)
+			return err
+		}
+		step.Done()
 	}
 
 	if f.script.EndMsg != "" {
 		clog.Line(f.script.EndMsg)
 	}
+	return nil
+}
+
+// stepName shortens cmd to its first line for the step summary.
+func stepName(cmd string) string {
+	name, _, multiline := strings.Cut(strings.TrimSpace(cmd), "\n")
+	name = strings.TrimSuffix(strings.TrimSpace(name), "\\")
+	if runes := []rune(name); len(runes) > 60 {
+		name = string(runes[:57]) + "..."
+	} else if multiline {
+		name += " ..."
+	}
+	return strings.TrimSpace(name)
 }
 
 func New(sf ScriptFunc) Func {
@@ -65,6 +114,9 @@ func New(sf ScriptFunc) Func {
 }
 
 type ScriptFunc struct {
+	// Confirm is asked before running scripts that change the system, e.g.
+	// by adding apt keys or changing user groups
+	Confirm       string
 	StartMsg      string
 	Commands      []string
 	EndMsg        string
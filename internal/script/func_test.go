@@ -0,0 +1,38 @@
+package script
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/byte4cat/nbx/v2/pkg/clog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncImpl_Run(t *testing.T) {
+	prev := clog.Default()
+	defer clog.SetDefault(prev)
+
+	t.Run("[FAILURE] should report a failing command exactly once", func(t *testing.T) {
+		var out bytes.Buffer
+		clog.SetDefault(clog.NewConsole(&out, &clog.Options{NoColor: true}))
+
+		err := New(ScriptFunc{Commands: []string{"exit 3", "echo unreachable"}}).Run()
+		require.Error(t, err)
+		assert.True(t, clog.Reported(err))
+		assert.Equal(t, 1, strings.Count(out.String(), "exit status 3"))
+		assert.Contains(t, out.String(), "✗ exit 3")
+		assert.NotContains(t, out.String(), "unreachable")
+	})
+
+	t.Run("[FAILURE] should return ErrCancelled when the confirmation is declined", func(t *testing.T) {
+		var out bytes.Buffer
+		clog.SetDefault(clog.NewConsole(&out, &clog.Options{NoColor: true, In: strings.NewReader("n\n")}))
+
+		err := New(ScriptFunc{Confirm: "Change the system?", Commands: []string{"echo ran"}}).Run()
+		assert.ErrorIs(t, err, ErrCancelled)
+		assert.Contains(t, out.String(), "Cancelled")
+		assert.NotContains(t, out.String(), "ran")
+	})
+}
@@ -1,49 +1,332 @@
+// Package clog prints command line output: banners, section lines, status
+// messages and lists, coloured when the output is a terminal.
+//
+// The package-level functions print through Default. Commands that need to
+// be tested or silenced create their own Console:
+//
+//	c := clog.NewConsole(cmd.OutOrStdout(), &clog.Options{Err: cmd.ErrOrStderr(), Verbosity: clog.Quiet})
 package clog
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sync"
+	"sync/atomic"
 
 	"github.com/common-nighthawk/go-figure"
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 )
 
+// Verbosity selects which messages a Console prints.
+type Verbosity int
+
+const (
+	// Quiet prints only warnings, errors and Print output.
+	Quiet Verbosity = iota - 1
+	// Normal prints everything except Debug messages.
+	Normal
+	// Verbose prints everything.
+	Verbose
+)
+
+type Options struct {
+	Err       io.Writer // warnings and errors, default: the output writer
+	Verbosity Verbosity
+
+	// Format selects how PrintTable, PrintTree and PrintKV print their data.
+	// With FormatJSON and FormatYAML all messages go to the Err writer, so
+	// the output holds only the data.
+	Format Format
+	Width  int  // columns of text output, default: the terminal width, then $COLUMNS, otherwise unlimited
+	ASCII  bool // draw trees with ASCII instead of Unicode characters
+
+	In  io.Reader // prompt answers, default: os.Stdin
+	Yes bool      // answer prompts with yes or their defaults without asking
+
+	// NoColor disables colours. Colours are also disabled when the NO_COLOR
+	// environment variable is set or the output is not a terminal.
+	NoColor bool
+}
+
+// Console prints command line output to a writer. It is safe for
+// concurrent use.
+type Console struct {
+	mu        sync.Mutex
+	out, err  io.Writer
+	msg       io.Writer // messages, spinners and progress bars
+	verbosity Verbosity
+	format    Format
+	width     int
+	ascii     bool
+	colored   bool
+	tty       bool // animate spinners and progress bars
+	live      liveLine
+
+	inMu   sync.Mutex // one prompt at a time
+	in     io.Reader
+	inTTY  bool
+	reader *bufio.Reader
+	yes    bool
+
+	green, yellow, red, blue, hiBlack *color.Color
+}
+
+// NewConsole returns a Console writing to out.
+func NewConsole(out io.Writer, opts *Options) *Console {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Err == nil {
+		o.Err = out
+	}
+
+	if o.In == nil {
+		o.In = os.Stdin
+	}
+
+	msg := out
+	if o.Format != FormatText {
+		msg = o.Err
+	}
+
+	tty := isTerminal(msg)
+	c := &Console{
+		out:       out,
+		err:       o.Err,
+		msg:       msg,
+		verbosity: o.Verbosity,
+		format:    o.Format,
+		width:     o.Width,
+		ascii:     o.ASCII,
+		in:        o.In,
+		inTTY:     isTerminal(o.In),
+		yes:       o.Yes,
+		colored:   !o.NoColor && os.Getenv("NO_COLOR") == "" && tty,
+		tty:       tty,
+		green:     color.New(color.FgGreen),
+		yellow:    color.New(color.FgYellow),
+		red:       color.New(color.FgRed),
+		blue:      color.New(color.FgBlue),
+		hiBlack:   color.New(color.FgHiBlack),
+	}
+	for _, col := range []*color.Color{c.green, c.yellow, c.red, c.blue, c.hiBlack} {
+		if c.colored {
+			col.EnableColor()
+		} else {
+			col.DisableColor()
+		}
+	}
+	return c
+}
+
+func isTerminal(v any) bool {
+	f, ok := v.(interface{ Fd() uintptr })
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// Colored reports whether the console prints colours.
+func (c *Console) Colored() bool {
+	return c.colored
+}
+
+// Verbosity returns the verbosity of the console.
+func (c *Console) Verbosity() Verbosity {
+	return c.verbosity
+}
+
+// Format returns the output format of the console.
+func (c *Console) Format() Format {
+	return c.format
+}
+
+// Out returns the writer of normal output.
+func (c *Console) Out() io.Writer {
+	return c.out
+}
+
+// Banner prints str in large ASCII art letters.
+func (c *Console) Banner(str string) {
+	if c.verbosity < Normal {
+		return
+	}
+	c.println(c.msg, c.green, figure.NewFigure(str, "", true).String())
+}
+
+// Line prints a section line.
+func (c *Console) Line(str string) {
+	if c.verbosity < Normal {
+		return
+	}
+	c.println(c.msg, c.blue, fmt.Sprintf("=====\t%v\t=====", str))
+}
+
+// Debug prints a message in verbose mode only.
+func (c *Console) Debug(format string, args ...any) {
+	if c.verbosity < Verbose {
+		return
+	}
+	c.println(c.msg, c.hiBlack, fmt.Sprintf(format, args...))
+}
+
+// Info prints a message.
+func (c *Console) Info(format string, args ...any) {
+	if c.verbosity < Normal {
+		return
+	}
+	c.println(c.msg, c.green, fmt.Sprintf(format, args...))
+}
+
+// Warn prints a warning, also in quiet mode.
+func (c *Console) Warn(format string, args ...any) {
+	c.println(c.err, c.yellow, fmt.Sprintf(format, args...))
+}
+
+// Error prints an error, also in quiet mode.
+func (c *Console) Error(format string, args ...any) {
+	c.println(c.err, c.red, fmt.Sprintf(format, args...))
+}
+
+// Fail prints an error like Error and returns it, so commands can return
+// it instead of exiting like Panic does. Arguments are formatted as by
+// fmt.Errorf, so %w wraps errors. Reported tells errors returned by Fail
+// apart, so they are not printed twice.
+func (c *Console) Fail(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	c.Error("%s", err.Error())
+	return &reportedError{err: err}
+}
+
+// Panic prints an error and exits the process with status 1.
+//
+// Deprecated: return the error of Fail instead, exiting makes commands
+// untestable.
+func (c *Console) Panic(format string, args ...any) {
+	c.Error(format, args...)
+	os.Exit(1)
+}
+
+// Item prints a list item.
+func (c *Console) Item(s string) {
+	if c.verbosity < Normal {
+		return
+	}
+	c.println(c.msg, c.blue, "  - "+s)
+}
+
+// Print prints s as is, also in quiet mode. Use it for the output a
+// command is run for.
+func (c *Console) Print(s string) {
+	c.write(c.out, s)
+}
+
+func (c *Console) println(w io.Writer, col *color.Color, s string) {
+	c.write(w, col.Sprintln(s))
+}
+
+// write writes s to w, above the spinner or progress bar on screen.
+func (c *Console) write(w io.Writer, s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeLocked(w, s)
+}
+
+func (c *Console) writeLocked(w io.Writer, s string) {
+	if s == "" {
+		return
+	}
+	if c.live != nil {
+		_, _ = io.WriteString(c.msg, clearLine)
+	}
+	_, _ = io.WriteString(w, s)
+	if c.live != nil {
+		_, _ = io.WriteString(c.msg, c.live.render())
+	}
+}
+
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string { return e.err.Error() }
+func (e *reportedError) Unwrap() error { return e.err }
+
+// Reported reports whether err, or an error it wraps, was returned by Fail
+// and so was printed already.
+func Reported(err error) bool {
+	var re *reportedError
+	return errors.As(err, &re)
+}
+
+var std atomic.Pointer[Console]
+
+func init() {
+	std.Store(NewConsole(os.Stdout, nil))
+}
+
+// Default returns the Console the package-level functions print through,
+// initially printing to stdout.
+func Default() *Console {
+	return std.Load()
+}
+
+// SetDefault makes c the Console of the package-level functions.
+func SetDefault(c *Console) {
+	std.Store(c)
+}
+
 // Banner prints a banner
 func Banner(str string) {
-	myFigure := figure.NewColorFigure(str, "", "green", true)
-	myFigure.Print()
-	fmt.Println()
+	Default().Banner(str)
 }
 
 // Line prints a line with a new line
 func Line(str string) {
-	color.Blue("=====\t%v\t=====\n", str)
+	Default().Line(str)
+}
+
+// Debug prints a string with a new line in verbose mode
+func Debug(format string, args ...any) {
+	Default().Debug(format, args...)
 }
 
-// Info prints a string with a new line with time
+// Info prints a string with a new line
 func Info(format string, args ...any) {
-	color.Green(fmt.Sprintf(format, args...))
+	Default().Info(format, args...)
 }
 
-// Warn prints a string with a new line with time
+// Warn prints a string with a new line
 func Warn(format string, args ...any) {
-	color.Yellow(fmt.Sprintf(format, args...))
+	Default().Warn(format, args...)
 }
 
-// Error prints a string with a new line with time
+// Error prints a string with a new line
 func Error(format string, args ...any) {
-	color.Red(fmt.Sprintf(format, args...))
+	Default().Error(format, args...)
+}
+
+// Fail prints a string with a new line and returns it as an error
+func Fail(format string, args ...any) error {
+	return Default().Fail(format, args...)
 }
 
+// Panic prints a string with a new line and exits.
+//
+// Deprecated: return the error of Fail instead.
 func Panic(format string, args ...any) {
-	color.Red(fmt.Sprintf(format, args...))
-	os.Exit(1)
+	Default().Panic(format, args...)
 }
 
+// Item prints a list item
 func Item(s string) {
-	color.Blue(fmt.Sprintf("  - %s", s))
+	Default().Item(s)
 }
 
-func Console(s string) {
-	fmt.Print(s)
+// Print prints a string as is
+func Print(s string) {
+	Default().Print(s)
 }
@@ -0,0 +1,86 @@
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsole(t *testing.T) {
+	t.Run("[SUCCESS] should write plain text to a non-terminal writer", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, nil)
+
+		c.Line("step")
+		c.Info("hello %s", "world")
+		c.Item("one")
+		c.Print("raw")
+
+		assert.False(t, c.Colored())
+		assert.Equal(t, "=====\tstep\t=====\nhello world\n  - one\nraw", out.String())
+	})
+
+	t.Run("[SUCCESS] should write warnings and errors to the error writer", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		c := NewConsole(&out, &Options{Err: &errOut})
+
+		c.Warn("careful")
+		c.Error("broken")
+
+		assert.Empty(t, out.String())
+		assert.Equal(t, "careful\nbroken\n", errOut.String())
+	})
+
+	t.Run("[SUCCESS] should respect the verbosity", func(t *testing.T) {
+		var out bytes.Buffer
+		quiet := NewConsole(&out, &Options{Verbosity: Quiet})
+		quiet.Banner("nbx")
+		quiet.Info("info")
+		quiet.Item("item")
+		quiet.Debug("debug")
+		quiet.Warn("warn")
+		quiet.Print("result\n")
+		assert.Equal(t, "warn\nresult\n", out.String())
+
+		out.Reset()
+		NewConsole(&out, nil).Debug("hidden")
+		assert.Empty(t, out.String())
+
+		NewConsole(&out, &Options{Verbosity: Verbose}).Debug("shown %d", 1)
+		assert.Equal(t, "shown 1\n", out.String())
+	})
+
+	t.Run("[SUCCESS] should disable colours with NO_COLOR", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		assert.False(t, NewConsole(&bytes.Buffer{}, nil).Colored())
+	})
+
+	t.Run("[FAILURE] should print and return errors from Fail", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, nil)
+
+		cause := errors.New("permission denied")
+		err := c.Fail("open config: %w", cause)
+
+		assert.EqualError(t, err, "open config: permission denied")
+		assert.ErrorIs(t, err, cause)
+		assert.True(t, Reported(err))
+		assert.True(t, Reported(fmt.Errorf("run: %w", err)))
+		assert.False(t, Reported(cause))
+		assert.Equal(t, "open config: permission denied\n", out.String())
+	})
+
+	t.Run("[SUCCESS] should print package-level output through Default", func(t *testing.T) {
+		prev := Default()
+		defer SetDefault(prev)
+
+		var out bytes.Buffer
+		SetDefault(NewConsole(&out, nil))
+		Info("via %s", "default")
+		Print("done")
+		assert.Equal(t, "via default\ndone", out.String())
+	})
+}
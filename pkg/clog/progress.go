@@ -0,0 +1,466 @@
+package clog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// clearLine returns the cursor to the start of the line and erases it.
+const clearLine = "\r\x1b[K"
+
+const (
+	spinnerInterval = 100 * time.Millisecond
+	progressWidth   = 30
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// liveLine is the spinner or progress bar drawn on the last line of a
+// terminal. Only one is drawn at a time.
+type liveLine interface {
+	render() string
+}
+
+// setLive draws l in place of the current live line.
+func (c *Console) setLive(l liveLine) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.live = l
+	_, _ = io.WriteString(c.msg, clearLine+l.render())
+}
+
+// redraw draws l again if it is the live line.
+func (c *Console) redraw(l liveLine) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.live == l {
+		_, _ = io.WriteString(c.msg, clearLine+l.render())
+	}
+}
+
+// endLive erases l if it is the live line and writes s in its place.
+func (c *Console) endLive(l liveLine, w io.Writer, s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.live == l {
+		c.live = nil
+		_, _ = io.WriteString(c.msg, clearLine)
+	}
+	c.writeLocked(w, s)
+}
+
+// animated reports whether spinners and progress bars are drawn in place.
+// Otherwise they print a line when they start and when they end.
+func (c *Console) animated() bool {
+	return c.tty && c.verbosity >= Normal
+}
+
+// Spinner shows that a task of unknown length is running. Finish it with
+// Success, Fail or Stop.
+type Spinner struct {
+	c     *Console
+	start time.Time
+
+	mu    sync.Mutex
+	msg   string
+	frame int
+
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Spinner starts a spinner with msg. On a terminal it is animated until it
+// is finished, otherwise msg is printed as a line.
+func (c *Console) Spinner(msg string) *Spinner {
+	return c.spinner(msg, c.animated())
+}
+
+func (c *Console) spinner(msg string, animate bool) *Spinner {
+	s := &Spinner{
+		c:     c,
+		start: time.Now(),
+		msg:   msg,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	if !animate {
+		close(s.done)
+		if c.verbosity >= Normal {
+			c.println(c.msg, c.blue, "==> "+msg)
+		}
+		return s
+	}
+
+	c.setLive(s)
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.mu.Lock()
+				s.frame = (s.frame + 1) % len(spinnerFrames)
+				s.mu.Unlock()
+				c.redraw(s)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+func (s *Spinner) render() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fmt.Sprintf("%s %s %s",
+		s.c.blue.Sprint(spinnerFrames[s.frame]), s.msg, s.c.hiBlack.Sprint(formatDuration(time.Since(s.start))))
+}
+
+// Update replaces the message of the spinner. Without a terminal it is
+// printed only in verbose mode.
+func (s *Spinner) Update(msg string) {
+	s.mu.Lock()
+	s.msg = msg
+	s.mu.Unlock()
+
+	if s.c.animated() {
+		s.c.redraw(s)
+	} else {
+		s.c.Debug("    %s", msg)
+	}
+}
+
+// Elapsed returns the time since the spinner started.
+func (s *Spinner) Elapsed() time.Duration {
+	return time.Since(s.start)
+}
+
+// Success finishes the spinner with a check mark, msg and the elapsed time.
+func (s *Spinner) Success(msg string) {
+	if s.c.verbosity < Normal {
+		s.Stop()
+		return
+	}
+	s.finish(s.c.msg, s.c.green.Sprint("✓")+" "+msg+" "+s.c.hiBlack.Sprint(formatDuration(s.Elapsed()))+"\n")
+}
+
+// Fail finishes the spinner with a cross, msg and the elapsed time. It is
+// printed to the error writer, also in quiet mode.
+func (s *Spinner) Fail(msg string) {
+	s.finish(s.c.err, s.c.red.Sprint("✗")+" "+msg+" "+s.c.hiBlack.Sprint(formatDuration(s.Elapsed()))+"\n")
+}
+
+// Stop removes the spinner without printing anything.
+func (s *Spinner) Stop() {
+	s.finish(s.c.msg, "")
+}
+
+func (s *Spinner) finish(w io.Writer, line string) {
+	s.once.Do(func() {
+		close(s.stop)
+		<-s.done
+		s.c.endLive(s, w, line)
+	})
+}
+
+// Progress is a progress bar for a task of known size. It implements
+// io.Writer, counting the bytes written, so it can track copies:
+//
+//	io.Copy(dst, io.TeeReader(resp.Body, c.Progress("download", resp.ContentLength)))
+type Progress struct {
+	c     *Console
+	msg   string
+	total int64
+	start time.Time
+
+	mu       sync.Mutex
+	current  int64
+	lastDraw time.Time
+	lastStep int64 // last quarter printed without a terminal
+	done     bool
+}
+
+// Progress starts a progress bar for msg counting up to total. On a
+// terminal the bar is redrawn as it advances, otherwise a line is printed
+// at every quarter.
+func (c *Console) Progress(msg string, total int64) *Progress {
+	p := &Progress{c: c, msg: msg, total: max(total, 1), start: time.Now()}
+	if c.animated() {
+		c.setLive(p)
+	}
+	return p
+}
+
+func (p *Progress) render() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	filled := int(p.current * progressWidth / p.total)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressWidth-filled)
+	return fmt.Sprintf("%s %s %3d%% (%d/%d) %s",
+		p.msg, p.c.blue.Sprint(bar), p.current*100/p.total, p.current, p.total,
+		p.c.hiBlack.Sprint(formatDuration(time.Since(p.start))))
+}
+
+// Add advances the bar by n.
+func (p *Progress) Add(n int64) {
+	p.mu.Lock()
+	p.set(p.current + n)
+}
+
+// Set sets the progress to n.
+func (p *Progress) Set(n int64) {
+	p.mu.Lock()
+	p.set(n)
+}
+
+// set updates the progress and draws it, unlocking p.mu.
+func (p *Progress) set(n int64) {
+	if p.done {
+		p.mu.Unlock()
+		return
+	}
+	p.current = min(max(n, 0), p.total)
+
+	if p.c.animated() {
+		// redraw at most every spinnerInterval, and at the end
+		draw := p.current == p.total || time.Since(p.lastDraw) >= spinnerInterval
+		if draw {
+			p.lastDraw = time.Now()
+		}
+		p.mu.Unlock()
+		if draw {
+			p.c.redraw(p)
+		}
+		return
+	}
+
+	step := p.current * 4 / p.total
+	report := step > p.lastStep && p.current < p.total
+	p.lastStep = max(step, p.lastStep)
+	line := fmt.Sprintf("%s: %d%% (%d/%d)", p.msg, step*25, p.current, p.total)
+	p.mu.Unlock()
+	if report {
+		p.c.Info("%s", line)
+	}
+}
+
+// Write counts len(b) as progress.
+func (p *Progress) Write(b []byte) (int, error) {
+	p.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// Done finishes the bar with the final count and the elapsed time.
+func (p *Progress) Done() {
+	p.mu.Lock()
+	if p.done {
+		p.mu.Unlock()
+		return
+	}
+	p.done = true
+	line := fmt.Sprintf("%s %s (%d/%d) %s\n",
+		p.c.green.Sprint("✓"), p.msg, p.current, p.total, p.c.hiBlack.Sprint(formatDuration(time.Since(p.start))))
+	p.mu.Unlock()
+
+	if p.c.verbosity < Normal {
+		line = ""
+	}
+	p.c.endLive(p, p.c.msg, line)
+}
+
+// StepStatus is the outcome of a step.
+type StepStatus int
+
+const (
+	StepRunning StepStatus = iota
+	StepDone
+	StepFailed
+	StepSkipped
+)
+
+func (s StepStatus) String() string {
+	switch s {
+	case StepDone:
+		return "done"
+	case StepFailed:
+		return "failed"
+	case StepSkipped:
+		return "skipped"
+	default:
+		return "running"
+	}
+}
+
+// Steps tracks named steps of a long task and prints a summary of their
+// durations and statuses:
+//
+//	steps := c.Steps()
+//	defer steps.Summary()
+//	st := steps.Start("apt-get update")
+//	if err := update(); err != nil {
+//		st.Fail(err)
+//		return err
+//	}
+//	st.Done()
+type Steps struct {
+	c *Console
+
+	mu    sync.Mutex
+	steps []*Step
+}
+
+// Step is a running step with a spinner showing its elapsed time.
+type Step struct {
+	Name string
+
+	spinner  *Spinner
+	mu       sync.Mutex
+	status   StepStatus
+	duration time.Duration
+	err      error
+}
+
+// Steps returns a tracker for the steps of a task.
+func (c *Console) Steps() *Steps {
+	return &Steps{c: c}
+}
+
+// Start starts the step name.
+func (s *Steps) Start(name string) *Step {
+	return s.add(&Step{Name: name, spinner: s.c.Spinner(name)})
+}
+
+// StartInteractive starts the step name without animating its spinner: its
+// name is printed as a line, as without a terminal. Use it for steps that
+// may prompt on the terminal, e.g. sudo asking for a password, which an
+// animated spinner would overwrite.
+func (s *Steps) StartInteractive(name string) *Step {
+	return s.add(&Step{Name: name, spinner: s.c.spinner(name, false)})
+}
+
+func (s *Steps) add(st *Step) *Step {
+	s.mu.Lock()
+	s.steps = append(s.steps, st)
+	s.mu.Unlock()
+	return st
+}
+
+// Done marks the step as succeeded.
+func (st *Step) Done() {
+	if st.end(StepDone, nil) {
+		st.spinner.Success(st.Name)
+	}
+}
+
+// Fail marks the step as failed with err. Errors returned by Fail, which
+// were printed already, are not repeated after the step name.
+func (st *Step) Fail(err error) {
+	if st.end(StepFailed, err) {
+		msg := st.Name
+		if err != nil && !Reported(err) {
+			msg += ": " + err.Error()
+		}
+		st.spinner.Fail(msg)
+	}
+}
+
+// Skip marks the step as skipped.
+func (st *Step) Skip() {
+	if st.end(StepSkipped, nil) {
+		st.spinner.Stop()
+	}
+}
+
+// Status returns the status of the step, its duration once it ended and
+// the error it failed with.
+func (st *Step) Status() (StepStatus, time.Duration, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.status == StepRunning {
+		return st.status, st.spinner.Elapsed(), nil
+	}
+	return st.status, st.duration, st.err
+}
+
+// end records the outcome of the step, reporting false if it ended already.
+func (st *Step) end(status StepStatus, err error) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.status != StepRunning {
+		return false
+	}
+	st.status, st.err, st.duration = status, err, st.spinner.Elapsed()
+	return true
+}
+
+// Summary prints a table of the steps with their statuses and durations.
+// Steps still running are listed as running. Nothing is printed in quiet
+// mode unless a step failed.
+func (s *Steps) Summary() {
+	s.mu.Lock()
+	steps := append([]*Step(nil), s.steps...)
+	s.mu.Unlock()
+
+	var (
+		total  time.Duration
+		failed bool
+		nameW  = len("STEP")
+		rows   = make([][3]string, len(steps))
+	)
+	for i, st := range steps {
+		status, d, _ := st.Status()
+		total += d
+		failed = failed || status == StepFailed
+		nameW = max(nameW, utf8.RuneCountInString(st.Name))
+		rows[i] = [3]string{st.Name, status.String(), formatDuration(d)}
+	}
+	if s.c.verbosity < Normal && !failed {
+		return
+	}
+
+	// statusW fits every status; colours are added after padding, since
+	// escape codes have no width on screen
+	const statusW = len("skipped")
+	line := func(name, status, colored, d string) string {
+		return fmt.Sprintf("%s%s  %s%s  %s\n",
+			name, strings.Repeat(" ", nameW-utf8.RuneCountInString(name)),
+			colored, strings.Repeat(" ", statusW-len(status)), d)
+	}
+	var buf strings.Builder
+	buf.WriteString(line("STEP", "STATUS", "STATUS", "DURATION"))
+	for _, r := range rows {
+		buf.WriteString(line(r[0], r[1], s.c.statusColor(r[1]), r[2]))
+	}
+	buf.WriteString(line("TOTAL", "", "", formatDuration(total)))
+	s.c.write(s.c.msg, buf.String())
+}
+
+func (c *Console) statusColor(status string) string {
+	switch status {
+	case StepDone.String():
+		return c.green.Sprint(status)
+	case StepFailed.String():
+		return c.red.Sprint(status)
+	case StepSkipped.String():
+		return c.yellow.Sprint(status)
+	default:
+		return status
+	}
+}
+
+// formatDuration rounds d for display: milliseconds below a second, tenths
+// of a second above.
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
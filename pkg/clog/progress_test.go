@@ -0,0 +1,161 @@
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// durations are the only part of the output that varies between runs
+var durationRE = regexp.MustCompile(`\d+(\.\d+)?(ms|s|µs|ns)`)
+
+func normalize(s string) string {
+	return durationRE.ReplaceAllString(s, "D")
+}
+
+func TestSpinner(t *testing.T) {
+	t.Run("[SUCCESS] should print lines without a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, nil)
+
+		s := c.Spinner("installing")
+		s.Update("still installing") // verbose only
+		s.Success("installed")
+
+		assert.Equal(t, "==> installing\n✓ installed D\n", normalize(out.String()))
+	})
+
+	t.Run("[SUCCESS] should animate on a terminal and keep other output above it", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, nil)
+		c.tty = true
+
+		s := c.Spinner("installing")
+		c.Info("a line")
+		time.Sleep(2 * spinnerInterval)
+		s.Success("installed")
+
+		got := normalize(out.String())
+		assert.True(t, strings.HasPrefix(got, clearLine+"⠋ installing D"+clearLine+"a line\n⠋ installing D"), got)
+		assert.Contains(t, got, "⠙ installing")
+		assert.True(t, strings.HasSuffix(got, clearLine+"✓ installed D\n"), got)
+	})
+
+	t.Run("[FAILURE] should print failures in quiet mode", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		c := NewConsole(&out, &Options{Err: &errOut, Verbosity: Quiet})
+
+		c.Spinner("ok").Success("ok")
+		c.Spinner("broken").Fail("broken")
+
+		assert.Empty(t, out.String())
+		assert.Equal(t, "✗ broken D\n", normalize(errOut.String()))
+	})
+}
+
+func TestProgress(t *testing.T) {
+	t.Run("[SUCCESS] should print every quarter without a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, nil)
+
+		p := c.Progress("download", 100)
+		for range 10 {
+			_, _ = p.Write(make([]byte, 10))
+		}
+		p.Done()
+
+		assert.Equal(t, "download: 25% (30/100)\ndownload: 50% (50/100)\ndownload: 75% (80/100)\n✓ download (100/100) D\n",
+			normalize(out.String()))
+	})
+
+	t.Run("[SUCCESS] should draw a bar on a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, nil)
+		c.tty = true
+
+		p := c.Progress("download", 4)
+		p.Set(4)
+		p.Done()
+
+		got := normalize(out.String())
+		assert.Contains(t, got, clearLine+"download "+strings.Repeat("░", progressWidth)+"   0% (0/4) D")
+		assert.Contains(t, got, clearLine+"download "+strings.Repeat("█", progressWidth)+" 100% (4/4) D")
+		assert.True(t, strings.HasSuffix(got, clearLine+"✓ download (4/4) D\n"), got)
+	})
+}
+
+func TestSteps(t *testing.T) {
+	t.Run("[SUCCESS] should print a summary of step statuses and durations", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, nil)
+
+		steps := c.Steps()
+		steps.Start("apt-get update").Done()
+		steps.Start("install").Fail(errors.New("exit status 1"))
+		steps.Start("cleanup").Skip()
+		running := steps.Start("verify")
+
+		status, _, _ := running.Status()
+		assert.Equal(t, StepRunning, status)
+
+		out.Reset()
+		steps.Summary()
+
+		expected := "STEP            STATUS   DURATION\n" +
+			"apt-get update  done     D\n" +
+			"install         failed   D\n" +
+			"cleanup         skipped  D\n" +
+			"verify          running  D\n" +
+			"TOTAL                    D\n"
+		assert.Equal(t, expected, normalize(out.String()))
+	})
+
+	t.Run("[SUCCESS] should only print the summary in quiet mode when a step failed", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, &Options{Verbosity: Quiet})
+
+		steps := c.Steps()
+		steps.Start("ok").Done()
+		steps.Summary()
+		assert.Empty(t, out.String())
+
+		st := steps.Start("broken")
+		st.Fail(errors.New("boom"))
+		st.Done() // ignored, the step ended already
+		steps.Summary()
+
+		status, _, err := st.Status()
+		assert.Equal(t, StepFailed, status)
+		require.EqualError(t, err, "boom")
+		assert.Contains(t, out.String(), "broken  failed")
+	})
+
+	t.Run("[SUCCESS] should not animate interactive steps on a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, nil)
+		c.tty = true
+
+		st := c.Steps().StartInteractive("sudo apt-get update")
+		out.WriteString("[sudo] password for neil: ")
+		time.Sleep(2 * spinnerInterval)
+		st.Done()
+
+		assert.Equal(t, "==> sudo apt-get update\n[sudo] password for neil: ✓ sudo apt-get update D\n", normalize(out.String()))
+	})
+
+	t.Run("[FAILURE] should not repeat errors printed by Fail", func(t *testing.T) {
+		var out bytes.Buffer
+		c := NewConsole(&out, nil)
+
+		st := c.Steps().Start("install")
+		st.Fail(c.Fail("exit status %d", 2))
+		assert.Equal(t, 1, strings.Count(out.String(), "exit status 2"))
+		assert.Contains(t, out.String(), "✗ install")
+	})
+}
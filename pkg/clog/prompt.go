@@ -0,0 +1,271 @@
+package clog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNonInteractive is returned by prompts that cannot be answered with
+// their default, when answers are assumed with Options.Yes or the input
+// ended.
+var ErrNonInteractive = errors.New("prompt needs an answer, but the console is not interactive")
+
+// Confirm asks a yes/no question. An empty answer is def. With
+// Options.Yes it returns true without asking.
+func (c *Console) Confirm(msg string, def bool) (bool, error) {
+	if c.yes {
+		return true, nil
+	}
+
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	for {
+		answer, err := c.ask(fmt.Sprintf("%s %s ", c.prompt(msg), hint), false)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		c.Warn("Please answer yes or no.")
+	}
+}
+
+// Select asks for one of options by number or name and returns its index.
+// An empty answer is the option at def; a negative def has no default.
+// With Options.Yes it returns def without asking.
+func (c *Console) Select(msg string, options []string, def int) (int, error) {
+	var defs []int
+	if def >= 0 && def < len(options) {
+		defs = []int{def}
+	}
+	indexes, err := c.choose(msg, options, defs, false)
+	if err != nil {
+		return -1, err
+	}
+	return indexes[0], nil
+}
+
+// MultiSelect asks for one or more of options, separated by commas or
+// spaces, and returns their indexes in order. An empty answer is defs.
+// With Options.Yes it returns defs without asking.
+func (c *Console) MultiSelect(msg string, options []string, defs []int) ([]int, error) {
+	return c.choose(msg, options, defs, true)
+}
+
+func (c *Console) choose(msg string, options []string, defs []int, multi bool) ([]int, error) {
+	if len(options) == 0 {
+		return nil, errors.New("prompt has no options")
+	}
+	if c.yes {
+		if len(defs) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNonInteractive, msg)
+		}
+		return defs, nil
+	}
+
+	var list strings.Builder
+	list.WriteString(c.prompt(msg) + "\n")
+	for i, o := range options {
+		fmt.Fprintf(&list, "  %s %s\n", c.blue.Sprintf("%d)", i+1), o)
+	}
+	c.write(c.msg, list.String())
+
+	hint := fmt.Sprintf("Choose [1-%d]", len(options))
+	if multi {
+		hint = fmt.Sprintf("Choose one or more [1-%d]", len(options))
+	}
+	if len(defs) > 0 {
+		nums := make([]string, len(defs))
+		for i, d := range defs {
+			nums[i] = strconv.Itoa(d + 1)
+		}
+		hint += fmt.Sprintf(" (default %s)", strings.Join(nums, ","))
+	}
+
+	for {
+		answer, err := c.ask(hint+": ", false)
+		if err != nil {
+			return nil, err
+		}
+		if answer == "" && len(defs) > 0 {
+			return defs, nil
+		}
+
+		fields := strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' })
+		if len(fields) == 0 || (!multi && len(fields) > 1) {
+			if multi {
+				c.Warn("Please choose at least one option.")
+			} else {
+				c.Warn("Please choose one option.")
+			}
+			continue
+		}
+		indexes, ok := parseChoices(fields, options)
+		if !ok {
+			c.Warn("Please choose by a number between 1 and %d or by name.", len(options))
+			continue
+		}
+		return indexes, nil
+	}
+}
+
+// parseChoices resolves answers given by number or by option name to
+// option indexes, in order and without duplicates.
+func parseChoices(fields, options []string) ([]int, bool) {
+	var indexes []int
+	for _, f := range fields {
+		i := slices.Index(options, f)
+		if n, err := strconv.Atoi(f); err == nil && n >= 1 && n <= len(options) {
+			i = n - 1
+		}
+		if i < 0 {
+			return nil, false
+		}
+		if !slices.Contains(indexes, i) {
+			indexes = append(indexes, i)
+		}
+	}
+	slices.Sort(indexes)
+	return indexes, true
+}
+
+// Input asks for a line of text. An empty answer is def. Answers, def
+// included, are checked with validate when it is not nil, and asked again
+// while it returns an error. With Options.Yes it returns def without
+// asking, or ErrNonInteractive when def is not valid.
+func (c *Console) Input(msg, def string, validate func(string) error) (string, error) {
+	if validate == nil {
+		validate = func(string) error { return nil }
+	}
+	if c.yes {
+		if err := validate(def); err != nil {
+			return "", fmt.Errorf("%w: %s: %w", ErrNonInteractive, msg, err)
+		}
+		return def, nil
+	}
+
+	question := c.prompt(msg) + " "
+	if def != "" {
+		question = fmt.Sprintf("%s %s ", c.prompt(msg), c.hiBlack.Sprintf("(%s)", def))
+	}
+	for {
+		answer, err := c.ask(question, false)
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+		if err := validate(answer); err != nil {
+			c.Warn("%v", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// Password asks for a secret without echoing it on a terminal. Empty
+// answers are asked again. With Options.Yes it returns ErrNonInteractive.
+func (c *Console) Password(msg string) (string, error) {
+	if c.yes {
+		return "", fmt.Errorf("%w: %s", ErrNonInteractive, msg)
+	}
+
+	for {
+		var (
+			answer string
+			err    error
+		)
+		if f, ok := c.in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			c.write(c.msg, c.prompt(msg)+" ")
+			var b []byte
+			b, err = term.ReadPassword(int(f.Fd()))
+			c.write(c.msg, "\n")
+			answer = string(b)
+		} else {
+			answer, err = c.ask(c.prompt(msg)+" ", true)
+		}
+		if err != nil {
+			return "", err
+		}
+		if answer != "" {
+			return answer, nil
+		}
+		c.Warn("Please enter a value.")
+	}
+}
+
+// prompt formats the question of a prompt.
+func (c *Console) prompt(msg string) string {
+	return c.green.Sprint("?") + " " + msg
+}
+
+// ask writes question and reads a line of answer without surrounding
+// spaces. It returns ErrNonInteractive when the input ends before the
+// answer. Answers not typed on a terminal are echoed, secrets excepted.
+func (c *Console) ask(question string, secret bool) (string, error) {
+	c.inMu.Lock()
+	defer c.inMu.Unlock()
+
+	c.write(c.msg, question)
+	if c.reader == nil {
+		c.reader = bufio.NewReader(c.in)
+	}
+	line, err := c.reader.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		c.write(c.msg, "\n")
+		return "", fmt.Errorf("%w: input ended", ErrNonInteractive)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read answer: %w", err)
+	}
+	if !c.inTTY {
+		if secret {
+			c.write(c.msg, "\n")
+		} else {
+			c.write(c.msg, strings.TrimRight(line, "\n")+"\n")
+		}
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Confirm asks a yes/no question
+func Confirm(msg string, def bool) (bool, error) {
+	return Default().Confirm(msg, def)
+}
+
+// Select asks for one of options
+func Select(msg string, options []string, def int) (int, error) {
+	return Default().Select(msg, options, def)
+}
+
+// MultiSelect asks for one or more of options
+func MultiSelect(msg string, options []string, defs []int) ([]int, error) {
+	return Default().MultiSelect(msg, options, defs)
+}
+
+// Input asks for a line of text
+func Input(msg, def string, validate func(string) error) (string, error) {
+	return Default().Input(msg, def, validate)
+}
+
+// Password asks for a secret without echoing it
+func Password(msg string) (string, error) {
+	return Default().Password(msg)
+}
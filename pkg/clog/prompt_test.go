@@ -0,0 +1,164 @@
+package clog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPromptConsole returns a console answering prompts with answers,
+// printing prompts to out and warnings to errOut.
+func newPromptConsole(answers string, yes bool) (c *Console, out, errOut *bytes.Buffer) {
+	out, errOut = &bytes.Buffer{}, &bytes.Buffer{}
+	c = NewConsole(out, &Options{Err: errOut, In: strings.NewReader(answers), Yes: yes})
+	return c, out, errOut
+}
+
+func TestConfirm(t *testing.T) {
+	t.Run("[SUCCESS] should read answers and defaults", func(t *testing.T) {
+		c, out, errOut := newPromptConsole("maybe\nYes\n\nn\n", false)
+
+		ok, err := c.Confirm("Add the apt key?", false)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = c.Confirm("Add the user to the docker group?", true)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = c.Confirm("Reboot?", true)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		assert.Equal(t, "? Add the apt key? [y/N] maybe\n"+
+			"? Add the apt key? [y/N] Yes\n"+
+			"? Add the user to the docker group? [Y/n] \n"+
+			"? Reboot? [Y/n] n\n", out.String())
+		assert.Equal(t, "Please answer yes or no.\n", errOut.String())
+	})
+
+	t.Run("[SUCCESS] should assume yes without asking", func(t *testing.T) {
+		c, out, _ := newPromptConsole("", true)
+
+		ok, err := c.Confirm("Remove everything?", false)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("[FAILURE] should fail when the input ends", func(t *testing.T) {
+		c, _, _ := newPromptConsole("", false)
+
+		_, err := c.Confirm("Continue?", true)
+		assert.ErrorIs(t, err, ErrNonInteractive)
+	})
+}
+
+func TestSelect(t *testing.T) {
+	platforms := []string{"ubuntu", "debian", "fedora"}
+
+	t.Run("[SUCCESS] should select by number, name or default", func(t *testing.T) {
+		c, out, errOut := newPromptConsole("4\n1 2\ndebian\n\n", false)
+
+		i, err := c.Select("Platform", platforms, -1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, i)
+
+		i, err = c.Select("Platform", platforms, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, i)
+
+		assert.True(t, strings.HasPrefix(out.String(), "? Platform\n  1) ubuntu\n  2) debian\n  3) fedora\nChoose [1-3]: 4\n"), out.String())
+		assert.Contains(t, out.String(), "Choose [1-3] (default 3): \n")
+		assert.Equal(t, "Please choose by a number between 1 and 3 or by name.\nPlease choose one option.\n", errOut.String())
+	})
+
+	t.Run("[SUCCESS] should select several options", func(t *testing.T) {
+		c, out, _ := newPromptConsole("fedora, 1,1\n\n", false)
+
+		indexes, err := c.MultiSelect("Platforms", platforms, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 2}, indexes)
+
+		indexes, err = c.MultiSelect("Platforms", platforms, []int{0, 1})
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 1}, indexes)
+		assert.Contains(t, out.String(), "Choose one or more [1-3] (default 1,2): ")
+	})
+
+	t.Run("[SUCCESS] should return defaults without asking", func(t *testing.T) {
+		c, _, _ := newPromptConsole("", true)
+
+		i, err := c.Select("Platform", platforms, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, i)
+	})
+
+	t.Run("[FAILURE] should fail without a default in non-interactive mode", func(t *testing.T) {
+		c, _, _ := newPromptConsole("", true)
+
+		_, err := c.Select("Platform", platforms, -1)
+		assert.ErrorIs(t, err, ErrNonInteractive)
+
+		_, err = c.MultiSelect("Platforms", nil, nil)
+		assert.EqualError(t, err, "prompt has no options")
+	})
+}
+
+func TestInput(t *testing.T) {
+	notEmpty := func(s string) error {
+		if s == "" {
+			return errors.New("a name is required")
+		}
+		return nil
+	}
+
+	t.Run("[SUCCESS] should validate answers and ask again", func(t *testing.T) {
+		c, out, errOut := newPromptConsole("\n  neil  \n\n", false)
+
+		name, err := c.Input("User name", "", notEmpty)
+		require.NoError(t, err)
+		assert.Equal(t, "neil", name)
+
+		dir, err := c.Input("Directory", "/opt", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "/opt", dir)
+
+		assert.Equal(t, "? User name \n? User name   neil  \n? Directory (/opt) \n", out.String())
+		assert.Equal(t, "a name is required\n", errOut.String())
+	})
+
+	t.Run("[FAILURE] should fail when the default is not valid in non-interactive mode", func(t *testing.T) {
+		c, _, _ := newPromptConsole("", true)
+
+		dir, err := c.Input("Directory", "/opt", notEmpty)
+		require.NoError(t, err)
+		assert.Equal(t, "/opt", dir)
+
+		_, err = c.Input("User name", "", notEmpty)
+		assert.ErrorIs(t, err, ErrNonInteractive)
+		assert.ErrorContains(t, err, "a name is required")
+	})
+}
+
+func TestPassword(t *testing.T) {
+	t.Run("[SUCCESS] should not echo the answer", func(t *testing.T) {
+		c, out, _ := newPromptConsole("\ns3cret\n", false)
+
+		pw, err := c.Password("Password")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", pw)
+		assert.NotContains(t, out.String(), "s3cret")
+	})
+
+	t.Run("[FAILURE] should fail in non-interactive mode", func(t *testing.T) {
+		c, _, _ := newPromptConsole("s3cret\n", true)
+
+		_, err := c.Password("Password")
+		assert.ErrorIs(t, err, ErrNonInteractive)
+	})
+}
@@ -0,0 +1,358 @@
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// Format is the output format of PrintTable, PrintTree and PrintKV.
+type Format int
+
+const (
+	// FormatText prints aligned tables, trees and key-value blocks.
+	FormatText Format = iota
+	// FormatJSON prints the same data as indented JSON.
+	FormatJSON
+	// FormatYAML prints the same data as YAML.
+	FormatYAML
+)
+
+// ParseFormat parses the value of an --output flag: text, json or yaml.
+// The empty string is text.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	}
+	return FormatText, fmt.Errorf("unknown output format %q, want text, json or yaml", s)
+}
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatYAML:
+		return "yaml"
+	default:
+		return "text"
+	}
+}
+
+// minColumnWidth is the width columns are not truncated below, unless their
+// header is wider.
+const minColumnWidth = 4
+
+// Table is a table with a header row. In JSON and YAML it is a list of
+// objects keyed by the headers in snake case.
+type Table struct {
+	Headers []string
+	Rows    [][]any
+}
+
+// NewTable returns an empty table with headers.
+func NewTable(headers ...string) *Table {
+	return &Table{Headers: headers}
+}
+
+// AddRow appends a row of cells, one per header. Cells are printed with
+// fmt.Sprint in text and encoded as they are in JSON and YAML.
+func (t *Table) AddRow(cells ...any) *Table {
+	t.Rows = append(t.Rows, cells)
+	return t
+}
+
+// Tree is a node of a tree.
+type Tree struct {
+	Name     string  `json:"name" yaml:"name"`
+	Children []*Tree `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// NewTree returns a tree with a root named name.
+func NewTree(name string) *Tree {
+	return &Tree{Name: name}
+}
+
+// Add appends a child named name and returns it.
+func (t *Tree) Add(name string) *Tree {
+	child := &Tree{Name: name}
+	t.Children = append(t.Children, child)
+	return child
+}
+
+// KV is an entry of a key-value block. In JSON and YAML the key is
+// converted to snake case.
+type KV struct {
+	Key   string
+	Value any
+}
+
+// object is an ordered JSON and YAML object.
+type object []KV
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, kv := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(kv.Key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o object) MarshalYAML() (any, error) {
+	m := make(yaml.MapSlice, len(o))
+	for i, kv := range o {
+		m[i] = yaml.MapItem{Key: kv.Key, Value: kv.Value}
+	}
+	return m, nil
+}
+
+// fieldName converts a header or key to the snake case name of its field
+// in JSON and YAML, e.g. "Git Commit" to "git_commit" and "OS/Arch" to
+// "os_arch".
+func fieldName(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "_")
+}
+
+// PrintTable prints t as columns aligned to the widest cell, truncating the
+// widest columns to fit the output width. It prints also in quiet mode.
+func (c *Console) PrintTable(t *Table) error {
+	if c.format != FormatText {
+		rows := make([]object, 0, len(t.Rows))
+		for _, r := range t.Rows {
+			o := make(object, len(t.Headers))
+			for i, h := range t.Headers {
+				o[i] = KV{Key: fieldName(h)}
+				if i < len(r) {
+					o[i].Value = r[i]
+				}
+			}
+			rows = append(rows, o)
+		}
+		return c.encode(rows)
+	}
+
+	cells := make([][]string, 0, len(t.Rows)+1)
+	header := make([]string, len(t.Headers))
+	for i, h := range t.Headers {
+		header[i] = strings.ToUpper(h)
+	}
+	cells = append(cells, header)
+	for _, r := range t.Rows {
+		row := make([]string, len(t.Headers))
+		for i := range row {
+			if i < len(r) && r[i] != nil {
+				row[i] = cellString(r[i])
+			}
+		}
+		cells = append(cells, row)
+	}
+
+	widths := make([]int, len(t.Headers))
+	for _, row := range cells {
+		for i, s := range row {
+			widths[i] = max(widths[i], runewidth.StringWidth(s))
+		}
+	}
+	mins := make([]int, len(header))
+	for i, h := range header {
+		mins[i] = max(runewidth.StringWidth(h), minColumnWidth)
+	}
+	fitColumns(widths, mins, c.textWidth())
+
+	var buf strings.Builder
+	for _, row := range cells {
+		var line strings.Builder
+		for i, s := range row {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			s = runewidth.Truncate(s, widths[i], "…")
+			line.WriteString(s)
+			line.WriteString(strings.Repeat(" ", widths[i]-runewidth.StringWidth(s)))
+		}
+		buf.WriteString(strings.TrimRight(line.String(), " "))
+		buf.WriteByte('\n')
+	}
+	c.write(c.out, buf.String())
+	return nil
+}
+
+// cellString formats a cell on one line.
+func cellString(v any) string {
+	return strings.Join(strings.Fields(fmt.Sprint(v)), " ")
+}
+
+// fitColumns narrows the widest columns until the columns and the two
+// spaces between them fit in limit, keeping them at least as wide as mins.
+// A limit of 0 is unlimited.
+func fitColumns(widths, mins []int, limit int) {
+	if limit <= 0 || len(widths) == 0 {
+		return
+	}
+	total := 2 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > limit {
+		widest := -1
+		for i, w := range widths {
+			if w > mins[i] && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// PrintTree prints t with its children indented below it, truncating
+// lines to the output width. It prints also in quiet mode.
+func (c *Console) PrintTree(t *Tree) error {
+	if c.format != FormatText {
+		return c.encode(t)
+	}
+
+	branch, last, pipe := "├── ", "└── ", "│   "
+	if c.ascii {
+		branch, last, pipe = "|-- ", "`-- ", "|   "
+	}
+	limit := c.textWidth()
+
+	var buf strings.Builder
+	line := func(s string) {
+		if limit > 0 {
+			s = runewidth.Truncate(s, limit, "…")
+		}
+		buf.WriteString(s)
+		buf.WriteByte('\n')
+	}
+	var walk func(n *Tree, prefix string)
+	walk = func(n *Tree, prefix string) {
+		for i, child := range n.Children {
+			connector, indent := branch, pipe
+			if i == len(n.Children)-1 {
+				connector, indent = last, "    "
+			}
+			line(prefix + connector + cellString(child.Name))
+			walk(child, prefix+indent)
+		}
+	}
+	line(cellString(t.Name))
+	walk(t, "")
+	c.write(c.out, buf.String())
+	return nil
+}
+
+// PrintKV prints kvs as a block of keys and values, with the values
+// aligned. It prints also in quiet mode.
+func (c *Console) PrintKV(kvs ...KV) error {
+	if c.format != FormatText {
+		o := make(object, len(kvs))
+		for i, kv := range kvs {
+			o[i] = KV{Key: fieldName(kv.Key), Value: kv.Value}
+		}
+		return c.encode(o)
+	}
+
+	keyW := 0
+	for _, kv := range kvs {
+		keyW = max(keyW, runewidth.StringWidth(kv.Key))
+	}
+	limit := c.textWidth()
+
+	var buf strings.Builder
+	for _, kv := range kvs {
+		value := ""
+		if kv.Value != nil {
+			value = cellString(kv.Value)
+		}
+		pad := strings.Repeat(" ", keyW-runewidth.StringWidth(kv.Key))
+		if limit > 0 {
+			value = runewidth.Truncate(value, max(limit-keyW-2, minColumnWidth), "…")
+		}
+		buf.WriteString(c.blue.Sprint(kv.Key+":") + pad + " " + value + "\n")
+	}
+	c.write(c.out, buf.String())
+	return nil
+}
+
+// encode prints v in the JSON or YAML format of the console.
+func (c *Console) encode(v any) error {
+	var (
+		b   []byte
+		err error
+	)
+	if c.format == FormatYAML {
+		b, err = yaml.Marshal(v)
+	} else {
+		b, err = json.MarshalIndent(v, "", "  ")
+		b = append(b, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("encode %s output: %w", c.format, err)
+	}
+	c.write(c.out, string(b))
+	return nil
+}
+
+// textWidth returns the width text output is truncated to, or 0 when it is
+// unlimited.
+func (c *Console) textWidth() int {
+	if c.width > 0 {
+		return c.width
+	}
+	if f, ok := c.out.(interface{ Fd() uintptr }); ok && isTerminal(c.out) {
+		if w, _, err := term.GetSize(int(f.Fd())); err == nil && w > 0 {
+			return w
+		}
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return 0
+}
+
+// PrintTable prints a table
+func PrintTable(t *Table) error {
+	return Default().PrintTable(t)
+}
+
+// PrintTree prints a tree
+func PrintTree(t *Tree) error {
+	return Default().PrintTree(t)
+}
+
+// PrintKV prints a key-value block
+func PrintKV(kvs ...KV) error {
+	return Default().PrintKV(kvs...)
+}
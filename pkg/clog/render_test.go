@@ -0,0 +1,145 @@
+package clog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	t.Run("[SUCCESS] should parse output formats", func(t *testing.T) {
+		for s, expected := range map[string]Format{"": FormatText, "text": FormatText, "JSON": FormatJSON, "yml": FormatYAML} {
+			f, err := ParseFormat(s)
+			require.NoError(t, err)
+			assert.Equal(t, expected, f)
+		}
+	})
+
+	t.Run("[FAILURE] should reject unknown formats", func(t *testing.T) {
+		_, err := ParseFormat("xml")
+		assert.EqualError(t, err, `unknown output format "xml", want text, json or yaml`)
+	})
+}
+
+func TestPrintTable(t *testing.T) {
+	t.Setenv("COLUMNS", "") // unlimited width
+	table := NewTable("Platform", "Script", "Steps").
+		AddRow("ubuntu", "install-docker", 6).
+		AddRow("debian", "install-go\nnow", nil)
+
+	t.Run("[SUCCESS] should align columns", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, NewConsole(&out, &Options{Verbosity: Quiet}).PrintTable(table))
+
+		expected := "PLATFORM  SCRIPT          STEPS\n" +
+			"ubuntu    install-docker  6\n" +
+			"debian    install-go now\n"
+		assert.Equal(t, expected, out.String())
+	})
+
+	t.Run("[SUCCESS] should truncate the widest columns to the width", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, NewConsole(&out, &Options{Width: 24}).PrintTable(table))
+
+		expected := "PLATFORM  SCRIPT   STEPS\n" +
+			"ubuntu    instal…  6\n" +
+			"debian    instal…\n"
+		assert.Equal(t, expected, out.String())
+	})
+
+	t.Run("[SUCCESS] should align wide characters", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, NewConsole(&out, nil).PrintTable(NewTable("名稱", "n").AddRow("工具箱", 1).AddRow("x", 2)))
+		assert.Equal(t, "名稱    N\n工具箱  1\nx       2\n", out.String())
+	})
+
+	t.Run("[SUCCESS] should encode rows as objects", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		c := NewConsole(&out, &Options{Err: &errOut, Format: FormatJSON})
+		c.Info("listing")
+		require.NoError(t, c.PrintTable(table))
+
+		assert.Equal(t, "listing\n", errOut.String())
+		assert.JSONEq(t, `[
+			{"platform": "ubuntu", "script": "install-docker", "steps": 6},
+			{"platform": "debian", "script": "install-go\nnow", "steps": null}
+		]`, out.String())
+
+		out.Reset()
+		require.NoError(t, NewConsole(&out, &Options{Format: FormatYAML}).PrintTable(table))
+		assert.Equal(t, "- platform: ubuntu\n  script: install-docker\n  steps: 6\n"+
+			"- platform: debian\n  script: |-\n    install-go\n    now\n  steps: null\n", out.String())
+
+		out.Reset()
+		require.NoError(t, NewConsole(&out, &Options{Format: FormatJSON}).PrintTable(NewTable("a")))
+		assert.Equal(t, "[]\n", out.String())
+	})
+}
+
+func TestPrintTree(t *testing.T) {
+	t.Setenv("COLUMNS", "") // unlimited width
+	tree := NewTree("scripts")
+	ubuntu := tree.Add("ubuntu")
+	ubuntu.Add("install-docker")
+	ubuntu.Add("install-go")
+	tree.Add("debian").Add("install-docker")
+
+	t.Run("[SUCCESS] should draw Unicode and ASCII trees", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, NewConsole(&out, nil).PrintTree(tree))
+		assert.Equal(t, "scripts\n"+
+			"├── ubuntu\n"+
+			"│   ├── install-docker\n"+
+			"│   └── install-go\n"+
+			"└── debian\n"+
+			"    └── install-docker\n", out.String())
+
+		out.Reset()
+		require.NoError(t, NewConsole(&out, &Options{ASCII: true, Width: 16}).PrintTree(tree))
+		assert.Equal(t, "scripts\n"+
+			"|-- ubuntu\n"+
+			"|   |-- install…\n"+
+			"|   `-- install…\n"+
+			"`-- debian\n"+
+			"    `-- install…\n", out.String())
+	})
+
+	t.Run("[SUCCESS] should encode nested nodes", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, NewConsole(&out, &Options{Format: FormatJSON}).PrintTree(tree))
+		assert.JSONEq(t, `{"name": "scripts", "children": [
+			{"name": "ubuntu", "children": [{"name": "install-docker"}, {"name": "install-go"}]},
+			{"name": "debian", "children": [{"name": "install-docker"}]}
+		]}`, out.String())
+	})
+}
+
+func TestPrintKV(t *testing.T) {
+	t.Setenv("COLUMNS", "") // unlimited width
+	kvs := []KV{{"Version", "v2.1.0"}, {"Git Commit", "abc123"}, {"OS/Arch", "linux/amd64"}}
+
+	t.Run("[SUCCESS] should align values", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, NewConsole(&out, nil).PrintKV(kvs...))
+		assert.Equal(t, "Version:    v2.1.0\nGit Commit: abc123\nOS/Arch:    linux/amd64\n", out.String())
+	})
+
+	t.Run("[SUCCESS] should encode an ordered object with snake case keys", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, NewConsole(&out, &Options{Format: FormatJSON}).PrintKV(kvs...))
+		assert.Equal(t, "{\n  \"version\": \"v2.1.0\",\n  \"git_commit\": \"abc123\",\n  \"os_arch\": \"linux/amd64\"\n}\n", out.String())
+
+		out.Reset()
+		require.NoError(t, NewConsole(&out, &Options{Format: FormatYAML}).PrintKV(kvs...))
+		assert.Equal(t, "version: v2.1.0\ngit_commit: abc123\nos_arch: linux/amd64\n", out.String())
+	})
+
+	t.Run("[FAILURE] should return encoding errors", func(t *testing.T) {
+		var out bytes.Buffer
+		err := NewConsole(&out, &Options{Format: FormatJSON}).PrintKV(KV{"ch", make(chan int)})
+		assert.ErrorContains(t, err, "encode json output")
+		assert.Empty(t, out.String())
+	})
+}
@@ -17,7 +17,8 @@ func BenchmarkParseMDBUpdateData(b *testing.B) {
 		Age:       30,
 	}
 
-	for range 1000000 {
+	b.ReportAllocs()
+	for b.Loop() {
 		BuildMongoUpdateMap(test, []string{})
 	}
 }
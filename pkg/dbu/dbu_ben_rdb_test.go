@@ -0,0 +1,49 @@
+package dbu
+
+import "testing"
+
+// go test -run=^$ -bench=RDB -benchmem ./pkg/dbu
+func BenchmarkParseRDBUpdateData(b *testing.B) {
+	type details struct {
+		Text *string `json:"text"`
+		PDF  *string `json:"pdf"`
+	}
+	type testStruct struct {
+		ID        string   `json:"id"`
+		FirstName string   `json:"firstName"`
+		LastName  *string  `json:"lastName"`
+		Age       int      `json:"age"`
+		Email     string   `gorm:"column:email_address"`
+		Tags      []string `gorm:"type:jsonb" json:"tags"`
+		Details   *details `gorm:"embedded;embeddedPrefix:details_"`
+	}
+
+	lastName, text := "Doe", "hello"
+	test := testStruct{
+		ID:        "123",
+		FirstName: "John",
+		LastName:  &lastName,
+		Age:       30,
+		Email:     "john@example.com",
+		Tags:      []string{"a", "b"},
+		Details:   &details{Text: &text},
+	}
+	skip := []string{"id"}
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_, _ = BuildRDBUpdateMap(test, skip)
+		}
+	})
+
+	// parsing the tags on every call, as BuildRDBUpdateMap did before the
+	// plans were cached
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			SetDefaultColumnNameFunc(DefaultSnakeCaseNamer)
+			_, _ = BuildRDBUpdateMap(test, skip)
+		}
+	})
+}
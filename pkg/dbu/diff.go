@@ -0,0 +1,284 @@
+package dbu
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// DiffOptions configures DiffRDBUpdateMap and DiffMongoUpdateMap.
+type DiffOptions struct {
+	// SkipFields lists the columns to leave out, or for Mongo the dotted
+	// paths to leave out with everything nested in them.
+	SkipFields []string
+	// Changes requests the change set of the changed fields, e.g. for
+	// audit logging.
+	Changes bool
+}
+
+// Change is the old and new value of a changed column or Mongo path.
+type Change struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+// DiffRDBUpdateMap constructs a map[string]any for use in relational database updates with
+// only the columns whose value differs between two snapshots of a struct, e.g. an entity as
+// loaded and after its mutation.
+//
+// old and new must be the same struct type, or pointers to it; a nil pointer compares as the
+// zero value. Columns are named and flattened as by BuildRDBUpdateMap, including the prefixes
+// of `embedded` structs, and jsonb columns are marshaled to json.RawMessage. Unlike
+// BuildRDBUpdateMap, a field that became a nil pointer, a field inside an embedded pointer that
+// became nil, or a Nullable that became unset or null is a change to nil, i.e. NULL.
+//
+// Values are compared deeply, with time.Time values compared by the instant they represent,
+// regardless of location and monotonic clock reading, also when nested in slices, maps, structs
+// and jsonb columns. jsonb columns whose Go values differ are then compared by their JSON
+// documents, so key order and spacing do not matter.
+//
+// With opts.Changes, the changed columns are also returned in field order, with their old and
+// new values as they would be written. Otherwise the change set is nil. opts may be nil.
+//
+// Returns an error if old and new are not structs or pointers to structs of the same type, or
+// if JSON marshaling fails.
+func DiffRDBUpdateMap(old, new any, opts *DiffOptions) (map[string]any, []Change, error) {
+	var o DiffOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	result := make(map[string]any)
+	oldVal, newVal, err := diffValues(old, new, "DiffRDBUpdateMap")
+	if err != nil {
+		return result, nil, err
+	}
+
+	var changes []Change
+	plan := defaultNamer.Load().rdbPlan(newVal.Type())
+	if plan.err != nil {
+		return result, nil, plan.err
+	}
+	for _, f := range plan.fields {
+		if slices.Contains(o.SkipFields, f.column) {
+			continue
+		}
+		if f.jsonb {
+			// compare the documents before marshaling too, where times
+			// are still compared by their instant
+			oldDoc, _ := f.goValue(oldVal)
+			newDoc, _ := f.goValue(newVal)
+			if equalValues(oldDoc, newDoc) {
+				continue
+			}
+		}
+		oldValue, _, err := f.value(oldVal, json.Marshal)
+		if err != nil {
+			return result, nil, err
+		}
+		newValue, _, err := f.value(newVal, json.Marshal)
+		if err != nil {
+			return result, nil, err
+		}
+		if equalValues(oldValue, newValue) {
+			continue
+		}
+
+		result[f.column] = newValue
+		if o.Changes {
+			changes = append(changes, Change{Key: f.column, Old: oldValue, New: newValue})
+		}
+	}
+	return result, changes, nil
+}
+
+// DiffMongoUpdateMap is the MongoDB equivalent of DiffRDBUpdateMap. It constructs a
+// map[string]any for a `$set` update with only the dotted paths whose value differs between two
+// snapshots of a struct, named and flattened as by BuildMongoUpdateMap, so a change inside a
+// nested struct only sets the changed fields. A field that became a nil pointer, or a Nullable
+// that became unset or null, is a change to nil.
+func DiffMongoUpdateMap(old, new any, opts *DiffOptions) (map[string]any, []Change, error) {
+	var o DiffOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	result := make(map[string]any)
+	oldVal, newVal, err := diffValues(old, new, "DiffMongoUpdateMap")
+	if err != nil {
+		return result, nil, err
+	}
+
+	skipMap := make(map[string]struct{}, len(o.SkipFields))
+	for _, field := range o.SkipFields {
+		skipMap[field] = struct{}{}
+	}
+
+	plan := mongoPlanOf(newVal.Type())
+	if plan.err != nil {
+		return result, nil, plan.err
+	}
+
+	var changes []Change
+	for _, f := range plan.fields {
+		if skippedPath(skipMap, f.path) {
+			continue
+		}
+		oldValue, _ := f.value(oldVal)
+		newValue, _ := f.value(newVal)
+		if equalValues(oldValue, newValue) {
+			continue
+		}
+
+		result[f.path] = newValue
+		if o.Changes {
+			changes = append(changes, Change{Key: f.path, Old: oldValue, New: newValue})
+		}
+	}
+	return result, changes, nil
+}
+
+// diffValues returns the structs old and new are or point to, with nil
+// pointers replaced by zero values, and an error naming the function fn if
+// they are not structs of the same type.
+func diffValues(old, new any, fn string) (reflect.Value, reflect.Value, error) {
+	oldVal, err := structValue(old, fn)
+	if err != nil {
+		return oldVal, oldVal, err
+	}
+	newVal, err := structValue(new, fn)
+	if err != nil {
+		return oldVal, newVal, err
+	}
+
+	oldType, newType := reflect.TypeOf(old), reflect.TypeOf(new)
+	if oldType.Kind() == reflect.Pointer {
+		oldType = oldType.Elem()
+	}
+	if newType.Kind() == reflect.Pointer {
+		newType = newType.Elem()
+	}
+	if oldType != newType {
+		return oldVal, newVal, fmt.Errorf("%s old and new must be the same type, got %s and %s", fn, oldType, newType)
+	}
+
+	if !oldVal.IsValid() {
+		oldVal = reflect.Zero(oldType)
+	}
+	if !newVal.IsValid() {
+		newVal = reflect.Zero(newType)
+	}
+	return oldVal, newVal, nil
+}
+
+// equalValues reports whether the column or path values a and b are
+// equal: JSON documents by their content, and other values deeply, with
+// times compared by their instant wherever they are nested, e.g. in
+// slices, maps and structs.
+func equalValues(a, b any) bool {
+	if a, ok := a.(json.RawMessage); ok {
+		b, ok := b.(json.RawMessage)
+		return ok && equalJSON(a, b)
+	}
+	return deepEqual(reflect.ValueOf(a), reflect.ValueOf(b), map[visit]bool{})
+}
+
+// visit is a pair of references compared already, as in reflect.DeepEqual,
+// which stops cycles.
+type visit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+// deepEqual is reflect.DeepEqual comparing exported times with Equal.
+func deepEqual(x, y reflect.Value, visited map[visit]bool) bool {
+	if !x.IsValid() || !y.IsValid() {
+		return x.IsValid() == y.IsValid()
+	}
+	if x.Type() != y.Type() {
+		return false
+	}
+	if x.Type() == timeType && x.CanInterface() && y.CanInterface() {
+		return x.Interface().(time.Time).Equal(y.Interface().(time.Time))
+	}
+
+	switch x.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if x.IsNil() || y.IsNil() {
+			return x.IsNil() == y.IsNil()
+		}
+		if x.Kind() == reflect.Slice && x.Len() != y.Len() {
+			return false
+		}
+		if x.UnsafePointer() == y.UnsafePointer() {
+			return true
+		}
+		v := visit{a: uintptr(x.UnsafePointer()), b: uintptr(y.UnsafePointer()), typ: x.Type()}
+		if visited[v] {
+			return true
+		}
+		visited[v] = true
+	}
+
+	switch x.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if x.IsNil() || y.IsNil() {
+			return x.IsNil() == y.IsNil()
+		}
+		return deepEqual(x.Elem(), y.Elem(), visited)
+	case reflect.Slice, reflect.Array:
+		for i := range x.Len() {
+			if !deepEqual(x.Index(i), y.Index(i), visited) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if x.Len() != y.Len() {
+			return false
+		}
+		iter := x.MapRange()
+		for iter.Next() {
+			yv := y.MapIndex(iter.Key())
+			if !yv.IsValid() || !deepEqual(iter.Value(), yv, visited) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := range x.NumField() {
+			if !deepEqual(x.Field(i), y.Field(i), visited) {
+				return false
+			}
+		}
+		return true
+	case reflect.Bool:
+		return x.Bool() == y.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return x.Int() == y.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return x.Uint() == y.Uint()
+	case reflect.Float32, reflect.Float64:
+		return x.Float() == y.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return x.Complex() == y.Complex()
+	case reflect.String:
+		return x.String() == y.String()
+	case reflect.Func:
+		return x.IsNil() && y.IsNil()
+	}
+	// channels and unsafe pointers
+	return x.Pointer() == y.Pointer()
+}
+
+// equalJSON reports whether a and b hold the same JSON document.
+func equalJSON(a, b json.RawMessage) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(x, y)
+}
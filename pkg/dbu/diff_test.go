@@ -0,0 +1,194 @@
+package dbu
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRDBUpdateMap(t *testing.T) {
+	SetDefaultColumnNameFunc(DefaultSnakeCaseNamer)
+
+	type Details struct {
+		Text *string
+		PDF  string
+	}
+	type Travel struct {
+		ID        string         `gorm:"column:travel_id"`
+		Name      string         `json:"name"`
+		StartAt   time.Time      `json:"startAt"`
+		EndAt     *time.Time     `json:"endAt"`
+		Meta      map[string]any `gorm:"type:jsonb" json:"meta"`
+		Tags      []string       `gorm:"type:jsonb" json:"tags"`
+		Nickname  Nullable[string]
+		Details   *Details `gorm:"embedded;embeddedPrefix:details_"`
+		UpdatedBy string
+	}
+
+	text := "hello"
+	startAt := time.Date(2025, 10, 25, 8, 0, 0, 0, time.UTC)
+	endAt := startAt.Add(time.Hour)
+	old := &Travel{
+		ID:        "t1",
+		Name:      "Taipei",
+		StartAt:   startAt,
+		EndAt:     &endAt,
+		Meta:      map[string]any{"a": 1, "b": []any{"x"}},
+		Tags:      []string{"city"},
+		Nickname:  NewNullable("tpe"),
+		Details:   &Details{Text: &text, PDF: "a.pdf"},
+		UpdatedBy: "neil",
+	}
+
+	t.Run("[SUCCESS] should return only the changed columns", func(t *testing.T) {
+		taipei := time.FixedZone("Asia/Taipei", 8*60*60)
+		updated := *old
+		updated.Name = "Kaohsiung"
+		updated.StartAt = startAt.In(taipei)                   // same instant
+		updated.Meta = map[string]any{"b": []any{"x"}, "a": 1} // same document
+		updated.Tags = []string{"city", "food"}
+		updated.EndAt = nil
+		updated.Nickname = NewNull[string]()
+		updated.Details = &Details{Text: &text, PDF: "b.pdf"}
+		updated.UpdatedBy = "admin"
+
+		actual, changes, err := DiffRDBUpdateMap(old, &updated, &DiffOptions{SkipFields: []string{"updated_by"}, Changes: true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":        "Kaohsiung",
+			"end_at":      nil,
+			"tags":        json.RawMessage(`["city","food"]`),
+			"nickname":    nil,
+			"details_pdf": "b.pdf",
+		}, actual)
+		assert.Equal(t, []Change{
+			{Key: "name", Old: "Taipei", New: "Kaohsiung"},
+			{Key: "end_at", Old: endAt, New: nil},
+			{Key: "tags", Old: json.RawMessage(`["city"]`), New: json.RawMessage(`["city","food"]`)},
+			{Key: "nickname", Old: "tpe", New: nil},
+			{Key: "details_pdf", Old: "a.pdf", New: "b.pdf"},
+		}, changes)
+	})
+
+	t.Run("[SUCCESS] should compare times nested in jsonb columns by their instant", func(t *testing.T) {
+		type Schedule struct {
+			At time.Time `json:"at"`
+		}
+		type Trip struct {
+			Schedule Schedule `gorm:"type:jsonb" json:"schedule"`
+		}
+		taipei := time.FixedZone("Asia/Taipei", 8*60*60)
+
+		actual, changes, err := DiffRDBUpdateMap(&Trip{Schedule{At: startAt}}, &Trip{Schedule{At: startAt.In(taipei)}}, &DiffOptions{Changes: true})
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+		assert.Empty(t, changes)
+
+		actual, _, err = DiffRDBUpdateMap(&Trip{Schedule{At: startAt}}, &Trip{Schedule{At: endAt.In(taipei)}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"schedule": json.RawMessage(`{"at":"2025-10-25T17:00:00+08:00"}`)}, actual)
+	})
+
+	t.Run("[SUCCESS] should set the columns of removed embedded structs to NULL", func(t *testing.T) {
+		updated := *old
+		updated.Details = nil
+
+		actual, changes, err := DiffRDBUpdateMap(*old, updated, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"details_text": nil, "details_pdf": nil}, actual)
+		assert.Nil(t, changes)
+
+		actual, _, err = DiffRDBUpdateMap(old, old, nil)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("[SUCCESS] should compare nil snapshots as zero values", func(t *testing.T) {
+		actual, _, err := DiffRDBUpdateMap((*Travel)(nil), &Travel{Name: "Taipei"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"name": "Taipei"}, actual)
+	})
+
+	t.Run("[FAILURE] should reject snapshots of different types", func(t *testing.T) {
+		_, _, err := DiffRDBUpdateMap(old, &Details{}, nil)
+		assert.EqualError(t, err, "DiffRDBUpdateMap old and new must be the same type, got dbu.Travel and dbu.Details")
+
+		_, _, err = DiffRDBUpdateMap(old, "new", nil)
+		assert.EqualError(t, err, "DiffRDBUpdateMap input must be a struct or pointer to struct, got string")
+
+		_, _, err = DiffRDBUpdateMap(old, &Travel{Meta: map[string]any{"c": make(chan int)}}, nil)
+		assert.ErrorContains(t, err, "failed to marshal field Meta (meta) to JSON")
+	})
+}
+
+func TestDiffMongoUpdateMap(t *testing.T) {
+	type Address struct {
+		Street string  `bson:"street"`
+		City   *string `bson:"city"`
+	}
+	type User struct {
+		Name      string    `bson:"name"`
+		Address   Address   `bson:"address"`
+		Billing   *Address  `bson:"billing"`
+		UpdatedAt time.Time `bson:"updated_at"`
+	}
+
+	city := "Taipei"
+	updatedAt := time.Date(2025, 10, 25, 8, 0, 0, 0, time.UTC)
+	old := User{Name: "neil", Address: Address{Street: "Main St", City: &city}, Billing: &Address{Street: "Side St"}, UpdatedAt: updatedAt}
+
+	t.Run("[SUCCESS] should set only the changed nested paths", func(t *testing.T) {
+		updated := old
+		updated.Address = Address{Street: "Main St"}
+		updated.Billing = nil
+		updated.UpdatedAt = updatedAt.Local()
+
+		actual, changes, err := DiffMongoUpdateMap(old, updated, &DiffOptions{SkipFields: []string{"billing"}, Changes: true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"address.city": nil}, actual)
+		assert.Equal(t, []Change{{Key: "address.city", Old: "Taipei", New: nil}}, changes)
+
+		actual, _, err = DiffMongoUpdateMap(old, updated, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"address.city": nil, "billing.street": nil}, actual)
+	})
+
+	t.Run("[SUCCESS] should compare nested times by their instant", func(t *testing.T) {
+		type Window struct {
+			From time.Time `bson:"from"`
+		}
+		type Schedule struct {
+			Slots   []time.Time          `bson:"slots"`
+			Windows []Window             `bson:"windows"`
+			Due     map[string]time.Time `bson:"due"`
+		}
+		taipei := time.FixedZone("Asia/Taipei", 8*60*60)
+		old := Schedule{
+			Slots:   []time.Time{updatedAt},
+			Windows: []Window{{From: updatedAt}},
+			Due:     map[string]time.Time{"a": updatedAt},
+		}
+		updated := Schedule{
+			Slots:   []time.Time{updatedAt.In(taipei)},
+			Windows: []Window{{From: updatedAt.In(taipei)}},
+			Due:     map[string]time.Time{"a": updatedAt.In(taipei)},
+		}
+
+		actual, _, err := DiffMongoUpdateMap(old, updated, nil)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+
+		updated.Windows = []Window{{From: updatedAt.Add(time.Second)}}
+		actual, _, err = DiffMongoUpdateMap(old, updated, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"windows": updated.Windows}, actual)
+	})
+
+	t.Run("[FAILURE] should reject snapshots of different types", func(t *testing.T) {
+		_, _, err := DiffMongoUpdateMap(old, Address{}, nil)
+		assert.EqualError(t, err, "DiffMongoUpdateMap old and new must be the same type, got dbu.User and dbu.Address")
+	})
+}
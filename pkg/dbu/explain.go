@@ -0,0 +1,177 @@
+package dbu
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Decision is how an update-map builder handles a field of a struct, as
+// returned by ExplainRDBUpdateMap and ExplainMongoUpdateMap.
+type Decision struct {
+	Field    string   `json:"field"`              // Go field path, e.g. "Audit.CreatedBy"
+	Key      string   `json:"key,omitempty"`      // column or dotted path, "" if the field has none
+	Reason   string   `json:"reason"`             // how the key is named, or why the field is skipped
+	Skipped  bool     `json:"skipped"`            // the field is left out of the update map
+	Problems []string `json:"problems,omitempty"` // ambiguous tags and key conflicts
+}
+
+// ExplainRDBUpdateMap returns the decision of RDBUpdateMap(x, opts...) for each field of x, in
+// field order, with the fields of embedded structs flattened: the column, how it is named, and
+// whether and why the field is skipped. It is meant for debugging, e.g.:
+//
+//	decisions, _ := dbu.ExplainRDBUpdateMap(req, dbu.WithSkip("id"))
+//	for _, d := range decisions {
+//		fmt.Printf("%-20s %-20s skipped=%t %s %v\n", d.Field, d.Key, d.Skipped, d.Reason, d.Problems)
+//	}
+//
+// Fields whose columns conflict, which make RDBUpdateMap fail, and fields with ambiguous tags,
+// as reported by ValidateRDBTags, list the problems. A nil pointer x explains the zero value.
+//
+// Returns an error if x is not a struct or pointer to struct.
+func ExplainRDBUpdateMap(x any, opts ...Option) ([]Decision, error) {
+	val, err := explainValue(x, "ExplainRDBUpdateMap")
+	if err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	plan := o.columnNamer().rdbPlan(val.Type())
+
+	decisions := make([]Decision, 0, len(plan.fields)+len(plan.dropped))
+	indexes := make([][]int, 0, cap(decisions))
+	for _, f := range plan.fields {
+		var skip string
+		switch {
+		case slices.Contains(o.skip, f.column):
+			skip = "skipped by WithSkip"
+		case o.only != nil && !slices.Contains(o.only, f.column):
+			skip = "not selected by WithOnly"
+		default:
+			skip = unsetReason(val, f.index, f.ptr, f.nullable, "embedded")
+			if skip == "" && f.omitted(val, o.zero) {
+				skip = "zero value omitted by the zero-value policy"
+			}
+		}
+		decisions = append(decisions, decision(f.name, f.column, f.reason, skip, f.problems))
+		indexes = append(indexes, f.index)
+	}
+	return appendDropped(decisions, indexes, plan.dropped), nil
+}
+
+// ExplainMongoUpdateMap returns the decision of BuildMongoUpdateMap(x, skipFields) for each
+// field of x, as ExplainRDBUpdateMap does, with the fields of nested structs flattened into
+// dotted paths. Fields with an update operator tell it in their reason.
+//
+// Returns an error if x is not a struct or pointer to struct.
+func ExplainMongoUpdateMap(x any, skipFields []string) ([]Decision, error) {
+	val, err := explainValue(x, "ExplainMongoUpdateMap")
+	if err != nil {
+		return nil, err
+	}
+	skipMap := make(map[string]struct{}, len(skipFields))
+	for _, field := range skipFields {
+		skipMap[field] = struct{}{}
+	}
+	plan := mongoPlanOf(val.Type())
+
+	decisions := make([]Decision, 0, len(plan.fields)+len(plan.dropped))
+	indexes := make([][]int, 0, cap(decisions))
+	for _, f := range plan.fields {
+		skip := "skipped by skipFields"
+		if !skippedPath(skipMap, f.path) {
+			skip = unsetReason(val, f.index, f.ptr, f.nullable, "nested")
+		}
+		decisions = append(decisions, decision(f.name, f.path, f.reason, skip, f.problems))
+		indexes = append(indexes, f.index)
+	}
+	return appendDropped(decisions, indexes, plan.dropped), nil
+}
+
+// ValidateRDBTags checks the tags of the struct type of x, a struct or pointer to struct, for
+// BuildRDBUpdateMap with the default namer. It returns an error listing each problem:
+//
+//   - fields mapping to the same column, e.g. a field of an embedded struct without a prefix
+//     shadowing an outer field, which make the builders fail;
+//   - `gorm:"column:..."` settings and bson tags naming different columns, of which the GORM
+//     one is used;
+//   - jsonb fields without a GORM column, bson or json name, whose column is named by the
+//     namer from the Go name.
+func ValidateRDBTags(x any) error {
+	val, err := explainValue(x, "ValidateRDBTags")
+	if err != nil {
+		return err
+	}
+	plan := defaultNamer.Load().rdbPlan(val.Type())
+	return errors.Join(plan.err, plan.tagErr)
+}
+
+// ValidateMongoTags checks the tags of the struct type of x, a struct or pointer to struct, for
+// BuildMongoUpdateMap and MongoUpdate. It returns an error listing the fields mapping to the
+// same path, e.g. through `bson:",inline"` structs, and the paths nested in the path of
+// another field, which make the builders fail.
+func ValidateMongoTags(x any) error {
+	val, err := explainValue(x, "ValidateMongoTags")
+	if err != nil {
+		return err
+	}
+	return mongoPlanOf(val.Type()).err
+}
+
+// explainValue returns the struct x or x points to, or its zero value for
+// a nil pointer, and an error naming the function fn if x is not a struct
+// or pointer to struct.
+func explainValue(x any, fn string) (reflect.Value, error) {
+	val, err := structValue(x, fn)
+	if err != nil || val.IsValid() {
+		return val, err
+	}
+	t := reflect.TypeOf(x).Elem()
+	if t.Kind() != reflect.Struct {
+		return val, fmt.Errorf("%s input must be a struct or pointer to struct, got pointer to %s", fn, t.Kind())
+	}
+	return reflect.Zero(t), nil
+}
+
+// unsetReason returns why the field at index of the struct v, a pointer if
+// ptr is set and a Nullable if null is set, is unset, or "" if it is set.
+// kind names the structs holding fields, "embedded" or "nested".
+func unsetReason(v reflect.Value, index []int, ptr, null bool, kind string) string {
+	fieldVal, ok := fieldByIndex(v, index)
+	if !ok {
+		return "inside a nil " + kind + " pointer"
+	}
+	if ptr {
+		if fieldVal.IsNil() {
+			return "nil pointer"
+		}
+		fieldVal = fieldVal.Elem()
+	}
+	if null {
+		if _, ok := nullableValue(fieldVal); !ok {
+			return "unset Nullable"
+		}
+	}
+	return ""
+}
+
+// decision returns the decision of a planned field, skipped for the reason
+// skip if it is not "".
+func decision(field, key, reason, skip string, problems []string) Decision {
+	d := Decision{Field: field, Key: key, Reason: reason, Problems: slices.Clone(problems)}
+	if skip != "" {
+		d.Reason, d.Skipped = skip, true
+	}
+	return d
+}
+
+// appendDropped returns decisions, of the fields at indexes, with the
+// dropped fields of a plan inserted in field order.
+func appendDropped(decisions []Decision, indexes [][]int, dropped []droppedField) []Decision {
+	for _, f := range dropped {
+		i, _ := slices.BinarySearchFunc(indexes, f.index, slices.Compare)
+		decisions = slices.Insert(decisions, i, Decision{Field: f.name, Reason: f.reason, Skipped: true})
+		indexes = slices.Insert(indexes, i, f.index)
+	}
+	return decisions
+}
@@ -0,0 +1,159 @@
+package dbu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyConflicts(t *testing.T) {
+	type Audit struct {
+		ID        string `json:"id"`
+		UpdatedBy string `json:"updated_by"`
+	}
+	type Order struct {
+		ID    string `json:"id"`
+		Audit Audit  `gorm:"embedded" bson:",inline"`
+	}
+	type Geo struct {
+		Geo string `bson:"geo"`
+		Lat string `bson:"geo.lat"`
+	}
+	type Profile struct {
+		Name  string   `gorm:"column:name" bson:"full_name"`
+		Extra []string `gorm:"type:jsonb" json:"-"`
+	}
+
+	t.Run("[FAILURE] should reject columns shared by several fields", func(t *testing.T) {
+		const problem = `dbu.Order: fields ID and Audit.ID both map to column "id"`
+		_, err := BuildRDBUpdateMap(Order{ID: "o1"}, nil)
+		assert.EqualError(t, err, problem)
+		_, err = RDBUpdateMap(Order{ID: "o1"}, WithSkip("id"))
+		assert.EqualError(t, err, problem)
+		_, _, err = DiffRDBUpdateMap(Order{}, Order{ID: "o1"}, nil)
+		assert.EqualError(t, err, problem)
+		assert.EqualError(t, ValidateRDBTags(&Order{}), problem)
+	})
+
+	t.Run("[FAILURE] should reject shared and nested Mongo paths", func(t *testing.T) {
+		_, err := BuildMongoUpdateMap(Order{ID: "o1"}, nil)
+		assert.EqualError(t, err, `dbu.Order: fields ID and Audit.ID both map to path "id"`)
+		_, err = MongoUpdate(Geo{}, nil)
+		assert.EqualError(t, err, `dbu.Geo: field Geo sets path "geo", which holds path "geo.lat" of field Lat`)
+		assert.Error(t, ValidateMongoTags((*Geo)(nil)))
+	})
+
+	t.Run("[SUCCESS] should report ambiguous tags without failing the builders", func(t *testing.T) {
+		err := ValidateRDBTags(Profile{})
+		assert.EqualError(t, err, `dbu.Profile: field Name: gorm column "name" and bson name "full_name" differ, the column is "name"
+dbu.Profile: field Extra: jsonb field has no gorm column, bson or json name, the column is named by the namer`)
+		assert.NoError(t, ValidateMongoTags(Profile{}))
+
+		_, err = BuildRDBUpdateMap(Profile{}, nil)
+		assert.NoError(t, err)
+	})
+}
+
+func TestExplainRDBUpdateMap(t *testing.T) {
+	type Audit struct {
+		UpdatedBy *string `json:"updated_by"`
+	}
+	type User struct {
+		ID       string           `json:"id"`
+		Name     string           `gorm:"column:full_name"`
+		Age      int              `json:"age"`
+		Nickname Nullable[string] `json:"nickname"`
+		Tags     []string         `gorm:"type:jsonb" json:"tags"`
+		Audit    *Audit           `gorm:"embedded;embeddedPrefix:audit_"`
+	}
+
+	t.Run("[SUCCESS] should explain the decision for each field", func(t *testing.T) {
+		decisions, err := ExplainRDBUpdateMap(&User{ID: "u1", Tags: []string{"a"}}, WithSkip("id"), WithZeroPolicy(ZeroOmit))
+		require.NoError(t, err)
+		assert.Equal(t, []Decision{
+			{Field: "ID", Key: "id", Reason: "skipped by WithSkip", Skipped: true},
+			{Field: "Name", Key: "full_name", Reason: "zero value omitted by the zero-value policy", Skipped: true},
+			{Field: "Age", Key: "age", Reason: "zero value omitted by the zero-value policy", Skipped: true},
+			{Field: "Nickname", Key: "nickname", Reason: "unset Nullable", Skipped: true},
+			{Field: "Tags", Key: "tags", Reason: "column from the json tag, marshaled to JSON"},
+			{Field: "Audit.UpdatedBy", Key: "audit_updated_by", Reason: "inside a nil embedded pointer", Skipped: true},
+		}, decisions)
+	})
+
+	t.Run("[SUCCESS] should explain included fields and their naming", func(t *testing.T) {
+		by := "admin"
+		decisions, err := ExplainRDBUpdateMap(User{Audit: &Audit{UpdatedBy: &by}}, WithOnly("full_name", "audit_updated_by"))
+		require.NoError(t, err)
+		assert.Equal(t, []Decision{
+			{Field: "ID", Key: "id", Reason: "not selected by WithOnly", Skipped: true},
+			{Field: "Name", Key: "full_name", Reason: "column from the gorm column setting"},
+			{Field: "Age", Key: "age", Reason: "not selected by WithOnly", Skipped: true},
+			{Field: "Nickname", Key: "nickname", Reason: "not selected by WithOnly", Skipped: true},
+			{Field: "Tags", Key: "tags", Reason: "not selected by WithOnly", Skipped: true},
+			{Field: "Audit.UpdatedBy", Key: "audit_updated_by", Reason: `column from the namer with the embedded prefix "audit_"`},
+		}, decisions)
+	})
+
+	t.Run("[SUCCESS] should list the fields without a column and the conflicts", func(t *testing.T) {
+		type Shadow struct {
+			ID    string `json:"id"`
+			Skip  string
+			Audit struct {
+				ID string `json:"id"`
+			} `gorm:"embedded"`
+		}
+		namer := func(name string) string {
+			if name == "Skip" {
+				return ""
+			}
+			return toSnakeCase(name)
+		}
+		decisions, err := ExplainRDBUpdateMap(Shadow{}, WithNamer(namer))
+		require.NoError(t, err)
+		conflict := []string{`fields ID and Audit.ID both map to column "id"`}
+		assert.Equal(t, []Decision{
+			{Field: "ID", Key: "id", Reason: "column from the namer", Problems: conflict},
+			{Field: "Skip", Reason: "no column, the namer gives an empty name", Skipped: true},
+			{Field: "Audit.ID", Key: "id", Reason: "column from the namer", Problems: conflict},
+		}, decisions)
+	})
+
+	t.Run("[FAILURE] should reject non-struct values", func(t *testing.T) {
+		_, err := ExplainRDBUpdateMap("user")
+		assert.Error(t, err)
+		_, err = ExplainRDBUpdateMap((*int)(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestExplainMongoUpdateMap(t *testing.T) {
+	type Address struct {
+		City *string `bson:"city"`
+		Zip  string  `bson:"zip"`
+	}
+	type User struct {
+		ID      string   `bson:"_id"`
+		Name    string   `json:"name"`
+		Hits    int      `dbu:"inc"`
+		Address *Address `bson:"address"`
+		Note    string   `bson:",omitempty"`
+	}
+
+	t.Run("[SUCCESS] should explain the decision for each field", func(t *testing.T) {
+		decisions, err := ExplainMongoUpdateMap(User{Address: &Address{}}, []string{"_id"})
+		require.NoError(t, err)
+		assert.Equal(t, []Decision{
+			{Field: "ID", Key: "_id", Reason: "skipped by skipFields", Skipped: true},
+			{Field: "Name", Key: "name", Reason: "key from the json tag"},
+			{Field: "Hits", Key: "hits", Reason: "key from the field name, updated with $inc"},
+			{Field: "Address.City", Key: "address.city", Reason: "nil pointer", Skipped: true},
+			{Field: "Address.Zip", Key: "address.zip", Reason: `key from the bson tag under "address"`},
+			{Field: "Note", Reason: "no key, the bson tag has no name", Skipped: true},
+		}, decisions)
+
+		decisions, err = ExplainMongoUpdateMap((*User)(nil), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "inside a nil nested pointer", decisions[4].Reason)
+	})
+}
@@ -0,0 +1,142 @@
+package dbu
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/byte4cat/nbx/v2/internal/dbutag"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// BuildRDBUpdateMapFromFieldMask constructs a map[string]any for use in relational database
+// updates from the paths of a protobuf FieldMask, as received by gRPC Update methods.
+//
+// Each path selects a field of the model struct (or pointer to struct), segment by segment: a
+// segment matches a field by its name in snake_case, its `json` tag name, or its Go name, e.g.
+// "display_name" matches `DisplayName string`. The selected fields get the same column keys as
+// with BuildRDBUpdateMap, including the prefixes of `embedded` structs, and a path selecting an
+// embedded struct selects all of its columns.
+//
+// The mask signals which fields to update, so masked fields are always included, with their zero
+// values: nil pointers, fields inside nil embedded pointers and unset Nullable values are set to
+// nil, i.e. NULL.
+//
+// mapping renames proto paths whose names differ from the model's, from the proto path to the
+// model path. It applies to the longest mapped prefix of a path, so {"info": "details"} maps
+// "info.text" to "details.text". It may be nil.
+//
+// Returns an error if the model is not a struct or pointer to struct, if a path names an unknown
+// field or a field without a column, or if a path selects inside a column that is not an embedded
+// struct, such as a key of a jsonb column. A nil model pointer or an empty mask gives an empty map.
+//
+// Example:
+//
+//	type Details struct {
+//		Text *string
+//		PDF  string
+//	}
+//	type Travel struct {
+//		ID      string
+//		Name    string   `json:"title"`
+//		Details *Details `gorm:"embedded;embeddedPrefix:details_"`
+//	}
+//
+//	mask := &fieldmaskpb.FieldMask{Paths: []string{"title", "info.text"}}
+//	m, err := BuildRDBUpdateMapFromFieldMask(&Travel{Name: "Taipei"}, mask, map[string]string{"info": "details"})
+//	// m == map[string]any{"name": "Taipei", "details_text": nil}
+func BuildRDBUpdateMapFromFieldMask(model any, mask *fieldmaskpb.FieldMask, mapping map[string]string) (map[string]any, error) {
+	result := make(map[string]any)
+	val, err := structValue(model, "BuildRDBUpdateMapFromFieldMask")
+	if err != nil || !val.IsValid() {
+		return result, err
+	}
+
+	plan := defaultNamer.Load().rdbPlan(val.Type())
+	if plan.err != nil {
+		return result, plan.err
+	}
+	for _, path := range mask.GetPaths() {
+		index, err := maskFieldIndex(val.Type(), mapFieldPath(path, mapping))
+		if err != nil {
+			return result, fmt.Errorf("unknown field mask path %q: %w", path, err)
+		}
+
+		selected := false
+		for _, f := range plan.fields {
+			switch {
+			case hasIndexPrefix(f.index, index):
+				value, _, err := f.value(val, json.Marshal)
+				if err != nil {
+					return result, err
+				}
+				result[f.column] = value
+				selected = true
+			case hasIndexPrefix(index, f.index):
+				return result, fmt.Errorf("field mask path %q selects inside column %s, which is not an embedded struct", path, f.column)
+			}
+		}
+		if !selected {
+			return result, fmt.Errorf("field mask path %q selects a field without a column", path)
+		}
+	}
+	return result, nil
+}
+
+// mapFieldPath renames the longest prefix of path found in mapping.
+func mapFieldPath(path string, mapping map[string]string) string {
+	prefix := path
+	for {
+		if to, ok := mapping[prefix]; ok {
+			return to + path[len(prefix):]
+		}
+		i := strings.LastIndexByte(prefix, '.')
+		if i < 0 {
+			return path
+		}
+		prefix = prefix[:i]
+	}
+}
+
+// maskFieldIndex returns the index of the field of the struct type t
+// selected by the dotted path, as for reflect.Value.FieldByIndex.
+func maskFieldIndex(t reflect.Type, path string) ([]int, error) {
+	var index []int
+	for name := range strings.SplitSeq(path, ".") {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s is not a struct", t)
+		}
+		field, ok := maskField(t, name)
+		if !ok {
+			return nil, fmt.Errorf("%s has no field %q", t, name)
+		}
+		index = append(index, field.Index...)
+		t = field.Type
+	}
+	return index, nil
+}
+
+// maskField returns the exported field of the struct type t named name in
+// snake_case, by its json tag, or by its Go name.
+func maskField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if toSnakeCase(field.Name) == name || dbutag.Name(field.Tag.Get("json")) == name || field.Name == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// hasIndexPrefix reports whether the field index starts with prefix.
+func hasIndexPrefix(index, prefix []int) bool {
+	return len(index) >= len(prefix) && slices.Equal(index[:len(prefix)], prefix)
+}
@@ -0,0 +1,104 @@
+package dbu
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestBuildRDBUpdateMapFromFieldMask(t *testing.T) {
+	SetDefaultColumnNameFunc(DefaultSnakeCaseNamer)
+
+	type Geo struct {
+		Lat float64
+		Lng float64
+	}
+	type Details struct {
+		Text *string
+		PDF  string `json:"pdf"`
+		Geo  *Geo   `gorm:"embedded;embeddedPrefix:geo_"`
+	}
+	type Travel struct {
+		ID          string   `gorm:"column:travel_id"`
+		Name        string   `json:"title"`
+		IsPublic    *bool    `json:"isPublic"`
+		Tags        []string `gorm:"type:jsonb" json:"tags"`
+		Nickname    Nullable[string]
+		Details     *Details       `gorm:"embedded;embeddedPrefix:details_"`
+		Destination map[string]any `gorm:"type:jsonb"`
+		Origin      Geo            `gorm:"type:jsonb"`
+	}
+
+	mask := func(paths ...string) *fieldmaskpb.FieldMask {
+		return &fieldmaskpb.FieldMask{Paths: paths}
+	}
+	text := "hello"
+	travel := &Travel{ID: "t1", Name: "Taipei", Tags: []string{"a"}, Details: &Details{Text: &text}}
+
+	t.Run("[SUCCESS] should include masked fields with their zero values", func(t *testing.T) {
+		actual, err := BuildRDBUpdateMapFromFieldMask(travel, mask("title", "is_public", "tags", "nickname", "details.pdf"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":        "Taipei",
+			"is_public":   nil,
+			"tags":        json.RawMessage(`["a"]`),
+			"nickname":    nil,
+			"details_pdf": "",
+		}, actual)
+	})
+
+	t.Run("[SUCCESS] should select all columns of embedded structs", func(t *testing.T) {
+		actual, err := BuildRDBUpdateMapFromFieldMask(travel, mask("details"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"details_text":    "hello",
+			"details_pdf":     "",
+			"details_geo_lat": nil,
+			"details_geo_lng": nil,
+		}, actual)
+
+		actual, err = BuildRDBUpdateMapFromFieldMask(&Travel{}, mask("details.geo.lat"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"details_geo_lat": nil}, actual)
+	})
+
+	t.Run("[SUCCESS] should rename paths by the mapping", func(t *testing.T) {
+		actual, err := BuildRDBUpdateMapFromFieldMask(travel, mask("travel_id", "info.text", "info.location.lng"), map[string]string{
+			"travel_id":     "id",
+			"info":          "details",
+			"info.location": "details.geo",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"travel_id": "t1", "details_text": "hello", "details_geo_lng": nil}, actual)
+	})
+
+	t.Run("[SUCCESS] should return an empty map for empty masks and nil models", func(t *testing.T) {
+		actual, err := BuildRDBUpdateMapFromFieldMask(travel, nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+
+		actual, err = BuildRDBUpdateMapFromFieldMask((*Travel)(nil), mask("title"), nil)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("[FAILURE] should reject unknown and invalid paths", func(t *testing.T) {
+		_, err := BuildRDBUpdateMapFromFieldMask(travel, mask("title", "subtitle"), nil)
+		assert.EqualError(t, err, `unknown field mask path "subtitle": dbu.Travel has no field "subtitle"`)
+
+		_, err = BuildRDBUpdateMapFromFieldMask(travel, mask("title.text"), nil)
+		assert.EqualError(t, err, `unknown field mask path "title.text": string is not a struct`)
+
+		_, err = BuildRDBUpdateMapFromFieldMask(travel, mask("destination.city"), nil)
+		assert.EqualError(t, err, `unknown field mask path "destination.city": map[string]interface {} is not a struct`)
+
+		_, err = BuildRDBUpdateMapFromFieldMask(travel, mask("origin.lat"), nil)
+		assert.EqualError(t, err, `field mask path "origin.lat" selects inside column origin, which is not an embedded struct`)
+
+		_, err = BuildRDBUpdateMapFromFieldMask([]Travel{}, mask("title"), nil)
+		assert.EqualError(t, err, "BuildRDBUpdateMapFromFieldMask input must be a struct or pointer to struct, got slice")
+	})
+}
@@ -0,0 +1,303 @@
+package dbu
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/byte4cat/nbx/v2/internal/dbutag"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// filterOps are the operators of the fields of filter structs.
+var filterOps = []string{"eq", "ne", "gt", "gte", "lt", "lte", "in", "like", "ilike", "between", "isnull"}
+
+// filterField is a field of a filter struct, with its column or path as
+// resolved by the plan of the update builders.
+type filterField struct {
+	name  string // Go field path, for errors
+	key   string
+	index []int
+	ptr   bool
+}
+
+// filterCond is the condition of a set field of a filter struct.
+type filterCond struct {
+	key   string
+	op    string
+	value reflect.Value // dereferenced
+}
+
+// ApplyFilter adds the conditions of a filter struct, or pointer to struct, to the WHERE clause
+// of db, joined with AND, and returns the new *gorm.DB, e.g. for the query parameters of a list
+// endpoint:
+//
+//	type ListUsersFilter struct {
+//		Status  *string    `form:"status"`                                  // status = ?
+//		MinAge  *int       `form:"min_age" dbu:"col=age,op=gte"`            // age >= ?
+//		Roles   []string   `form:"role" dbu:"col=role,op=in"`               // role IN (?,...)
+//		Name    *string    `form:"name" dbu:"op=ilike"`                     // LOWER(name) LIKE LOWER(?)
+//		Created *[2]string `form:"created" dbu:"col=created_at,op=between"` // created_at BETWEEN ? AND ?
+//		Deleted *bool      `form:"deleted" dbu:"col=deleted_at,op=isnull"`  // deleted_at IS NULL if true
+//	}
+//
+//	tx, err := dbu.ApplyFilter(db.Model(&User{}), filter)
+//	err = tx.Find(&users).Error
+//
+// Each field is a condition on its column, named as by BuildRDBUpdateMap, including the prefixes
+// of `embedded` structs, unless the `col` option of its `dbu` tag names it. The `op` option sets
+// the operator, "eq" by default:
+//
+//   - eq, ne, gt, gte, lt, lte: =, <>, >, >=, <, <=.
+//   - in: IN, for slices and arrays.
+//   - like: LIKE, for strings used as patterns as they are, e.g. "ab%".
+//   - ilike: a case-insensitive LIKE, as LOWER(column) LIKE LOWER(?) for portability.
+//   - between: BETWEEN, for slices and arrays of the two bounds.
+//   - isnull: IS NULL if the bool is true, IS NOT NULL if false.
+//
+// Nil pointers, nil slices and maps, fields inside nil embedded pointers and fields tagged
+// `dbu:"-"` are ignored, so a filter without set fields returns db as it is.
+//
+// Returns an error if filter is not a struct or pointer to struct, if fields share a column as
+// for BuildRDBUpdateMap, if an operator is unknown or does not fit the type of its field, or if
+// the value of a between field does not hold two bounds.
+func ApplyFilter(db *gorm.DB, filter any) (*gorm.DB, error) {
+	val, err := structValue(filter, "ApplyFilter")
+	if err != nil || !val.IsValid() {
+		return db, err
+	}
+
+	plan := defaultNamer.Load().rdbPlan(val.Type())
+	if plan.err != nil {
+		return db, plan.err
+	}
+	fields := make([]filterField, len(plan.fields))
+	for i, f := range plan.fields {
+		fields[i] = filterField{name: f.name, key: f.column, index: f.index, ptr: f.ptr}
+	}
+	conds, err := filterConds(val, fields)
+	if err != nil || len(conds) == 0 {
+		return db, err
+	}
+
+	exprs := make([]clause.Expression, len(conds))
+	for i, c := range conds {
+		exprs[i] = c.gormExpression()
+	}
+	return db.Clauses(clause.Where{Exprs: exprs}), nil
+}
+
+// BuildMongoFilter is the MongoDB equivalent of ApplyFilter. It returns the query document of
+// the conditions of a filter struct, or pointer to struct, with the fields named and nested
+// structs flattened into dotted paths as by BuildMongoUpdateMap, unless the `col` option of
+// their `dbu` tag names them. The conditions on the same path are merged, e.g.
+// {"age": {"$gte": 18, "$lt": 65}}, and a lone eq condition is written as {"status": "active"}.
+//
+// The operators map to $eq, $ne, $gt, $gte, $lt, $lte, $in, $gte with $lte for between, and
+// {"$eq": null} or {"$ne": null} for isnull, which match missing fields as null. like and ilike
+// translate the SQL pattern to an anchored $regex, with % matching any string and _ any
+// character, ilike with the "i" option.
+//
+// A nil pointer or a filter without set fields gives an empty document, which matches all
+// documents.
+func BuildMongoFilter(filter any) (bson.D, error) {
+	doc := bson.D{}
+	val, err := structValue(filter, "BuildMongoFilter")
+	if err != nil || !val.IsValid() {
+		return doc, err
+	}
+
+	plan := mongoPlanOf(val.Type())
+	if plan.err != nil {
+		return doc, plan.err
+	}
+	fields := make([]filterField, len(plan.fields))
+	for i, f := range plan.fields {
+		fields[i] = filterField{name: f.name, key: f.path, index: f.index, ptr: f.ptr}
+	}
+	conds, err := filterConds(val, fields)
+	if err != nil {
+		return doc, err
+	}
+
+	for _, c := range conds {
+		ops := c.mongoOperators()
+		if i := slices.IndexFunc(doc, func(e bson.E) bool { return e.Key == c.key }); i >= 0 {
+			doc[i].Value = append(doc[i].Value.(bson.D), ops...)
+			continue
+		}
+		doc = append(doc, bson.E{Key: c.key, Value: ops})
+	}
+	for i := range doc {
+		if ops := doc[i].Value.(bson.D); len(ops) == 1 && ops[0].Key == "$eq" && ops[0].Value != nil {
+			doc[i].Value = ops[0].Value
+		}
+	}
+	return doc, nil
+}
+
+// filterConds returns the conditions of the set fields of the filter
+// struct v, in field order.
+func filterConds(v reflect.Value, fields []filterField) ([]filterCond, error) {
+	var conds []filterCond
+	for _, f := range fields {
+		field := v.Type().FieldByIndex(f.index)
+		if field.Tag.Get("dbu") == "-" {
+			continue
+		}
+		column, op := dbutag.FilterOptions(field.Tag)
+		if op == "" {
+			op = "eq"
+		}
+		if column == "" {
+			column = f.key
+		}
+		if err := checkFilterOp(f.name, op, field.Type, f.ptr); err != nil {
+			return nil, err
+		}
+
+		fieldVal, ok := fieldByIndex(v, f.index)
+		if !ok {
+			continue // inside a nil embedded or nested pointer
+		}
+		if f.ptr {
+			if fieldVal.IsNil() {
+				continue
+			}
+			fieldVal = fieldVal.Elem()
+		}
+		if (fieldVal.Kind() == reflect.Slice || fieldVal.Kind() == reflect.Map) && fieldVal.IsNil() {
+			continue
+		}
+		if op == "between" && fieldVal.Len() != 2 {
+			return nil, fmt.Errorf("field %s must hold 2 bounds for op between, got %d", f.name, fieldVal.Len())
+		}
+		conds = append(conds, filterCond{key: column, op: op, value: fieldVal})
+	}
+	return conds, nil
+}
+
+// checkFilterOp returns an error if op is not a filter operator or does
+// not fit the type t of the field name, or the type t points to if ptr is
+// set.
+func checkFilterOp(name, op string, t reflect.Type, ptr bool) error {
+	if !slices.Contains(filterOps, op) {
+		return fmt.Errorf("field %s has unknown filter op %q", name, op)
+	}
+	if ptr {
+		t = t.Elem()
+	}
+	var want string
+	switch kind := t.Kind(); op {
+	case "in":
+		if kind != reflect.Slice && kind != reflect.Array {
+			want = "a slice or array"
+		}
+	case "between":
+		if kind != reflect.Slice && (kind != reflect.Array || t.Len() != 2) {
+			want = "a slice or array of 2 bounds"
+		}
+	case "like", "ilike":
+		if kind != reflect.String {
+			want = "a string"
+		}
+	case "isnull":
+		if kind != reflect.Bool {
+			want = "a bool"
+		}
+	}
+	if want != "" {
+		return fmt.Errorf("field %s must be %s for op %s, got %s", name, want, op, t)
+	}
+	return nil
+}
+
+// gormExpression returns the WHERE expression of c.
+func (c filterCond) gormExpression() clause.Expression {
+	column := clause.Column{Name: c.key}
+	value := c.value.Interface()
+	switch c.op {
+	case "ne":
+		return clause.Neq{Column: column, Value: value}
+	case "gt":
+		return clause.Gt{Column: column, Value: value}
+	case "gte":
+		return clause.Gte{Column: column, Value: value}
+	case "lt":
+		return clause.Lt{Column: column, Value: value}
+	case "lte":
+		return clause.Lte{Column: column, Value: value}
+	case "in":
+		return clause.IN{Column: column, Values: c.values()}
+	case "like":
+		return clause.Like{Column: column, Value: value}
+	case "ilike":
+		return clause.Expr{SQL: "LOWER(?) LIKE LOWER(?)", Vars: []any{column, value}}
+	case "between":
+		return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []any{column, c.value.Index(0).Interface(), c.value.Index(1).Interface()}}
+	case "isnull":
+		if c.value.Bool() {
+			return clause.Eq{Column: column, Value: nil}
+		}
+		return clause.Neq{Column: column, Value: nil}
+	}
+	return clause.Eq{Column: column, Value: value}
+}
+
+// mongoOperators returns the query operators of c.
+func (c filterCond) mongoOperators() bson.D {
+	value := c.value.Interface()
+	switch c.op {
+	case "ne", "gt", "gte", "lt", "lte":
+		return bson.D{{Key: "$" + c.op, Value: value}}
+	case "in":
+		return bson.D{{Key: "$in", Value: c.values()}}
+	case "like", "ilike":
+		regex := primitive.Regex{Pattern: likePattern(c.value.String())}
+		if c.op == "ilike" {
+			regex.Options = "i"
+		}
+		return bson.D{{Key: "$regex", Value: regex}}
+	case "between":
+		return bson.D{{Key: "$gte", Value: c.value.Index(0).Interface()}, {Key: "$lte", Value: c.value.Index(1).Interface()}}
+	case "isnull":
+		if c.value.Bool() {
+			return bson.D{{Key: "$eq", Value: nil}}
+		}
+		return bson.D{{Key: "$ne", Value: nil}}
+	}
+	return bson.D{{Key: "$eq", Value: value}}
+}
+
+// values returns the elements of the slice or array value of c.
+func (c filterCond) values() []any {
+	values := make([]any, c.value.Len())
+	for i := range values {
+		values[i] = c.value.Index(i).Interface()
+	}
+	return values
+}
+
+// likePattern translates the SQL LIKE pattern to an anchored regular
+// expression.
+func likePattern(pattern string) string {
+	var b strings.Builder
+	b.WriteByte('^')
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteByte('.')
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteByte('$')
+	return b.String()
+}
@@ -0,0 +1,199 @@
+package dbu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+func TestApplyFilter(t *testing.T) {
+	type User struct {
+		ID        uint
+		Name      string
+		Age       int
+		Role      string
+		Status    string
+		CreatedAt time.Time
+		DeletedAt *time.Time
+	}
+	type Paging struct {
+		Status *string `json:"status"`
+	}
+	type UserFilter struct {
+		Name    *string    `form:"name" dbu:"op=ilike"`
+		MinAge  *int       `form:"min_age" dbu:"col=age,op=gte"`
+		MaxAge  *int       `form:"max_age" dbu:"col=age,op=lt"`
+		Roles   []string   `form:"role" dbu:"col=role,op=in"`
+		Created *[2]string `form:"created" dbu:"col=created_at,op=between"`
+		Deleted *bool      `form:"deleted" dbu:"col=deleted_at,op=isnull"`
+		Paging  *Paging    `gorm:"embedded"`
+		Page    int        `dbu:"-"`
+	}
+
+	db, err := gorm.Open(sqliteDialector{}, &gorm.Config{})
+	require.NoError(t, err)
+	query := func(filter any) (string, error) {
+		var err error
+		sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			tx, err = ApplyFilter(tx.Model(&User{}), filter)
+			return tx.Find(&[]User{})
+		})
+		return sql, err
+	}
+
+	t.Run("[SUCCESS] should translate the set fields to conditions", func(t *testing.T) {
+		name, minAge, maxAge, deleted, status := "Jo%", 18, 65, true, "active"
+		sql, err := query(&UserFilter{
+			Name:    &name,
+			MinAge:  &minAge,
+			MaxAge:  &maxAge,
+			Roles:   []string{"admin", "editor"},
+			Created: &[2]string{"2025-01-01", "2025-12-31"},
+			Deleted: &deleted,
+			Paging:  &Paging{Status: &status},
+			Page:    2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM `users` WHERE LOWER(`name`) LIKE LOWER(\"Jo%\") AND `age` >= 18 AND `age` < 65"+
+			" AND `role` IN (\"admin\",\"editor\") AND (`created_at` BETWEEN \"2025-01-01\" AND \"2025-12-31\")"+
+			" AND `deleted_at` IS NULL AND `status` = \"active\"", sql)
+	})
+
+	t.Run("[SUCCESS] should ignore nil pointers and slices", func(t *testing.T) {
+		deleted := false
+		sql, err := query(UserFilter{Deleted: &deleted, Paging: &Paging{}})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM `users` WHERE `deleted_at` IS NOT NULL", sql)
+
+		sql, err = query(&UserFilter{Roles: []string{}})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM `users` WHERE `role` IN (NULL)", sql)
+
+		for _, filter := range []any{UserFilter{}, (*UserFilter)(nil)} {
+			sql, err = query(filter)
+			require.NoError(t, err)
+			assert.Equal(t, "SELECT * FROM `users`", sql)
+		}
+	})
+
+	t.Run("[SUCCESS] should name columns as the update builders", func(t *testing.T) {
+		type StatusFilter struct {
+			Status *string `gorm:"column:state"`
+			Owner  *string `bson:"owner_id" dbu:"op=ne"`
+			Label  *string `json:"label"`
+		}
+		status, owner, label := "open", "u1", "x"
+		sql, err := query(StatusFilter{Status: &status, Owner: &owner, Label: &label})
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM `users` WHERE `state` = \"open\" AND `owner_id` <> \"u1\" AND `label` = \"x\"", sql)
+	})
+
+	t.Run("[FAILURE] should reject unknown and mismatched operators", func(t *testing.T) {
+		type badOp struct {
+			Age *int `dbu:"op=above"`
+		}
+		_, err := query(badOp{})
+		assert.EqualError(t, err, `field Age has unknown filter op "above"`)
+
+		type badType struct {
+			Name *string `dbu:"op=in"`
+		}
+		_, err = query(badType{})
+		assert.EqualError(t, err, "field Name must be a slice or array for op in, got string")
+
+		type badBounds struct {
+			Age []int `dbu:"op=between"`
+		}
+		_, err = query(badBounds{Age: []int{1}})
+		assert.EqualError(t, err, "field Age must hold 2 bounds for op between, got 1")
+
+		_, err = ApplyFilter(db, 42)
+		assert.Error(t, err)
+	})
+
+	t.Run("[FAILURE] should reject fields sharing a column", func(t *testing.T) {
+		type Audit struct {
+			Name *string `json:"name"`
+		}
+		type shadowed struct {
+			Name  *string `json:"name"`
+			Audit Audit   `gorm:"embedded"`
+		}
+		_, err := query(shadowed{})
+		assert.EqualError(t, err, `dbu.shadowed: fields Name and Audit.Name both map to column "name"`)
+	})
+}
+
+func TestBuildMongoFilter(t *testing.T) {
+	type Address struct {
+		City *string `bson:"city"`
+	}
+	type UserFilter struct {
+		Status  *string       `bson:"status"`
+		Name    *string       `bson:"name" dbu:"op=like"`
+		Email   *string       `bson:"email" dbu:"op=ilike"`
+		MinAge  *int          `dbu:"col=age,op=gte"`
+		MaxAge  *int          `dbu:"col=age,op=lte"`
+		Roles   []string      `dbu:"col=role,op=in"`
+		Created *[2]time.Time `dbu:"col=created_at,op=between"`
+		Deleted *bool         `dbu:"col=deleted_at,op=isnull"`
+		Address *Address      `bson:"address"`
+	}
+
+	t.Run("[SUCCESS] should build the query document of the set fields", func(t *testing.T) {
+		status, name, email, minAge, maxAge, deleted, city := "active", "Jo_n%", "A.B@x", 18, 65, false, "Taipei"
+		from, to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		filter, err := BuildMongoFilter(UserFilter{
+			Status:  &status,
+			Name:    &name,
+			Email:   &email,
+			MinAge:  &minAge,
+			MaxAge:  &maxAge,
+			Roles:   []string{"admin"},
+			Created: &[2]time.Time{from, to},
+			Deleted: &deleted,
+			Address: &Address{City: &city},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, bson.D{
+			{Key: "status", Value: "active"},
+			{Key: "name", Value: bson.D{{Key: "$regex", Value: primitive.Regex{Pattern: `^Jo.n.*$`}}}},
+			{Key: "email", Value: bson.D{{Key: "$regex", Value: primitive.Regex{Pattern: `^A\.B@x$`, Options: "i"}}}},
+			{Key: "age", Value: bson.D{{Key: "$gte", Value: 18}, {Key: "$lte", Value: 65}}},
+			{Key: "role", Value: bson.D{{Key: "$in", Value: []any{"admin"}}}},
+			{Key: "created_at", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lte", Value: to}}},
+			{Key: "deleted_at", Value: bson.D{{Key: "$ne", Value: nil}}},
+			{Key: "address.city", Value: "Taipei"},
+		}, filter)
+	})
+
+	t.Run("[SUCCESS] should give an empty document without set fields", func(t *testing.T) {
+		for _, filter := range []any{UserFilter{}, (*UserFilter)(nil), UserFilter{Address: &Address{}}} {
+			actual, err := BuildMongoFilter(filter)
+			require.NoError(t, err)
+			assert.Equal(t, bson.D{}, actual)
+		}
+	})
+
+	t.Run("[FAILURE] should reject mismatched operators", func(t *testing.T) {
+		type badType struct {
+			Deleted *string `dbu:"op=isnull"`
+		}
+		_, err := BuildMongoFilter(badType{})
+		assert.EqualError(t, err, "field Deleted must be a bool for op isnull, got string")
+	})
+
+	t.Run("[FAILURE] should reject fields sharing a path", func(t *testing.T) {
+		type shadowed struct {
+			Status *string `bson:"status"`
+			State  *string `bson:"status"`
+		}
+		_, err := BuildMongoFilter(shadowed{})
+		assert.EqualError(t, err, `dbu.shadowed: fields Status and State both map to path "status"`)
+	})
+}
@@ -0,0 +1,159 @@
+package dbu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/byte4cat/nbx/v2/internal/dbutag"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// GormColumnNameFunc returns a DefaultColumnNameFunc naming columns with a
+// GORM naming strategy, e.g. the NamingStrategy of the configured *gorm.DB,
+// so that BuildRDBUpdateMap names columns as GORM does:
+//
+//	dbu.SetDefaultColumnNameFunc(dbu.GormColumnNameFunc(db.NamingStrategy))
+func GormColumnNameFunc(namer schema.Namer) DefaultColumnNameFunc {
+	return func(fieldName string) string {
+		return namer.ColumnName("", fieldName)
+	}
+}
+
+// gormPlans caches the *rdbPlan of each GORM schema. Schemas are cached by
+// their *gorm.DB, so a schema pointer identifies a type and naming strategy.
+var gormPlans sync.Map // *schema.Schema -> *rdbPlan
+
+// BuildGormUpdateMap constructs a map[string]any for use in relational database updates, like
+// BuildRDBUpdateMap, but with the columns of the GORM schema of x, as parsed by db with its
+// naming strategy. The keys are thus the real columns GORM writes, and:
+//
+//   - Fields ignored with `gorm:"-"`, read-only fields (`gorm:"->"`, `gorm:"<-:false"`,
+//     `gorm:"<-:create"`) and associations are left out.
+//   - Fields with `autoUpdateTime`, including UpdatedAt, are left out, so that GORM sets them
+//     when the map is passed to Updates.
+//   - Anonymous embedded structs are flattened as GORM does, e.g. gorm.Model.
+//
+// Nil pointers and unset Nullable values are skipped, and jsonb fields without a GORM
+// serializer are marshaled to json.RawMessage, as by BuildRDBUpdateMap.
+//
+// Returns an error if x is not a struct or pointer to struct, if db cannot parse its schema,
+// if skipFields lists a key that is not a column, or if JSON marshaling fails.
+func BuildGormUpdateMap(db *gorm.DB, x any, skipFields []string) (map[string]any, error) {
+	result := make(map[string]any)
+	val, err := structValue(x, "BuildGormUpdateMap")
+	if err != nil {
+		return result, err
+	}
+
+	s, err := gormSchema(db, x)
+	if err != nil {
+		return result, err
+	}
+	plan := gormPlan(s)
+
+	var errs []error
+	for _, key := range skipFields {
+		if f := s.LookUpField(key); f == nil || f.DBName != key {
+			errs = append(errs, gormColumnError(s, key))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return result, err
+	}
+	if !val.IsValid() {
+		return result, nil
+	}
+
+	for _, f := range plan.fields {
+		if slices.Contains(skipFields, f.column) {
+			continue
+		}
+		value, ok, err := f.value(val, json.Marshal)
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			result[f.column] = value
+		}
+	}
+	return result, nil
+}
+
+// ValidateRDBUpdateMap checks the keys of an update map, e.g. built by BuildRDBUpdateMap with
+// the dbu column naming, against the GORM schema of model as parsed by db. It returns an error
+// listing each key that is not a column GORM updates: unknown columns, fields ignored with
+// `gorm:"-"`, read-only fields and associations.
+func ValidateRDBUpdateMap(db *gorm.DB, model any, m map[string]any) error {
+	if _, err := structValue(model, "ValidateRDBUpdateMap"); err != nil {
+		return err
+	}
+	s, err := gormSchema(db, model)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if f := s.LookUpField(key); f == nil || f.DBName != key || !f.Updatable {
+			errs = append(errs, gormColumnError(s, key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// gormSchema returns the GORM schema of model parsed by db.
+func gormSchema(db *gorm.DB, model any) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, fmt.Errorf("failed to parse the GORM schema of %T: %w", model, err)
+	}
+	return stmt.Schema, nil
+}
+
+// gormPlan returns the cached plan of the updatable columns of s.
+func gormPlan(s *schema.Schema) *rdbPlan {
+	if p, ok := gormPlans.Load(s); ok {
+		return p.(*rdbPlan)
+	}
+
+	p := &rdbPlan{}
+	for _, f := range s.Fields {
+		if f.DBName == "" || !f.Updatable || f.AutoUpdateTime > 0 {
+			continue
+		}
+		index := make([]int, len(f.StructField.Index))
+		for i, x := range f.StructField.Index {
+			if x < 0 {
+				x = -x - 1 // GORM marks embedded pointers with negative indexes
+			}
+			index[i] = x
+		}
+		ptr := f.FieldType.Kind() == reflect.Pointer
+		p.fields = append(p.fields, rdbField{
+			name:     f.Name,
+			column:   f.DBName,
+			index:    index,
+			ptr:      ptr,
+			jsonb:    f.Serializer == nil && dbutag.RDBJSONB(f.Tag),
+			nullable: isNullable(f.FieldType, ptr),
+		})
+	}
+
+	actual, _ := gormPlans.LoadOrStore(s, p)
+	return actual.(*rdbPlan)
+}
+
+// gormColumnError returns the error for a key that is not an updatable
+// column of s, saying why.
+func gormColumnError(s *schema.Schema, key string) error {
+	if f := s.LookUpField(key); f == nil || f.DBName != key {
+		return fmt.Errorf("%s has no column %q", s.Name, key)
+	}
+	return fmt.Errorf("column %q of %s is read-only", key, s.Name)
+}
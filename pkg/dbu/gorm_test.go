@@ -0,0 +1,120 @@
+package dbu
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestBuildGormUpdateMap(t *testing.T) {
+	type Owner struct {
+		ID   uint
+		Name string
+	}
+	type Details struct {
+		Text *string
+		PDF  string
+	}
+	type Travel struct {
+		gorm.Model
+		Name     string
+		APIKey   string
+		Tags     []string          `gorm:"type:jsonb"`
+		Labels   map[string]string `gorm:"serializer:json"`
+		Nickname Nullable[string]
+		Details  *Details `gorm:"embedded;embeddedPrefix:details_"`
+		Internal string   `gorm:"-"`
+		Code     string   `gorm:"<-:create"`
+		Total    int      `gorm:"->"`
+		EditedAt int64    `gorm:"autoUpdateTime:milli"`
+		OwnerID  uint
+		Owner    Owner
+	}
+
+	db, err := gorm.Open(nil, &gorm.Config{})
+	require.NoError(t, err)
+
+	text := "hello"
+	travel := &Travel{
+		Model:    gorm.Model{ID: 1, UpdatedAt: time.Now()},
+		Name:     "Taipei",
+		APIKey:   "key",
+		Tags:     []string{"a"},
+		Labels:   map[string]string{"k": "v"},
+		Nickname: NewNull[string](),
+		Details:  &Details{Text: &text},
+		Internal: "internal",
+		Code:     "TPE",
+		Total:    3,
+		EditedAt: 1,
+		OwnerID:  2,
+		Owner:    Owner{ID: 2, Name: "neil"},
+	}
+
+	t.Run("[SUCCESS] should use the columns of the GORM schema", func(t *testing.T) {
+		actual, err := BuildGormUpdateMap(db, travel, []string{"id", "created_at", "deleted_at"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":         "Taipei",
+			"api_key":      "key",
+			"tags":         json.RawMessage(`["a"]`),
+			"labels":       map[string]string{"k": "v"},
+			"nickname":     nil,
+			"details_text": "hello",
+			"details_pdf":  "",
+			"owner_id":     uint(2),
+		}, actual)
+	})
+
+	t.Run("[SUCCESS] should name columns with the GORM naming strategy", func(t *testing.T) {
+		t.Cleanup(func() { SetDefaultColumnNameFunc(nil) })
+
+		namer := schema.NamingStrategy{}
+		assert.Equal(t, "api_key", GormColumnNameFunc(namer)("APIKey"))
+
+		SetDefaultColumnNameFunc(GormColumnNameFunc(namer))
+		actual, err := BuildRDBUpdateMap(struct{ APIKey, UserID string }{"key", "u1"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"api_key": "key", "user_id": "u1"}, actual)
+	})
+
+	t.Run("[SUCCESS] should accept the updatable columns of a map", func(t *testing.T) {
+		m, err := BuildRDBUpdateMap(&Travel{Name: "Taipei", Details: &Details{PDF: "a.pdf"}}, nil)
+		require.NoError(t, err)
+		delete(m, "model")
+		delete(m, "internal")
+		delete(m, "code")
+		delete(m, "total")
+		delete(m, "owner")
+		assert.NoError(t, ValidateRDBUpdateMap(db, &Travel{}, m))
+	})
+
+	t.Run("[FAILURE] should reject keys that are not updatable columns", func(t *testing.T) {
+		err := ValidateRDBUpdateMap(db, Travel{}, map[string]any{
+			"name":     "Taipei",
+			"Name":     "Taipei",
+			"model":    gorm.Model{},
+			"internal": "internal",
+			"code":     "TPE",
+			"total":    3,
+			"owner":    Owner{},
+		})
+		assert.EqualError(t, err, `Travel has no column "Name"`+"\n"+
+			`column "code" of Travel is read-only`+"\n"+
+			`Travel has no column "internal"`+"\n"+
+			`Travel has no column "model"`+"\n"+
+			`Travel has no column "owner"`+"\n"+
+			`column "total" of Travel is read-only`)
+
+		_, err = BuildGormUpdateMap(db, travel, []string{"name", "title"})
+		assert.EqualError(t, err, `Travel has no column "title"`)
+
+		_, err = BuildGormUpdateMap(db, []Travel{}, nil)
+		assert.EqualError(t, err, "BuildGormUpdateMap input must be a struct or pointer to struct, got slice")
+	})
+}
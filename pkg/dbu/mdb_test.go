@@ -1,8 +1,15 @@
 package dbu
 
 import (
+	"net/url"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestParseMDBUpdateData(t *testing.T) {
@@ -26,7 +33,10 @@ func TestParseMDBUpdateData(t *testing.T) {
 			"age":       30,
 		}
 
-		actual := BuildMongoUpdateMap(test, []string{"id", "lastName"})
+		actual, err := BuildMongoUpdateMap(test, []string{"id", "lastName"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 		if !reflect.DeepEqual(actual, expected) {
 			t.Errorf("expected %v, got %v", expected, actual)
 		}
@@ -54,7 +64,10 @@ func TestParseMDBUpdateData(t *testing.T) {
 			"age":       30,
 		}
 
-		actual := BuildMongoUpdateMap(test, []string{})
+		actual, err := BuildMongoUpdateMap(test, []string{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 		if !reflect.DeepEqual(actual, expected) {
 			t.Errorf("expected %v, got %v", expected, actual)
 		}
@@ -84,9 +97,148 @@ func TestParseMDBUpdateData(t *testing.T) {
 			"age":       30,
 		}
 
-		actual := BuildMongoUpdateMap(test, []string{})
+		actual, err := BuildMongoUpdateMap(test, []string{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 		if !reflect.DeepEqual(actual, expected) {
 			t.Errorf("expected %v, got %v", expected, actual)
 		}
 	})
 }
+
+func TestBuildMongoUpdateMap_Nested(t *testing.T) {
+	type geo struct {
+		Lat float64 `bson:"lat"`
+		Lng float64 `bson:"lng"`
+	}
+	type address struct {
+		Street string  `bson:"street"`
+		City   *string `bson:"city"`
+		Geo    *geo    `bson:"geo"`
+	}
+	type Audit struct {
+		UpdatedBy string    `bson:"updated_by"`
+		UpdatedAt time.Time `bson:"updated_at"`
+	}
+	type Node struct {
+		Label string `bson:"label"`
+		Next  *Node  `bson:"next"`
+	}
+	type user struct {
+		Name    string            `bson:"name"`
+		Address address           `bson:"address"`
+		Billing *address          `bson:"billing"`
+		Tags    map[string]string `bson:"tags"`
+		Audit   `bson:",inline"`
+		*Node   `bson:",inline"`
+		secret  string
+	}
+
+	city := "Taipei"
+	updatedAt := time.Date(2025, 10, 25, 0, 0, 0, 0, time.UTC)
+	next := &Node{Label: "second"}
+	test := &user{
+		Name:    "neil",
+		Address: address{Street: "Main St", City: &city, Geo: &geo{Lat: 25, Lng: 121}},
+		Tags:    map[string]string{"a": "b"},
+		Audit:   Audit{UpdatedBy: "admin", UpdatedAt: updatedAt},
+		Node:    &Node{Label: "first", Next: next},
+		secret:  "secret",
+	}
+
+	t.Run("[SUCCESS] should flatten nested structs into dotted paths", func(t *testing.T) {
+		actual, err := BuildMongoUpdateMap(test, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":            "neil",
+			"address.street":  "Main St",
+			"address.city":    "Taipei",
+			"address.geo.lat": 25.0,
+			"address.geo.lng": 121.0,
+			"tags":            map[string]string{"a": "b"},
+			"updated_by":      "admin",
+			"updated_at":      updatedAt, // set as a whole
+			"label":           "first",
+			"next":            *next, // a recursive type is set as a whole
+		}, actual)
+	})
+
+	t.Run("[SUCCESS] should nest embedded structs without the inline option", func(t *testing.T) {
+		type stamp struct {
+			Audit
+			Deleted *time.Time `bson:"deleted_at"`
+		}
+
+		actual, err := BuildMongoUpdateMap(stamp{Audit: Audit{UpdatedBy: "admin", UpdatedAt: updatedAt}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"audit.updated_by": "admin", "audit.updated_at": updatedAt}, actual)
+	})
+
+	t.Run("[SUCCESS] should skip full paths and everything nested in them", func(t *testing.T) {
+		actual, err := BuildMongoUpdateMap(test, []string{"address.geo", "address.city", "next", "tags", "name", "updated_at", "label"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"address.street": "Main St", "updated_by": "admin"}, actual)
+	})
+
+	t.Run("[SUCCESS] should skip nil nested pointers", func(t *testing.T) {
+		actual, err := BuildMongoUpdateMap(&user{Address: address{Street: "Main St"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":           "",
+			"address.street": "Main St",
+			"tags":           map[string]string(nil),
+			"updated_by":     "",
+			"updated_at":     time.Time{},
+		}, actual)
+
+		actual, err = BuildMongoUpdateMap((*user)(nil), nil)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("[SUCCESS] should set driver values and structs without exported fields as a whole", func(t *testing.T) {
+		type opaque struct {
+			n int
+		}
+		type payment struct {
+			Amount primitive.Decimal128 `bson:"amount"`
+			Seen   primitive.Timestamp  `bson:"seen"`
+			Raw    primitive.Binary     `bson:"raw"`
+			Link   url.URL              `bson:"link"`
+			Opaque opaque               `bson:"opaque"`
+		}
+		amount, err := primitive.ParseDecimal128("12.50")
+		require.NoError(t, err)
+		p := payment{
+			Amount: amount,
+			Seen:   primitive.Timestamp{T: 1700000000, I: 1},
+			Raw:    primitive.Binary{Subtype: 4, Data: []byte{1, 2}},
+			Link:   url.URL{Scheme: "https", Host: "example.com"},
+			Opaque: opaque{n: 1},
+		}
+
+		actual, err := BuildMongoUpdateMap(p, nil)
+		require.NoError(t, err)
+		expected := map[string]any{"amount": p.Amount, "seen": p.Seen, "raw": p.Raw, "link": p.Link, "opaque": p.Opaque}
+		assert.Equal(t, expected, actual)
+
+		update, err := MongoUpdate(p, nil)
+		require.NoError(t, err)
+		assert.Equal(t, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "amount", Value: p.Amount},
+			{Key: "seen", Value: p.Seen},
+			{Key: "raw", Value: p.Raw},
+			{Key: "link", Value: p.Link},
+			{Key: "opaque", Value: p.Opaque},
+		}}}, update)
+	})
+
+	t.Run("[FAILURE] should return an error for non-struct input", func(t *testing.T) {
+		_, err := BuildMongoUpdateMap([]string{"a"}, nil)
+		assert.EqualError(t, err, "BuildMongoUpdateMap input must be a struct or pointer to struct, got slice")
+
+		_, err = BuildMongoUpdateMap(nil, nil)
+		assert.EqualError(t, err, "BuildMongoUpdateMap input must be a struct or pointer to struct, got nil")
+	})
+}
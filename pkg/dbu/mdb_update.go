@@ -0,0 +1,154 @@
+package dbu
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MongoUpdate builds a complete MongoDB update document from a struct (or pointer to struct),
+// ready to be passed to UpdateOne or UpdateMany.
+//
+// Fields are walked as by BuildMongoUpdateMap: keys are the dotted paths of the BSON fields,
+// nested structs are flattened, nil pointers are skipped, and skipFields holds the dotted paths
+// to exclude with everything nested in them. Each field then goes to an update operator:
+//
+//   - `dbu:"inc"`: `$inc`, for numeric fields.
+//   - `dbu:"push"`: `$push` with `$each`, for slices and arrays. Empty ones are skipped.
+//   - `dbu:"setOnInsert"`: `$setOnInsert`, for fields only written when an upsert inserts.
+//   - Any other field: `$set`.
+//
+// Explicit nulls go to `$unset` whatever the operator: null Nullable fields, and pointers to a nil
+// pointer, slice, map or interface. Unset Nullable fields are skipped.
+//
+// Struct fields with a `dbu` operator are used as a whole rather than flattened.
+//
+// The result is ordered: the operators come in the order above, starting with `$set` and
+// `$unset`, and omitted when empty; the paths of each operator in the order of the fields.
+// It is empty for a nil pointer or a struct without fields to update.
+//
+// Returns an error if the input is not a struct or pointer to struct, if its paths conflict as
+// for BuildMongoUpdateMap, or if a field does not fit its operator.
+//
+// Example:
+//
+//	type Counter struct {
+//		Name      *string   `bson:"name"`
+//		Nickname  **string  `bson:"nickname"`
+//		Hits      int       `bson:"hits" dbu:"inc"`
+//		Tags      []string  `bson:"tags" dbu:"push"`
+//		CreatedAt time.Time `bson:"created_at" dbu:"setOnInsert"`
+//	}
+//
+//	var noNickname *string
+//	update, err := MongoUpdate(Counter{Name: &name, Nickname: &noNickname, Hits: 1, Tags: []string{"a"}, CreatedAt: now}, nil)
+//	// update == bson.D{
+//	//   {Key: "$set", Value: bson.D{{Key: "name", Value: name}}},
+//	//   {Key: "$unset", Value: bson.D{{Key: "nickname", Value: ""}}},
+//	//   {Key: "$inc", Value: bson.D{{Key: "hits", Value: 1}}},
+//	//   {Key: "$push", Value: bson.D{{Key: "tags", Value: bson.D{{Key: "$each", Value: []string{"a"}}}}}},
+//	//   {Key: "$setOnInsert", Value: bson.D{{Key: "created_at", Value: now}}},
+//	// }
+func MongoUpdate(x any, skipFields []string) (bson.D, error) {
+	update := bson.D{}
+	val, err := structValue(x, "MongoUpdate")
+	if err != nil || !val.IsValid() {
+		return update, err
+	}
+
+	skipMap := make(map[string]struct{}, len(skipFields))
+	for _, field := range skipFields {
+		skipMap[field] = struct{}{}
+	}
+
+	plan := mongoPlanOf(val.Type())
+	if plan.err != nil {
+		return update, plan.err
+	}
+
+	var set, unset, inc, push, setOnInsert bson.D
+	for _, f := range plan.fields {
+		if skippedPath(skipMap, f.path) {
+			continue
+		}
+		fieldVal, ok := fieldByIndex(val, f.index)
+		if !ok {
+			continue // inside a nil nested pointer
+		}
+		if f.ptr {
+			if fieldVal.IsNil() {
+				continue
+			}
+			fieldVal = fieldVal.Elem()
+		}
+
+		value := fieldVal.Interface()
+		null := f.ptr && explicitNull(fieldVal)
+		if f.nullable {
+			if value, ok = nullableValue(fieldVal); !ok {
+				continue
+			}
+			null = value == nil
+		}
+		if null {
+			unset = append(unset, bson.E{Key: f.path, Value: ""})
+			continue
+		}
+
+		switch f.op {
+		case "$inc":
+			if kind := reflect.ValueOf(value).Kind(); !numeric(kind) {
+				return update, fmt.Errorf("field %s must be numeric for $inc, got %s", f.path, kind)
+			}
+			inc = append(inc, bson.E{Key: f.path, Value: value})
+		case "$push":
+			rv := reflect.ValueOf(value)
+			if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+				return update, fmt.Errorf("field %s must be a slice or array for $push, got %s", f.path, rv.Kind())
+			}
+			if rv.Len() == 0 {
+				continue
+			}
+			push = append(push, bson.E{Key: f.path, Value: bson.D{{Key: "$each", Value: value}}})
+		case "$setOnInsert":
+			setOnInsert = append(setOnInsert, bson.E{Key: f.path, Value: value})
+		default:
+			set = append(set, bson.E{Key: f.path, Value: value})
+		}
+	}
+
+	for _, op := range []bson.E{
+		{Key: "$set", Value: set},
+		{Key: "$unset", Value: unset},
+		{Key: "$inc", Value: inc},
+		{Key: "$push", Value: push},
+		{Key: "$setOnInsert", Value: setOnInsert},
+	} {
+		if len(op.Value.(bson.D)) > 0 {
+			update = append(update, op)
+		}
+	}
+	return update, nil
+}
+
+// explicitNull reports whether v, the value a pointer field points to, is
+// nil, i.e. the field is set to null.
+func explicitNull(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// numeric reports whether values of kind k can be incremented by $inc.
+func numeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package dbu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMongoUpdate(t *testing.T) {
+	type Stats struct {
+		Views int `bson:"views"`
+	}
+	type profile struct {
+		City    *string `bson:"city"`
+		Country string  `bson:"country"`
+	}
+	type user struct {
+		Name      *string    `bson:"name"`
+		Nickname  **string   `bson:"nickname"`
+		Labels    *[]string  `bson:"labels"`
+		Profile   *profile   `bson:"profile"`
+		Hits      int        `bson:"hits" dbu:"inc"`
+		Score     *float64   `bson:"score" dbu:"inc"`
+		Tags      []string   `bson:"tags" dbu:"push"`
+		Stats     Stats      `bson:"stats" dbu:"setOnInsert"`
+		CreatedAt *time.Time `bson:"created_at" dbu:"setOnInsert"`
+	}
+
+	name, city := "neil", "Taipei"
+	score := 0.5
+	createdAt := time.Date(2025, 10, 25, 0, 0, 0, 0, time.UTC)
+	var noNickname *string
+
+	t.Run("[SUCCESS] should build an ordered update document", func(t *testing.T) {
+		update, err := MongoUpdate(&user{
+			Name:      &name,
+			Nickname:  &noNickname,
+			Labels:    new([]string),
+			Profile:   &profile{City: &city},
+			Hits:      1,
+			Score:     &score,
+			Tags:      []string{"a", "b"},
+			Stats:     Stats{Views: 1},
+			CreatedAt: &createdAt,
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "name", Value: "neil"},
+				{Key: "profile.city", Value: "Taipei"},
+				{Key: "profile.country", Value: ""},
+			}},
+			{Key: "$unset", Value: bson.D{
+				{Key: "nickname", Value: ""},
+				{Key: "labels", Value: ""},
+			}},
+			{Key: "$inc", Value: bson.D{
+				{Key: "hits", Value: 1},
+				{Key: "score", Value: 0.5},
+			}},
+			{Key: "$push", Value: bson.D{
+				{Key: "tags", Value: bson.D{{Key: "$each", Value: []string{"a", "b"}}}},
+			}},
+			{Key: "$setOnInsert", Value: bson.D{
+				{Key: "stats", Value: Stats{Views: 1}},
+				{Key: "created_at", Value: createdAt},
+			}},
+		}, update)
+
+		_, err = bson.Marshal(update)
+		require.NoError(t, err)
+	})
+
+	t.Run("[SUCCESS] should omit empty operators and skipped paths", func(t *testing.T) {
+		update, err := MongoUpdate(user{Name: &name, Profile: &profile{City: &city}}, []string{"profile.country", "hits", "stats"})
+		require.NoError(t, err)
+		assert.Equal(t, bson.D{
+			{Key: "$set", Value: bson.D{{Key: "name", Value: "neil"}, {Key: "profile.city", Value: "Taipei"}}},
+		}, update)
+
+		update, err = MongoUpdate((*user)(nil), nil)
+		require.NoError(t, err)
+		assert.Empty(t, update)
+	})
+
+	t.Run("[FAILURE] should reject fields that do not fit their operator", func(t *testing.T) {
+		_, err := MongoUpdate(struct {
+			Name string `bson:"name" dbu:"inc"`
+		}{}, nil)
+		assert.EqualError(t, err, "field name must be numeric for $inc, got string")
+
+		_, err = MongoUpdate(struct {
+			Tag string `bson:"tag" dbu:"push"`
+		}{}, nil)
+		assert.EqualError(t, err, "field tag must be a slice or array for $push, got string")
+
+		_, err = MongoUpdate(1, nil)
+		assert.EqualError(t, err, "MongoUpdate input must be a struct or pointer to struct, got int")
+	})
+}
@@ -0,0 +1,167 @@
+package dbu
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Nullable is a value of type T that is either unset, explicitly null, or
+// set. It lets a PATCH request tell "leave the column alone" from "clear
+// the column":
+//
+//	type UpdateUserRequest struct {
+//		Nickname dbu.Nullable[string] `json:"nickname"`
+//	}
+//
+//	// {}                   -> unset, skipped by the update-map builders
+//	// {"nickname": null}   -> null, set to NULL (or $unset by MongoUpdate)
+//	// {"nickname": "neil"} -> set to "neil"
+//
+// The zero value is unset. Nullable implements json.Marshaler and
+// json.Unmarshaler, sql.Scanner and driver.Valuer, and bson.ValueMarshaler
+// and bson.ValueUnmarshaler; unset values are omitted by the `omitzero`
+// JSON option and the `omitempty` BSON option, and are written as null
+// otherwise.
+type Nullable[T any] struct {
+	value T
+	state nullState
+}
+
+type nullState uint8
+
+const (
+	nullUnset nullState = iota
+	nullNull
+	nullSet
+)
+
+// nullable is implemented by every Nullable[T], so the update-map builders
+// can read them through reflection.
+type nullable interface {
+	IsSet() bool
+	anyValue() any
+}
+
+// NewNullable returns a Nullable set to v.
+func NewNullable[T any](v T) Nullable[T] {
+	return Nullable[T]{value: v, state: nullSet}
+}
+
+// NewNull returns an explicitly null Nullable.
+func NewNull[T any]() Nullable[T] {
+	return Nullable[T]{state: nullNull}
+}
+
+// Set sets n to v.
+func (n *Nullable[T]) Set(v T) {
+	*n = NewNullable(v)
+}
+
+// SetNull sets n to null.
+func (n *Nullable[T]) SetNull() {
+	*n = NewNull[T]()
+}
+
+// Unset resets n to its unset zero value.
+func (n *Nullable[T]) Unset() {
+	*n = Nullable[T]{}
+}
+
+// IsSet reports whether n is null or holds a value.
+func (n Nullable[T]) IsSet() bool {
+	return n.state != nullUnset
+}
+
+// IsNull reports whether n is explicitly null.
+func (n Nullable[T]) IsNull() bool {
+	return n.state == nullNull
+}
+
+// IsZero reports whether n is unset.
+func (n Nullable[T]) IsZero() bool {
+	return n.state == nullUnset
+}
+
+// Get returns the value of n and whether it holds one.
+func (n Nullable[T]) Get() (T, bool) {
+	return n.value, n.state == nullSet
+}
+
+// anyValue returns the value of n, or nil if n does not hold one.
+func (n Nullable[T]) anyValue() any {
+	if n.state != nullSet {
+		return nil
+	}
+	return n.value
+}
+
+// MarshalJSON implements json.Marshaler. Unset and null values are
+// marshaled to null.
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if n.state != nullSet {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. As it is only called for keys
+// present in the input, absent keys leave n unset.
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		n.SetNull()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	n.Set(v)
+	return nil
+}
+
+// Scan implements sql.Scanner. NULL columns are scanned to null.
+func (n *Nullable[T]) Scan(src any) error {
+	var v sql.Null[T]
+	if err := v.Scan(src); err != nil {
+		return err
+	}
+	if !v.Valid {
+		n.SetNull()
+		return nil
+	}
+	n.Set(v.V)
+	return nil
+}
+
+// Value implements driver.Valuer. Unset and null values are NULL.
+func (n Nullable[T]) Value() (driver.Value, error) {
+	return sql.Null[T]{V: n.value, Valid: n.state == nullSet}.Value()
+}
+
+// MarshalBSONValue implements bson.ValueMarshaler. Unset and null values
+// are marshaled to null.
+func (n Nullable[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if n.state != nullSet {
+		return bson.TypeNull, nil, nil
+	}
+	return bson.MarshalValue(n.value)
+}
+
+// UnmarshalBSONValue implements bson.ValueUnmarshaler.
+func (n *Nullable[T]) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t == bson.TypeNull || t == bson.TypeUndefined {
+		n.SetNull()
+		return nil
+	}
+	var v T
+	if err := bson.UnmarshalValue(t, data, &v); err != nil {
+		return err
+	}
+	n.Set(v)
+	return nil
+}
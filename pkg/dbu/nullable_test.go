@@ -0,0 +1,123 @@
+package dbu
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNullable(t *testing.T) {
+	type request struct {
+		Nickname Nullable[string]   `json:"nickname,omitzero" bson:"nickname,omitempty"`
+		Age      Nullable[int]      `json:"age,omitzero" bson:"age,omitempty"`
+		Tags     Nullable[[]string] `json:"tags" bson:"tags"`
+	}
+
+	t.Run("[SUCCESS] should tell unset, null and set JSON values apart", func(t *testing.T) {
+		var req request
+		require.NoError(t, json.Unmarshal([]byte(`{"nickname": null, "age": 30}`), &req))
+
+		assert.True(t, req.Nickname.IsSet())
+		assert.True(t, req.Nickname.IsNull())
+		age, ok := req.Age.Get()
+		assert.True(t, ok)
+		assert.Equal(t, 30, age)
+		assert.False(t, req.Tags.IsSet())
+		assert.True(t, req.Tags.IsZero())
+
+		data, err := json.Marshal(req)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"nickname": null, "age": 30, "tags": null}`, string(data))
+	})
+
+	t.Run("[SUCCESS] should scan and value SQL NULLs", func(t *testing.T) {
+		var n Nullable[int64]
+		require.NoError(t, n.Scan(int64(7)))
+		assert.Equal(t, NewNullable(int64(7)), n)
+		require.NoError(t, n.Scan(nil))
+		assert.Equal(t, NewNull[int64](), n)
+
+		value, err := NewNullable("neil").Value()
+		require.NoError(t, err)
+		assert.Equal(t, "neil", value)
+		for _, n := range []Nullable[string]{NewNull[string](), {}} {
+			value, err = n.Value()
+			require.NoError(t, err)
+			assert.Nil(t, value)
+		}
+	})
+
+	t.Run("[SUCCESS] should round-trip BSON values", func(t *testing.T) {
+		data, err := bson.Marshal(request{Nickname: NewNull[string](), Tags: NewNullable([]string{"a"})})
+		require.NoError(t, err)
+		assert.Equal(t, `{"nickname": null,"tags": ["a"]}`, bson.Raw(data).String())
+
+		var req request
+		require.NoError(t, bson.Unmarshal(data, &req))
+		assert.Equal(t, request{Nickname: NewNull[string](), Tags: NewNullable([]string{"a"})}, req)
+	})
+
+	t.Run("[FAILURE] should reject values of another type", func(t *testing.T) {
+		var n Nullable[int]
+		assert.Error(t, json.Unmarshal([]byte(`"thirty"`), &n))
+		assert.Error(t, n.Scan("thirty"))
+		assert.False(t, n.IsSet())
+	})
+}
+
+func TestNullable_UpdateMaps(t *testing.T) {
+	type user struct {
+		Name     Nullable[string]   `json:"name"`
+		Nickname Nullable[string]   `json:"nickname"`
+		Age      *Nullable[int]     `json:"age"`
+		Tags     Nullable[[]string] `gorm:"type:jsonb" json:"tags"`
+		Extra    Nullable[[]string] `gorm:"type:jsonb" json:"extra"`
+		Score    Nullable[float64]  `json:"score" dbu:"inc"`
+		Labels   Nullable[[]string] `json:"labels" dbu:"push"`
+	}
+	age := NewNull[int]()
+	test := &user{
+		Name:   NewNullable("neil"),
+		Age:    &age,
+		Tags:   NewNullable([]string{"a"}),
+		Extra:  NewNull[[]string](),
+		Score:  NewNullable(0.5),
+		Labels: NewNull[[]string](),
+	}
+
+	t.Run("[SUCCESS] should set explicit nulls to nil and skip unset fields", func(t *testing.T) {
+		actual, err := BuildRDBUpdateMap(test, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":   "neil",
+			"age":    nil,
+			"tags":   json.RawMessage(`["a"]`),
+			"extra":  nil,
+			"score":  0.5,
+			"labels": nil,
+		}, actual)
+
+		mongo, err := BuildMongoUpdateMap(test, []string{"labels"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":  "neil",
+			"age":   nil,
+			"tags":  []string{"a"},
+			"extra": nil,
+			"score": 0.5,
+		}, mongo)
+	})
+
+	t.Run("[SUCCESS] should unset explicit nulls in Mongo updates", func(t *testing.T) {
+		update, err := MongoUpdate(test, nil)
+		require.NoError(t, err)
+		assert.Equal(t, bson.D{
+			{Key: "$set", Value: bson.D{{Key: "name", Value: "neil"}, {Key: "tags", Value: []string{"a"}}}},
+			{Key: "$unset", Value: bson.D{{Key: "age", Value: ""}, {Key: "extra", Value: ""}, {Key: "labels", Value: ""}}},
+			{Key: "$inc", Value: bson.D{{Key: "score", Value: 0.5}}},
+		}, update)
+	})
+}
@@ -0,0 +1,203 @@
+package dbu
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Option configures a call of RDBUpdateMap.
+type Option func(*options)
+
+// ZeroPolicy tells RDBUpdateMap which zero values to include. It applies to
+// fields that are neither pointers nor Nullable values, as those already
+// tell unset values apart.
+type ZeroPolicy int
+
+const (
+	// ZeroInclude includes zero values, as BuildRDBUpdateMap does.
+	ZeroInclude ZeroPolicy = iota
+	// ZeroOmit omits zero values.
+	ZeroOmit
+	// ZeroOmitEmpty omits the empty values of fields with the omitempty
+	// option in their `json` or `bson` tag, with the definition of empty
+	// of encoding/json: false, 0, "", and empty arrays, slices and maps.
+	ZeroOmitEmpty
+)
+
+type options struct {
+	skip        []string
+	only        []string
+	namer       DefaultColumnNameFunc
+	zero        ZeroPolicy
+	tagPriority []string
+	marshal     func(any) ([]byte, error)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{marshal: json.Marshal}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithSkip leaves out the columns, with the prefixes of embedded structs
+// applied, from the update map. It can be given more than once.
+func WithSkip(columns ...string) Option {
+	return func(o *options) {
+		o.skip = append(o.skip, columns...)
+	}
+}
+
+// WithOnly restricts the update map to the columns, with the prefixes of
+// embedded structs applied. It can be given more than once. Columns the
+// struct does not have are an error.
+func WithOnly(columns ...string) Option {
+	return func(o *options) {
+		o.only = append(o.only, columns...)
+	}
+}
+
+// WithNamer names the columns of fields without a column tag with namer
+// instead of the package-level default set by SetDefaultColumnNameFunc.
+func WithNamer(namer DefaultColumnNameFunc) Option {
+	return func(o *options) {
+		o.namer = namer
+	}
+}
+
+// WithZeroPolicy sets which zero values the update map includes, by
+// default all of them.
+func WithZeroPolicy(policy ZeroPolicy) Option {
+	return func(o *options) {
+		o.zero = policy
+	}
+}
+
+// WithTagPriority names columns by the first of the tags naming the field,
+// where "gorm" stands for the `column` setting of the GORM tag, before
+// falling back to the namer. For example WithTagPriority("gorm", "json")
+// ignores bson tags and names all fields by their json tag. By default,
+// the order is gorm, bson, then json for jsonb fields only.
+func WithTagPriority(tags ...string) Option {
+	return func(o *options) {
+		o.tagPriority = tags
+	}
+}
+
+// WithJSONBMarshaler marshals the values of jsonb fields with marshal
+// instead of json.Marshal.
+func WithJSONBMarshaler(marshal func(any) ([]byte, error)) Option {
+	return func(o *options) {
+		o.marshal = marshal
+	}
+}
+
+// RDBUpdateMap constructs a map[string]any for use in relational database updates from v, a
+// struct or pointer to struct, with the rules of BuildRDBUpdateMap adjusted by opts, e.g.:
+//
+//	m, err := dbu.RDBUpdateMap(req,
+//		dbu.WithSkip("id"),
+//		dbu.WithNamer(dbu.GormColumnNameFunc(db.NamingStrategy)),
+//		dbu.WithZeroPolicy(dbu.ZeroOmitEmpty),
+//	)
+//
+// The options apply to this call only. Plans of the field tags are cached per struct type for
+// the package-level default namer; with WithNamer or WithTagPriority, the tags are parsed on
+// each call.
+//
+// Returns an error if v is not a struct or pointer to struct, if two fields map to the same
+// column, if WithOnly names a column v does not have, or if JSON marshaling fails.
+func RDBUpdateMap[T any](v T, opts ...Option) (map[string]any, error) {
+	return buildRDBUpdateMap(v, newOptions(opts), "RDBUpdateMap")
+}
+
+// buildRDBUpdateMap implements RDBUpdateMap, with the function fn named in
+// errors.
+func buildRDBUpdateMap(x any, o *options, fn string) (map[string]any, error) {
+	result := make(map[string]any)
+	val, err := structValue(x, fn)
+	if err != nil || !val.IsValid() {
+		return result, err
+	}
+
+	plan := o.columnNamer().rdbPlan(val.Type())
+	if plan.err != nil {
+		return result, plan.err
+	}
+	for _, column := range o.only {
+		if !slices.ContainsFunc(plan.fields, func(f rdbField) bool { return f.column == column }) {
+			return result, fmt.Errorf("%s has no column %s", val.Type(), column)
+		}
+	}
+
+	for _, f := range plan.fields {
+		if slices.Contains(o.skip, f.column) || o.only != nil && !slices.Contains(o.only, f.column) {
+			continue
+		}
+		if f.omitted(val, o.zero) {
+			continue
+		}
+		value, ok, err := f.value(val, o.marshal)
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			result[f.column] = value
+		}
+	}
+	return result, nil
+}
+
+// columnNamer returns the namer of the options: the package-level default
+// with its cached plans, or one made for the call.
+func (o *options) columnNamer() *columnNamer {
+	if o.namer == nil && o.tagPriority == nil {
+		return defaultNamer.Load()
+	}
+	n := defaultNamer.Load()
+	if o.namer != nil {
+		n = newColumnNamer(o.namer)
+	} else {
+		n = newColumnNamer(n.fn)
+	}
+	n.priority = o.tagPriority
+	return n
+}
+
+// omitted reports whether the zero-value policy leaves out the field f of
+// the struct v.
+func (f *rdbField) omitted(v reflect.Value, policy ZeroPolicy) bool {
+	if policy == ZeroInclude || f.ptr || f.nullable {
+		return false
+	}
+	fieldVal, ok := fieldByIndex(v, f.index)
+	if !ok {
+		return false
+	}
+	switch policy {
+	case ZeroOmit:
+		return fieldVal.IsZero()
+	case ZeroOmitEmpty:
+		return f.omitempty && isEmptyValue(fieldVal)
+	}
+	return false
+}
+
+// isEmptyValue reports whether v is empty as defined by the omitempty
+// option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package dbu
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRDBUpdateMap(t *testing.T) {
+	type profile struct {
+		Bio string `json:"bio"`
+	}
+	type user struct {
+		ID        string           `json:"id"`
+		FirstName string           `json:"first_name,omitempty" bson:"given_name"`
+		Age       int              `json:"age,omitempty"`
+		Active    bool             `json:"active"`
+		Nickname  *string          `json:"nickname,omitempty"`
+		Email     Nullable[string] `json:"email"`
+		Profile   profile          `gorm:"type:jsonb" json:"profile"`
+	}
+	test := user{ID: "u1", Profile: profile{Bio: "hi"}}
+
+	t.Run("[SUCCESS] should match BuildRDBUpdateMap without options", func(t *testing.T) {
+		expected, err := BuildRDBUpdateMap(test, nil)
+		require.NoError(t, err)
+		actual, err := RDBUpdateMap(&test)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("[SUCCESS] should skip and restrict columns", func(t *testing.T) {
+		actual, err := RDBUpdateMap(test, WithSkip("id"), WithSkip("profile"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"given_name": "", "age": 0, "active": false}, actual)
+
+		actual, err = RDBUpdateMap(test, WithOnly("age", "profile"), WithSkip("profile"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"age": 0}, actual)
+	})
+
+	t.Run("[SUCCESS] should apply the zero-value policy to plain fields only", func(t *testing.T) {
+		actual, err := RDBUpdateMap(test, WithZeroPolicy(ZeroOmit))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"id": "u1", "profile": json.RawMessage(`{"bio":"hi"}`)}, actual)
+
+		empty := ""
+		withNickname := test
+		withNickname.Nickname = &empty
+		withNickname.Email = NewNull[string]()
+		actual, err = RDBUpdateMap(withNickname, WithZeroPolicy(ZeroOmitEmpty))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"id":       "u1",
+			"active":   false,
+			"nickname": "",
+			"email":    nil,
+			"profile":  json.RawMessage(`{"bio":"hi"}`),
+		}, actual)
+	})
+
+	t.Run("[SUCCESS] should name columns with the namer and tag priority of the call", func(t *testing.T) {
+		actual, err := RDBUpdateMap(test, WithNamer(strings.ToUpper), WithOnly("ID", "given_name"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"ID": "u1", "given_name": ""}, actual)
+
+		actual, err = RDBUpdateMap(test, WithTagPriority("json"), WithOnly("first_name", "profile"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"first_name": "", "profile": json.RawMessage(`{"bio":"hi"}`)}, actual)
+
+		actual, err = RDBUpdateMap(test, WithOnly("given_name"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"given_name": ""}, actual, "options must not leak into the cached plans")
+	})
+
+	t.Run("[SUCCESS] should marshal jsonb columns with the given marshaler", func(t *testing.T) {
+		marshal := func(v any) ([]byte, error) { return json.MarshalIndent(v, "", " ") }
+		actual, err := RDBUpdateMap(test, WithOnly("profile"), WithJSONBMarshaler(marshal))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"profile": json.RawMessage("{\n \"bio\": \"hi\"\n}")}, actual)
+	})
+
+	t.Run("[FAILURE] should reject unknown WithOnly columns", func(t *testing.T) {
+		_, err := RDBUpdateMap(test, WithOnly("age", "birthday"))
+		assert.ErrorContains(t, err, "has no column birthday")
+	})
+
+	t.Run("[FAILURE] should return marshaler errors", func(t *testing.T) {
+		failure := errors.New("marshal failed")
+		marshal := func(any) ([]byte, error) { return nil, failure }
+		_, err := RDBUpdateMap(test, WithJSONBMarshaler(marshal))
+		assert.ErrorIs(t, err, failure)
+	})
+
+	t.Run("[FAILURE] should reject non-struct values", func(t *testing.T) {
+		_, err := RDBUpdateMap(42)
+		assert.Error(t, err)
+	})
+}
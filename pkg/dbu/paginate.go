@@ -0,0 +1,409 @@
+package dbu
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultPageLimit is the page size of Paginate for requests without a
+// positive limit.
+const DefaultPageLimit = 20
+
+// ErrInvalidCursor is returned by Paginate, wrapped, for cursors that are
+// malformed, not signed with the current key, or made for another table or
+// sort order.
+var ErrInvalidCursor = errors.New("dbu: invalid cursor")
+
+// NullOrder tells Paginate whether a sort column can be NULL and where its
+// NULLs sort.
+type NullOrder int
+
+const (
+	// NotNull is for columns without NULLs, sorted by the database alone.
+	NotNull NullOrder = iota
+	// NullsLast sorts NULLs after the other values, whatever the direction.
+	NullsLast
+	// NullsFirst sorts NULLs before the other values, whatever the direction.
+	NullsFirst
+)
+
+// SortField is a column of the sort order of Paginate.
+type SortField struct {
+	Column string
+	Desc   bool
+	Nulls  NullOrder
+}
+
+// PageRequest is a request for a page of Paginate.
+type PageRequest struct {
+	// Cursor is the NextCursor or PrevCursor of a previous page, or "" for
+	// the first page.
+	Cursor string
+	// Limit is the page size, DefaultPageLimit if not positive.
+	Limit int
+	// Sort is the sort order, the primary key if empty. The primary key
+	// columns it lacks are appended in ascending order as tiebreakers, so
+	// rows with equal sort values are not skipped. For models without a
+	// primary key its last column must identify rows and be NotNull.
+	Sort []SortField
+}
+
+// Page is a page of Paginate.
+type Page[T any] struct {
+	Items []T
+	// NextCursor requests the page after Items, "" if there is none.
+	NextCursor string
+	// PrevCursor requests the page before Items, "" if there is none.
+	PrevCursor string
+	// HasMore reports whether more items follow in the direction of the
+	// request: after Items for the first page and NextCursor, before them
+	// for PrevCursor.
+	HasMore bool
+}
+
+// cursorKey stores the HMAC key of the cursors of Paginate.
+var cursorKey atomic.Pointer[[]byte]
+
+// SetCursorKey sets the HMAC-SHA256 key signing the cursors of Paginate, so clients cannot
+// forge or tamper with them. It must be set, typically once during application initialization,
+// before Paginate is used, and be kept secret; changing it invalidates the cursors in use.
+// Passing nil or an empty key unsets it.
+func SetCursorKey(key []byte) {
+	if len(key) == 0 {
+		cursorKey.Store(nil)
+		return
+	}
+	key = slices.Clone(key)
+	cursorKey.Store(&key)
+}
+
+// cursor is the payload of the cursors of Paginate.
+type cursor struct {
+	Table    string            `json:"t"`           // the table the cursor is made for
+	Sort     string            `json:"s"`           // the sort order the cursor is made for
+	Values   []json.RawMessage `json:"v"`           // of the sort columns of the row to page from
+	Backward bool              `json:"b,omitempty"` // the page before the row
+}
+
+// Paginate returns a page of the rows of the model T, a struct, matching db, e.g. filtered by
+// ApplyFilter, with keyset pagination: instead of an OFFSET, the rows of the page come after or
+// before the row of the cursor in the sort order, so pages are fast on big tables and stable
+// while rows are inserted or deleted.
+//
+//	dbu.SetCursorKey(secret)
+//
+//	page, err := dbu.Paginate[User](db.Where("active"), dbu.PageRequest{
+//		Cursor: r.URL.Query().Get("cursor"),
+//		Limit:  50,
+//		Sort: []dbu.SortField{
+//			{Column: "last_login_at", Desc: true, Nulls: dbu.NullsLast},
+//			{Column: "id"},
+//		},
+//	})
+//	// page.Items, then page.NextCursor and page.PrevCursor for the links
+//
+// Sort columns may mix directions. The columns are checked against the GORM schema of T, so
+// they may come from clients, and the primary key columns they lack are appended as
+// tiebreakers, so the sort order identifies rows.
+// NULLs of the columns that are not NotNull are ordered with CASE expressions, so they sort as
+// requested on every database.
+//
+// Cursors are opaque: base64url-encoded JSON of the table, the sort order and the sort column
+// values of a row, signed with HMAC-SHA256 by the key of SetCursorKey, so they are only valid
+// for the table and sort order they were made for. Values are decoded to the types of their
+// fields, so large integers and times keep their precision.
+//
+// Returns an error if no cursor key is set, if T is not a struct GORM can parse, if a sort
+// column is unknown, if T has no primary key and the last sort column is not NotNull,
+// ErrInvalidCursor wrapped for invalid cursors, and the errors of the query.
+func Paginate[T any](db *gorm.DB, req PageRequest) (Page[T], error) {
+	var page Page[T]
+	key := cursorKey.Load()
+	if key == nil {
+		return page, errors.New("dbu: Paginate needs a cursor key, see SetCursorKey")
+	}
+
+	var model T
+	s, err := gormSchema(db, &model)
+	if err != nil {
+		return page, err
+	}
+	sort, fields, err := pageSort(s, req.Sort)
+	if err != nil {
+		return page, err
+	}
+	signature := sortSignature(sort)
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+
+	var c cursor
+	var values []any
+	if req.Cursor != "" {
+		if c, err = decodeCursor(*key, req.Cursor); err != nil {
+			return page, err
+		}
+		if c.Table != s.Table {
+			return page, fmt.Errorf("%w: made for the table %q", ErrInvalidCursor, c.Table)
+		}
+		if c.Sort != signature || len(c.Values) != len(fields) {
+			return page, fmt.Errorf("%w: made for the sort order %q", ErrInvalidCursor, c.Sort)
+		}
+		if values, err = cursorValues(c, fields); err != nil {
+			return page, err
+		}
+	}
+
+	order := sort
+	if c.Backward {
+		order = make([]SortField, len(sort))
+		for i, f := range sort {
+			order[i] = f.reverse()
+		}
+	}
+	tx := db.Clauses(orderByClause(order))
+	if values != nil {
+		tx = tx.Clauses(clause.Where{Exprs: []clause.Expression{keysetCondition(order, values)}})
+	}
+	if err := tx.Limit(limit + 1).Find(&page.Items).Error; err != nil {
+		return page, err
+	}
+
+	page.HasMore = len(page.Items) > limit
+	if page.HasMore {
+		page.Items = page.Items[:limit]
+	}
+	if c.Backward {
+		slices.Reverse(page.Items)
+	}
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	hasNext, hasPrev := page.HasMore, req.Cursor != ""
+	if c.Backward {
+		hasNext, hasPrev = true, page.HasMore
+	}
+	if hasNext {
+		if page.NextCursor, err = encodeCursor(db, *key, s.Table, signature, fields, page.Items[len(page.Items)-1], false); err != nil {
+			return page, err
+		}
+	}
+	if hasPrev {
+		if page.PrevCursor, err = encodeCursor(db, *key, s.Table, signature, fields, page.Items[0], true); err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
+
+// pageSort returns the sort order of a request for the schema s, ending
+// with the primary key columns it lacks, and the fields of its columns.
+func pageSort(s *schema.Schema, sort []SortField) ([]SortField, []*schema.Field, error) {
+	sort = slices.Clip(sort)
+	for _, pk := range s.PrimaryFields {
+		if !slices.ContainsFunc(sort, func(f SortField) bool { return f.Column == pk.DBName }) {
+			sort = append(sort, SortField{Column: pk.DBName})
+		}
+	}
+	switch {
+	case len(sort) == 0:
+		return nil, nil, fmt.Errorf("%s has no primary key to sort by", s.Name)
+	case len(s.PrimaryFields) == 0 && sort[len(sort)-1].Nulls != NotNull:
+		return nil, nil, fmt.Errorf("%s has no primary key, the last sort column %q must be NotNull", s.Name, sort[len(sort)-1].Column)
+	}
+
+	fields := make([]*schema.Field, len(sort))
+	var errs []error
+	for i, f := range sort {
+		if fields[i] = s.LookUpField(f.Column); fields[i] == nil || fields[i].DBName != f.Column {
+			errs = append(errs, fmt.Errorf("%s has no column %q", s.Name, f.Column))
+		}
+	}
+	return sort, fields, errors.Join(errs...)
+}
+
+// sortSignature returns the sort order as cursors record it, e.g.
+// "last_login_at desc nulls last,id".
+func sortSignature(sort []SortField) string {
+	parts := make([]string, len(sort))
+	for i, f := range sort {
+		parts[i] = f.Column
+		if f.Desc {
+			parts[i] += " desc"
+		}
+		switch f.Nulls {
+		case NullsLast:
+			parts[i] += " nulls last"
+		case NullsFirst:
+			parts[i] += " nulls first"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// reverse returns f in the opposite order, to page backward.
+func (f SortField) reverse() SortField {
+	f.Desc = !f.Desc
+	switch f.Nulls {
+	case NullsLast:
+		f.Nulls = NullsFirst
+	case NullsFirst:
+		f.Nulls = NullsLast
+	}
+	return f
+}
+
+// orderByClause returns the ORDER BY clause of order, with NULLs sorted by
+// a CASE expression before the columns that can be NULL.
+func orderByClause(order []SortField) clause.OrderBy {
+	var sql []string
+	var vars []any
+	for _, f := range order {
+		column := clause.Column{Name: f.Column}
+		switch f.Nulls {
+		case NullsLast:
+			sql = append(sql, "CASE WHEN ? IS NULL THEN 1 ELSE 0 END")
+			vars = append(vars, column)
+		case NullsFirst:
+			sql = append(sql, "CASE WHEN ? IS NULL THEN 0 ELSE 1 END")
+			vars = append(vars, column)
+		}
+		if f.Desc {
+			sql = append(sql, "? DESC")
+		} else {
+			sql = append(sql, "?")
+		}
+		vars = append(vars, column)
+	}
+	return clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(sql, ", "), Vars: vars, WithoutParentheses: true}}
+}
+
+// keysetCondition returns the condition selecting the rows after the row
+// with the values of the columns of order: equal to it on the first
+// columns and after it on the next one.
+func keysetCondition(order []SortField, values []any) clause.Expression {
+	var or []clause.Expression
+	for i, f := range order {
+		after := afterCondition(f, values[i])
+		if after == nil {
+			continue // nothing sorts after a NULL in the NULLs last
+		}
+		and := make([]clause.Expression, 0, i+1)
+		for j, g := range order[:i] {
+			and = append(and, clause.Eq{Column: clause.Column{Name: g.Column}, Value: values[j]})
+		}
+		or = append(or, clause.And(append(and, after)...))
+	}
+	if len(or) == 0 {
+		return clause.Expr{SQL: "1 = 0"}
+	}
+	return clause.Or(or...)
+}
+
+// afterCondition returns the condition on the column of f selecting the
+// values after value, or nil for none.
+func afterCondition(f SortField, value any) clause.Expression {
+	column := clause.Column{Name: f.Column}
+	if value == nil {
+		if f.Nulls == NullsFirst {
+			return clause.Neq{Column: column, Value: nil}
+		}
+		return nil
+	}
+
+	var after clause.Expression = clause.Gt{Column: column, Value: value}
+	if f.Desc {
+		after = clause.Lt{Column: column, Value: value}
+	}
+	if f.Nulls == NullsLast {
+		return clause.Or(after, clause.Eq{Column: column, Value: nil})
+	}
+	return after
+}
+
+// encodeCursor returns the signed cursor of the values of the sort fields
+// of the row item of table.
+func encodeCursor(db *gorm.DB, key []byte, table, signature string, fields []*schema.Field, item any, backward bool) (string, error) {
+	row := reflect.Indirect(reflect.ValueOf(item))
+	c := cursor{Table: table, Sort: signature, Values: make([]json.RawMessage, len(fields)), Backward: backward}
+	for i, f := range fields {
+		value, _ := f.ValueOf(db.Statement.Context, row)
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode the cursor value of %s: %w", f.DBName, err)
+		}
+		c.Values[i] = data
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the cursor: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(payload)), nil
+}
+
+// decodeCursor returns the payload of the cursor s after checking its
+// signature.
+func decodeCursor(key []byte, s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) < sha256.Size {
+		return c, fmt.Errorf("%w: malformed", ErrInvalidCursor)
+	}
+	payload, sum := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return c, fmt.Errorf("%w: bad signature", ErrInvalidCursor)
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return c, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return c, nil
+}
+
+// cursorValues decodes the values of c to the types of fields, with nil
+// for NULLs.
+func cursorValues(c cursor, fields []*schema.Field) ([]any, error) {
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		v := reflect.New(f.FieldType)
+		if err := json.Unmarshal(c.Values[i], v.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: value of %s: %v", ErrInvalidCursor, f.DBName, err)
+		}
+		v = v.Elem()
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+		if valuer, ok := v.Interface().(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				return nil, fmt.Errorf("%w: value of %s: %v", ErrInvalidCursor, f.DBName, err)
+			}
+			values[i] = value // nil for NULLs, e.g. of Nullable and sql.NullString
+			continue
+		}
+		values[i] = v.Interface()
+	}
+	return values, nil
+}
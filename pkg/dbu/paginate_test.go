@@ -0,0 +1,167 @@
+package dbu
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPaginate(t *testing.T) {
+	type User struct {
+		ID      int64
+		Score   int
+		LoginAt *time.Time
+	}
+	columns := []string{"id", "score", "login_at"}
+	login := time.Date(2025, 10, 25, 8, 0, 0, 0, time.UTC)
+	const bigID = int64(1)<<60 + 1 // not exact as a float64
+
+	rows := &fakeRows{}
+	db, err := gorm.Open(sqliteDialector{rows: rows}, &gorm.Config{})
+	require.NoError(t, err)
+	SetCursorKey([]byte("secret"))
+	defer SetCursorKey(nil)
+
+	sort := []SortField{
+		{Column: "score", Desc: true},
+		{Column: "login_at", Nulls: NullsLast},
+		{Column: "id"},
+	}
+	const orderBy = " ORDER BY `score` DESC, CASE WHEN `login_at` IS NULL THEN 1 ELSE 0 END, `login_at`, `id`"
+	var first, last Page[User]
+
+	t.Run("[SUCCESS] should return the first page with the next cursor", func(t *testing.T) {
+		rows.set(columns,
+			[]driver.Value{int64(1), int64(9), login},
+			[]driver.Value{bigID, int64(5), nil},
+			[]driver.Value{int64(3), int64(5), nil},
+		)
+		first, err = Paginate[User](db.Where("score > ?", 0), PageRequest{Limit: 2, Sort: sort})
+		require.NoError(t, err)
+
+		query, args := rows.last()
+		assert.Equal(t, "SELECT * FROM `users` WHERE score > ?"+orderBy+" LIMIT ?", query)
+		assert.Equal(t, []any{int64(0), int64(3)}, args)
+		assert.Equal(t, []User{{ID: 1, Score: 9, LoginAt: &login}, {ID: bigID, Score: 5}}, first.Items)
+		assert.True(t, first.HasMore)
+		assert.NotEmpty(t, first.NextCursor)
+		assert.Empty(t, first.PrevCursor)
+	})
+
+	t.Run("[SUCCESS] should page forward after the cursor row", func(t *testing.T) {
+		rows.set(columns, []driver.Value{int64(3), int64(5), nil})
+		last, err = Paginate[User](db, PageRequest{Cursor: first.NextCursor, Limit: 2, Sort: sort})
+		require.NoError(t, err)
+
+		query, args := rows.last()
+		assert.Equal(t, "SELECT * FROM `users` WHERE (`score` < ? OR (`score` = ? AND `login_at` IS NULL AND `id` > ?))"+
+			orderBy+" LIMIT ?", query)
+		assert.Equal(t, []any{int64(5), int64(5), bigID, int64(3)}, args)
+		assert.Equal(t, []User{{ID: 3, Score: 5}}, last.Items)
+		assert.False(t, last.HasMore)
+		assert.Empty(t, last.NextCursor)
+		assert.NotEmpty(t, last.PrevCursor)
+	})
+
+	t.Run("[SUCCESS] should page backward in the reversed order", func(t *testing.T) {
+		rows.set(columns,
+			[]driver.Value{bigID, int64(5), nil},
+			[]driver.Value{int64(1), int64(9), login},
+		)
+		page, err := Paginate[User](db, PageRequest{Cursor: last.PrevCursor, Limit: 2, Sort: sort})
+		require.NoError(t, err)
+
+		query, args := rows.last()
+		assert.Equal(t, "SELECT * FROM `users` WHERE (`score` > ? OR (`score` = ? AND `login_at` IS NOT NULL)"+
+			" OR (`score` = ? AND `login_at` IS NULL AND `id` < ?))"+
+			" ORDER BY `score`, CASE WHEN `login_at` IS NULL THEN 0 ELSE 1 END, `login_at` DESC, `id` DESC LIMIT ?", query)
+		assert.Equal(t, []any{int64(5), int64(5), int64(5), int64(3), int64(3)}, args)
+		assert.Equal(t, first.Items, page.Items)
+		assert.False(t, page.HasMore)
+		assert.NotEmpty(t, page.NextCursor)
+		assert.Empty(t, page.PrevCursor)
+	})
+
+	t.Run("[SUCCESS] should sort by the primary key by default", func(t *testing.T) {
+		rows.set(columns)
+		page, err := Paginate[User](db, PageRequest{})
+		require.NoError(t, err)
+
+		query, args := rows.last()
+		assert.Equal(t, "SELECT * FROM `users` ORDER BY `id` LIMIT ?", query)
+		assert.Equal(t, []any{int64(DefaultPageLimit + 1)}, args)
+		assert.Empty(t, page.Items)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("[SUCCESS] should break ties of the sort columns with the primary key", func(t *testing.T) {
+		byScore := []SortField{{Column: "score", Desc: true}}
+		rows.set(columns,
+			[]driver.Value{int64(1), int64(5), nil},
+			[]driver.Value{int64(2), int64(5), nil},
+			[]driver.Value{int64(3), int64(5), nil},
+		)
+		page, err := Paginate[User](db, PageRequest{Limit: 2, Sort: byScore})
+		require.NoError(t, err)
+
+		query, _ := rows.last()
+		assert.Equal(t, "SELECT * FROM `users` ORDER BY `score` DESC, `id` LIMIT ?", query)
+		assert.Equal(t, []User{{ID: 1, Score: 5}, {ID: 2, Score: 5}}, page.Items)
+
+		rows.set(columns, []driver.Value{int64(3), int64(5), nil})
+		page, err = Paginate[User](db, PageRequest{Cursor: page.NextCursor, Limit: 2, Sort: byScore})
+		require.NoError(t, err)
+
+		query, args := rows.last()
+		assert.Equal(t, "SELECT * FROM `users` WHERE (`score` < ? OR (`score` = ? AND `id` > ?))"+
+			" ORDER BY `score` DESC, `id` LIMIT ?", query)
+		assert.Equal(t, []any{int64(5), int64(5), int64(2), int64(3)}, args)
+		assert.Equal(t, []User{{ID: 3, Score: 5}}, page.Items)
+	})
+
+	t.Run("[FAILURE] should reject tampered and foreign cursors", func(t *testing.T) {
+		cursor := []byte(first.NextCursor)
+		cursor[len(cursor)/2] ^= 1
+		_, err := Paginate[User](db, PageRequest{Cursor: string(cursor), Sort: sort})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = Paginate[User](db, PageRequest{Cursor: "not a cursor", Sort: sort})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = Paginate[User](db, PageRequest{Cursor: first.NextCursor, Sort: sort[1:]})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		type Account User
+		_, err = Paginate[Account](db, PageRequest{Cursor: first.NextCursor, Sort: sort})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		assert.ErrorContains(t, err, `made for the table "users"`)
+
+		SetCursorKey([]byte("rotated"))
+		defer SetCursorKey([]byte("secret"))
+		_, err = Paginate[User](db, PageRequest{Cursor: first.NextCursor, Sort: sort})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("[FAILURE] should reject unknown columns and a missing key", func(t *testing.T) {
+		_, err := Paginate[User](db, PageRequest{Sort: []SortField{{Column: "score; DROP TABLE users"}}})
+		assert.EqualError(t, err, `User has no column "score; DROP TABLE users"`)
+
+		type Event struct {
+			Name string
+			At   *time.Time
+		}
+		_, err = Paginate[Event](db, PageRequest{Sort: []SortField{{Column: "at", Nulls: NullsLast}}})
+		assert.EqualError(t, err, `Event has no primary key, the last sort column "at" must be NotNull`)
+		_, err = Paginate[Event](db, PageRequest{})
+		assert.EqualError(t, err, "Event has no primary key to sort by")
+
+		SetCursorKey(nil)
+		defer SetCursorKey([]byte("secret"))
+		_, err = Paginate[User](db, PageRequest{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,414 @@
+package dbu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/byte4cat/nbx/v2/internal/dbutag"
+	"github.com/byte4cat/nbx/v2/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// columnNamer is a DefaultColumnNameFunc, with an optional tag priority,
+// and the field plans derived with them, so tags are parsed once per struct
+// type and namer.
+type columnNamer struct {
+	fn       DefaultColumnNameFunc
+	priority []string // tags naming columns, see WithTagPriority; nil for the default rules
+	plans    sync.Map // reflect.Type -> *rdbPlan
+}
+
+func newColumnNamer(fn DefaultColumnNameFunc) *columnNamer {
+	return &columnNamer{fn: fn}
+}
+
+// column returns the column of a field, its source as for
+// dbutag.RDBColumnSource, and whether it is stored as jsonb.
+func (n *columnNamer) column(fieldName string, tag reflect.StructTag) (string, string, bool) {
+	if n.priority == nil {
+		return dbutag.RDBColumnSource(fieldName, tag, n.fn)
+	}
+	column, source := dbutag.RDBColumnByPriority(fieldName, tag, n.fn, n.priority)
+	return column, source, dbutag.RDBJSONB(tag)
+}
+
+// rdbPlan lists the columns of a struct type for BuildRDBUpdateMap, with
+// the fields of embedded structs flattened.
+type rdbPlan struct {
+	fields  []rdbField
+	dropped []droppedField // fields without a column, for explanations
+	err     error          // columns shared by several fields
+	tagErr  error          // ambiguous tags, see dbutag.RDBTagProblems
+}
+
+// rdbField is a column of an rdbPlan.
+type rdbField struct {
+	name      string // Go field path, e.g. "Audit.CreatedBy", for errors
+	column    string // with the prefixes of the embedded structs
+	index     []int  // as for reflect.Value.FieldByIndex, through embedded structs
+	ptr       bool   // the field is a pointer, skipped when nil
+	jsonb     bool   // the value is marshaled to json.RawMessage
+	nullable  bool   // the field is a Nullable, skipped when unset
+	omitempty bool   // the json or bson tag has the omitempty option
+	reason    string // how the column is named, for explanations
+	problems  []string
+}
+
+// droppedField is a field a plan leaves out, and why.
+type droppedField struct {
+	name   string
+	index  []int
+	reason string
+}
+
+// rdbPlan returns the cached plan of the struct type t.
+func (n *columnNamer) rdbPlan(t reflect.Type) *rdbPlan {
+	if p, ok := n.plans.Load(t); ok {
+		return p.(*rdbPlan)
+	}
+
+	p := &rdbPlan{}
+	n.appendRDBFields(p, t, nil, "", "")
+	fields, columns := make([]string, len(p.fields)), make([]string, len(p.fields))
+	var tagErrs []error
+	for i, f := range p.fields {
+		fields[i], columns[i] = f.name, f.column
+		for _, problem := range f.problems {
+			tagErrs = append(tagErrs, fmt.Errorf("%s: field %s: %s", t, f.name, problem))
+		}
+	}
+	p.tagErr = errors.Join(tagErrs...)
+	p.err = conflictError(t, dbutag.KeyConflicts(fields, columns, "column", false), func(i int, problem string) {
+		p.fields[i].problems = append(p.fields[i].problems, problem)
+	})
+	if logger.IsLevelEnabled(zapcore.DebugLevel) {
+		for _, f := range p.fields {
+			logger.Debug("planned field", zap.String("type", t.String()), zap.String("fieldName", f.name),
+				zap.String("column", f.column), zap.Bool("jsonb", f.jsonb), zap.Bool("pointer", f.ptr),
+				zap.Bool("nullable", f.nullable),
+			)
+		}
+	}
+
+	actual, _ := n.plans.LoadOrStore(t, p)
+	return actual.(*rdbPlan)
+}
+
+// value returns the value of the column f of the struct v, marshaled to
+// json.RawMessage with marshal for jsonb columns, or nil if it is
+// explicitly null. It reports false if the field is unset: a nil pointer,
+// inside a nil embedded pointer, or an unset Nullable.
+func (f *rdbField) value(v reflect.Value, marshal func(any) ([]byte, error)) (any, bool, error) {
+	value, ok := f.goValue(v)
+	if !ok || !f.jsonb || f.nullable && value == nil {
+		return value, ok, nil
+	}
+	jsonValue, err := marshal(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal field %s (%s) to JSON: %w", f.name, f.column, err)
+	}
+	return json.RawMessage(jsonValue), true, nil
+}
+
+// goValue is value before jsonb columns are marshaled.
+func (f *rdbField) goValue(v reflect.Value) (any, bool) {
+	fieldVal, ok := fieldByIndex(v, f.index)
+	if !ok {
+		return nil, false
+	}
+	if f.ptr {
+		if fieldVal.IsNil() {
+			return nil, false
+		}
+		fieldVal = fieldVal.Elem()
+	}
+	if f.nullable {
+		return nullableValue(fieldVal)
+	}
+	return fieldVal.Interface(), true
+}
+
+// appendRDBFields appends the columns of the struct type t to p. Fields
+// marked with the GORM tag `embedded` are flattened recursively, with
+// their `embeddedPrefix` prepended to the columns. path is the Go path of
+// t, e.g. "Audit.", for errors.
+func (n *columnNamer) appendRDBFields(p *rdbPlan, t reflect.Type, index []int, prefix, path string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		idx := append(index[:len(index):len(index)], i)
+		name := path + field.Name
+
+		if embeddedPrefix, ok := dbutag.RDBEmbedded(field.Tag); ok {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				n.appendRDBFields(p, ft, idx, prefix+embeddedPrefix, name+".")
+				continue
+			}
+		}
+
+		column, source, jsonb := n.column(field.Name, field.Tag)
+		if column == "" {
+			p.dropped = append(p.dropped, droppedField{name: name, index: idx, reason: "no column, the namer gives an empty name"})
+			continue
+		}
+
+		reason := "column from the " + keySources[source]
+		if prefix != "" {
+			reason += fmt.Sprintf(" with the embedded prefix %q", prefix)
+		}
+		if jsonb {
+			reason += ", marshaled to JSON"
+		}
+		ptr := field.Type.Kind() == reflect.Pointer
+		p.fields = append(p.fields, rdbField{
+			name:      name,
+			column:    prefix + column,
+			index:     idx,
+			ptr:       ptr,
+			jsonb:     jsonb,
+			nullable:  isNullable(field.Type, ptr),
+			omitempty: dbutag.OmitEmpty(field.Tag),
+			reason:    reason,
+			problems:  dbutag.RDBTagProblems(field.Tag),
+		})
+	}
+}
+
+// mongoPlans caches the *mongoPlan of each struct type. Mongo keys come
+// from tags only, so unlike rdbPlan they do not depend on a namer.
+var mongoPlans sync.Map // reflect.Type -> *mongoPlan
+
+// mongoPlan lists the dotted paths of a struct type for
+// BuildMongoUpdateMap, with the fields of nested structs flattened.
+type mongoPlan struct {
+	fields  []mongoField
+	dropped []droppedField // fields without a key, for explanations
+	err     error          // paths shared by several fields or nested in another
+}
+
+// mongoField is a path of a mongoPlan.
+type mongoField struct {
+	name     string // Go field path, e.g. "Address.City", for errors
+	path     string // dotted, e.g. "address.city"
+	index    []int  // as for reflect.Value.FieldByIndex, through nested structs
+	ptr      bool   // the field is a pointer, skipped when nil
+	nullable bool   // the field is a Nullable, skipped when unset
+	op       string // update operator for MongoUpdate, "" for $set
+	reason   string // how the path is named, for explanations
+	problems []string
+}
+
+// mongoPlanOf returns the cached plan of the struct type t.
+func mongoPlanOf(t reflect.Type) *mongoPlan {
+	if p, ok := mongoPlans.Load(t); ok {
+		return p.(*mongoPlan)
+	}
+
+	p := &mongoPlan{}
+	appendMongoFields(p, t, nil, "", "", []reflect.Type{t})
+	fields, paths := make([]string, len(p.fields)), make([]string, len(p.fields))
+	for i, f := range p.fields {
+		fields[i], paths[i] = f.name, f.path
+	}
+	p.err = conflictError(t, dbutag.KeyConflicts(fields, paths, "path", true), func(i int, problem string) {
+		p.fields[i].problems = append(p.fields[i].problems, problem)
+	})
+	if logger.IsLevelEnabled(zapcore.DebugLevel) {
+		for _, f := range p.fields {
+			logger.Debug("planned field", zap.String("type", t.String()), zap.String("path", f.path),
+				zap.Bool("pointer", f.ptr), zap.Bool("nullable", f.nullable), zap.String("operator", f.op),
+			)
+		}
+	}
+
+	actual, _ := mongoPlans.LoadOrStore(t, p)
+	return actual.(*mongoPlan)
+}
+
+// value returns the value of the path f of the struct v, or nil if it is
+// explicitly null. It reports false if the field is unset: a nil pointer,
+// inside a nil nested pointer, or an unset Nullable.
+func (f *mongoField) value(v reflect.Value) (any, bool) {
+	fieldVal, ok := fieldByIndex(v, f.index)
+	if !ok {
+		return nil, false
+	}
+	if f.ptr {
+		if fieldVal.IsNil() {
+			return nil, false
+		}
+		fieldVal = fieldVal.Elem()
+	}
+	if f.nullable {
+		return nullableValue(fieldVal)
+	}
+	return fieldVal.Interface(), true
+}
+
+// appendMongoFields appends the paths of the struct type t to p. Nested
+// structs are flattened recursively, under their key or, with the
+// `bson:",inline"` option, at the level of t, unless they have an update
+// operator. seen holds the struct types being flattened, so a recursive
+// type is set as a whole. path is the Go path of t, e.g. "Address.", for
+// errors.
+func appendMongoFields(p *mongoPlan, t reflect.Type, index []int, prefix, path string, seen []reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		idx := append(index[:len(index):len(index)], i)
+		name := path + field.Name
+
+		ft := field.Type
+		ptr := ft.Kind() == reflect.Pointer
+		if ptr {
+			ft = ft.Elem()
+		}
+		op := dbutag.MongoOperator(field.Tag)
+		document := op == "" && mongoDocument(ft) && !slices.Contains(seen, ft)
+
+		if document && dbutag.MongoInline(field.Tag) {
+			appendMongoFields(p, ft, idx, prefix, name+".", append(seen, ft))
+			continue
+		}
+
+		key, source := dbutag.MongoKeySource(field.Name, field.Tag)
+		if key == "" {
+			p.dropped = append(p.dropped, droppedField{name: name, index: idx, reason: "no key, the " + keySources[source] + " has no name"})
+			continue
+		}
+		if document {
+			appendMongoFields(p, ft, idx, prefix+key+".", name+".", append(seen, ft))
+			continue
+		}
+
+		reason := "key from the " + keySources[source]
+		if prefix != "" {
+			reason += fmt.Sprintf(" under %q", strings.TrimSuffix(prefix, "."))
+		}
+		if op != "" {
+			reason += ", updated with " + op
+		}
+		p.fields = append(p.fields, mongoField{
+			name:     name,
+			path:     prefix + key,
+			index:    idx,
+			ptr:      ptr,
+			nullable: isNullable(ft, false),
+			op:       op,
+			reason:   reason,
+		})
+	}
+}
+
+// keySources describes the sources of dbutag.RDBColumnSource and
+// dbutag.MongoKeySource.
+var keySources = map[string]string{
+	"gorm":  "gorm column setting",
+	"bson":  "bson tag",
+	"json":  "json tag",
+	"form":  "form tag",
+	"namer": "namer",
+	"name":  "field name",
+}
+
+// conflictError adds the problem of each conflict of the plan of t to both
+// fields with add, and returns the error listing them, or nil.
+func conflictError(t reflect.Type, conflicts []dbutag.Conflict, add func(i int, problem string)) error {
+	var errs []error
+	for _, c := range conflicts {
+		add(c.I, c.Problem)
+		add(c.J, c.Problem)
+		errs = append(errs, fmt.Errorf("%s: %s", t, c.Problem))
+	}
+	return errors.Join(errs...)
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// mongoDocument reports whether BuildMongoUpdateMap flattens the fields of
+// values of type t rather than setting them as a whole: only structs with
+// exported fields that the default bson registry encodes with its generic
+// struct codec, so not times, the types of bson/primitive such as
+// Decimal128, or structs marshaling themselves.
+func mongoDocument(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	for _, name := range dbutag.MongoValueMethods {
+		if _, ok := pt.MethodByName(name); ok {
+			return false
+		}
+	}
+	if !slices.ContainsFunc(reflect.VisibleFields(t), func(f reflect.StructField) bool { return f.IsExported() }) {
+		return false
+	}
+	enc, err := bson.DefaultRegistry.LookupEncoder(t)
+	_, generic := enc.(*bsoncodec.StructCodec)
+	return err == nil && generic
+}
+
+var nullableType = reflect.TypeFor[nullable]()
+
+// isNullable reports whether t, or the type it points to if ptr is set, is
+// a Nullable.
+func isNullable(t reflect.Type, ptr bool) bool {
+	if ptr {
+		t = t.Elem()
+	}
+	return t.Implements(nullableType)
+}
+
+// nullableValue returns the value of the Nullable v, or nil if it is
+// null, and false if it is unset.
+func nullableValue(v reflect.Value) (any, bool) {
+	n := v.Interface().(nullable)
+	return n.anyValue(), n.IsSet()
+}
+
+// skippedPath reports whether path or one of the documents holding it is
+// in skip.
+func skippedPath(skip map[string]struct{}, path string) bool {
+	for {
+		if _, ok := skip[path]; ok {
+			return true
+		}
+		i := strings.LastIndexByte(path, '.')
+		if i < 0 {
+			return false
+		}
+		path = path[:i]
+	}
+}
+
+// fieldByIndex returns the field of v at index, stepping through embedded
+// and nested pointers. It reports false if one of them is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
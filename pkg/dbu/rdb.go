@@ -1,14 +1,6 @@
 package dbu
 
-import (
-	"encoding/json"
-	"fmt"
-	"reflect"
-	"strings"
-
-	"github.com/byte4cat/nbx/v2/pkg/logger"
-	"go.uber.org/zap"
-)
+import "sync/atomic"
 
 // DefaultColumnNameFunc is the type for a function that provides a default column name
 // for a struct field based on its Go name, used when no tags specify the name.
@@ -22,21 +14,24 @@ func DefaultSnakeCaseNamer(fieldName string) string {
 	return toSnakeCase(fieldName)
 }
 
-// packageDefaultNamer stores the package-level default column name function.
-// It is initialized to DefaultSnakeCaseNamer.
-var packageDefaultNamer DefaultColumnNameFunc = DefaultSnakeCaseNamer
+// defaultNamer stores the package-level default column name function and
+// the field plans derived with it. It is initialized to DefaultSnakeCaseNamer.
+var defaultNamer atomic.Pointer[columnNamer]
+
+func init() {
+	defaultNamer.Store(newColumnNamer(DefaultSnakeCaseNamer))
+}
 
 // SetDefaultColumnNameFunc sets the package-level default column name function.
 // This function should typically be called once during application initialization.
 // If nil is passed, the default namer will be reset to DefaultSnakeCaseNamer.
-// Note: If called concurrently with BuildRDBUpdateMapV6 after initialization,
-// this could theoretically lead to race conditions, but this is an uncommon use case.
+// Setting a namer drops the field plans cached for the previous one; calls
+// already running finish with the previous namer.
 func SetDefaultColumnNameFunc(namer DefaultColumnNameFunc) {
 	if namer == nil {
-		packageDefaultNamer = DefaultSnakeCaseNamer
-	} else {
-		packageDefaultNamer = namer
+		namer = DefaultSnakeCaseNamer
 	}
+	defaultNamer.Store(newColumnNamer(namer))
 }
 
 // BuildRDBUpdateMap constructs a map[string]any for use in relational database updates.
@@ -54,160 +49,17 @@ func SetDefaultColumnNameFunc(namer DefaultColumnNameFunc) {
 //
 // Fields listed in skipFields (with prefix applied) are omitted from the result.
 // JSONB fields are marshaled to json.RawMessage.
+// Nullable fields are omitted when unset and set to nil, i.e. NULL, when explicitly null.
 //
-// Returns an error if input is not a struct or pointer to struct, or if JSON marshaling fails.
+// The tags of a struct type are parsed on its first use and cached for the
+// current default column name function, so later calls only read the values.
+//
+// Returns an error if input is not a struct or pointer to struct, if two fields map to the same
+// column, e.g. a field of an embedded struct without a prefix shadowing an outer field (see
+// ValidateRDBTags and ExplainRDBUpdateMap), or if JSON marshaling fails.
+//
+// BuildRDBUpdateMap is RDBUpdateMap(x, WithSkip(skipFields...)); use RDBUpdateMap for the
+// other options.
 func BuildRDBUpdateMap(x any, skipFields []string) (map[string]any, error) {
-	result := make(map[string]any)
-	skipMap := make(map[string]struct{}, len(skipFields))
-	for _, field := range skipFields {
-		skipMap[field] = struct{}{}
-	}
-
-	val := reflect.ValueOf(x)
-	typ := reflect.TypeOf(x)
-
-	// Handle nil input pointer
-	if typ.Kind() == reflect.Pointer && val.IsNil() {
-		return result, nil
-	}
-
-	// Dereference pointer if necessary
-	if typ.Kind() == reflect.Pointer {
-		val = val.Elem()
-		typ = typ.Elem()
-	}
-
-	// Ensure it's a struct
-	if typ.Kind() != reflect.Struct {
-		return result, fmt.Errorf("BuildRDBUpdateMapV6 input must be a struct or pointer to struct, got %s", typ.Kind())
-	}
-
-	var processingErr error
-
-	// processFields recursively flattens struct fields into the result map.
-	// If a field is marked with `gorm:"embedded"`, its fields are recursively processed.
-	// If an embeddedPrefix is specified, it is prepended to all keys from that embedded struct.
-	// Supports both value and pointer embedded structs.
-	var processFields func(v reflect.Value, t reflect.Type, prefix string)
-	processFields = func(v reflect.Value, t reflect.Type, prefix string) {
-		if processingErr != nil {
-			return
-		}
-		for i := 0; i < t.NumField(); i++ {
-			field := t.Field(i)
-			fieldVal := v.Field(i)
-
-			if !fieldVal.CanInterface() {
-				continue
-			}
-
-			gormTagValue := field.Tag.Get("gorm")
-			bsonTagValue := field.Tag.Get("bson")
-			jsonTagValue := field.Tag.Get("json")
-
-			logger.Debug("processing field", zap.String("fieldName", field.Name),
-				zap.String("gormTag", gormTagValue), zap.String("bsonTag", bsonTagValue),
-				zap.String("jsonTag", jsonTagValue),
-			)
-
-			// If the field is marked as embedded, recursively flatten its fields.
-			// If an embeddedPrefix is specified, prepend it to all keys from this embedded struct.
-			if strings.Contains(gormTagValue, "embedded") {
-				logger.Debug("processing embedded", zap.String("fieldName", field.Name))
-				embeddedPrefix := ""
-				for part := range strings.SplitSeq(gormTagValue, ";") {
-					part = strings.TrimSpace(part)
-					if after, ok := strings.CutPrefix(part, "embeddedPrefix:"); ok {
-						embeddedPrefix = after
-						break
-					}
-				}
-				if fieldVal.Kind() == reflect.Pointer {
-					if fieldVal.IsNil() {
-						continue
-					}
-					// Recursively process pointer embedded struct
-					processFields(fieldVal.Elem(), fieldVal.Elem().Type(), prefix+embeddedPrefix)
-				} else {
-					// Recursively process value embedded struct
-					processFields(fieldVal, field.Type, prefix+embeddedPrefix)
-				}
-				continue
-			}
-
-			var mapKey string
-			isJSONB := strings.Contains(gormTagValue, "type:jsonb") || strings.Contains(gormTagValue, "jsonb")
-
-			if strings.Contains(gormTagValue, "column:") {
-				parts := strings.SplitSeq(gormTagValue, ";")
-				for part := range parts {
-					part = strings.TrimSpace(part)
-					if after, ok := strings.CutPrefix(part, "column:"); ok {
-						mapKey = after
-						mapKey = strings.TrimSpace(mapKey)
-						break
-					}
-				}
-			}
-			if mapKey == "" {
-				if bsonTagValue != "" && bsonTagValue != "-" {
-					mapKey = strings.Split(bsonTagValue, ",")[0]
-				}
-			}
-			if mapKey == "" {
-				if isJSONB {
-					if jsonTagValue != "" && jsonTagValue != "-" {
-						mapKey = strings.Split(jsonTagValue, ",")[0]
-					}
-				} else {
-					mapKey = packageDefaultNamer(field.Name)
-				}
-			}
-			if mapKey == "" {
-				if isJSONB {
-					if jsonTagValue != "" && jsonTagValue != "-" {
-						mapKey = strings.Split(jsonTagValue, ",")[0]
-					} else {
-						mapKey = packageDefaultNamer(field.Name)
-					}
-				} else {
-					mapKey = packageDefaultNamer(field.Name)
-					if mapKey == "" && jsonTagValue != "" && jsonTagValue != "-" {
-						mapKey = strings.Split(jsonTagValue, ",")[0]
-					}
-				}
-			}
-			if mapKey == "" {
-				continue
-			}
-			if _, shouldSkip := skipMap[prefix+mapKey]; shouldSkip {
-				continue
-			}
-			if isJSONB {
-				if fieldVal.Kind() == reflect.Pointer && fieldVal.IsNil() {
-					continue
-				}
-				valueToMarshal := fieldVal.Interface()
-				if fieldVal.Kind() == reflect.Pointer {
-					valueToMarshal = fieldVal.Elem().Interface()
-				}
-				jsonValue, err := json.Marshal(valueToMarshal)
-				if err != nil {
-					processingErr = fmt.Errorf("failed to marshal field %s (%s) to JSON: %w", field.Name, prefix+mapKey, err)
-					return
-				}
-				result[prefix+mapKey] = json.RawMessage(jsonValue)
-				continue
-			}
-			if fieldVal.Kind() == reflect.Pointer {
-				if !fieldVal.IsNil() {
-					result[prefix+mapKey] = fieldVal.Elem().Interface()
-				}
-			} else {
-				result[prefix+mapKey] = fieldVal.Interface()
-			}
-		}
-	}
-	processFields(val, typ, "")
-	return result, processingErr
+	return buildRDBUpdateMap(x, newOptions([]Option{WithSkip(skipFields...)}), "BuildRDBUpdateMap")
 }
@@ -3,6 +3,8 @@ package dbu
 import (
 	"encoding/json"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -598,6 +600,58 @@ func TestBuildRDBUpdateMap_ShouldSkipNilTimeField(t *testing.T) {
 	t.Logf("DBU produced update map: %+v", actual)
 }
 
+func TestBuildRDBUpdateMap_PlanCache(t *testing.T) {
+	type Details struct {
+		Text *string `json:"text"`
+	}
+	type Travel struct {
+		ID       string   `json:"id"`
+		StartsAt string   `gorm:"column:starts_on"`
+		Details  *Details `gorm:"embedded;embeddedPrefix:details_"`
+	}
+
+	text := "hello"
+	obj := &Travel{ID: "1", StartsAt: "2025-10-25", Details: &Details{Text: &text}}
+
+	t.Run("[SUCCESS] should parse the tags once per type", func(t *testing.T) {
+		SetDefaultColumnNameFunc(DefaultSnakeCaseNamer)
+
+		_, err := BuildRDBUpdateMap(obj, nil)
+		require.NoError(t, err)
+
+		p, ok := defaultNamer.Load().plans.Load(reflect.TypeOf(Travel{}))
+		require.True(t, ok)
+		assert.Equal(t, []rdbField{
+			{name: "ID", column: "id", index: []int{0}, reason: "column from the namer"},
+			{name: "StartsAt", column: "starts_on", index: []int{1}, reason: "column from the gorm column setting"},
+			{name: "Details.Text", column: "details_text", index: []int{2, 0}, ptr: true, reason: `column from the namer with the embedded prefix "details_"`},
+		}, p.(*rdbPlan).fields)
+	})
+
+	t.Run("[SUCCESS] should drop the cached plans when the namer changes", func(t *testing.T) {
+		SetDefaultColumnNameFunc(strings.ToUpper)
+		defer SetDefaultColumnNameFunc(nil)
+
+		actual, err := BuildRDBUpdateMap(obj, []string{"details_TEXT"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"ID": "1", "starts_on": "2025-10-25"}, actual)
+	})
+
+	t.Run("[SUCCESS] should be safe for concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				actual, err := BuildRDBUpdateMap(obj, nil)
+				assert.NoError(t, err)
+				assert.Len(t, actual, 3)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
 func decodeRawMessages(m map[string]any) map[string]any {
 	out := map[string]any{}
 	for k, v := range m {
@@ -0,0 +1,123 @@
+package dbu
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// sqliteDialector is a stand-in for the SQLite GORM driver, enough to
+// render queries with DryRun: backtick-quoted identifiers, ? placeholders
+// and, unless rows are set, no connection.
+type sqliteDialector struct {
+	rows *fakeRows // returned by every query
+}
+
+func (sqliteDialector) Name() string { return "sqlite" }
+
+func (d sqliteDialector) Initialize(db *gorm.DB) error {
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	if d.rows != nil {
+		db.ConnPool = sql.OpenDB(fakeConnector{d.rows})
+	}
+	return nil
+}
+
+func (sqliteDialector) Migrator(*gorm.DB) gorm.Migrator { return nil }
+
+func (sqliteDialector) DataTypeOf(*schema.Field) string { return "" }
+
+func (sqliteDialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+func (sqliteDialector) BindVarTo(w clause.Writer, _ *gorm.Statement, _ any) {
+	w.WriteByte('?')
+}
+
+func (sqliteDialector) QuoteTo(w clause.Writer, s string) {
+	w.WriteByte('`')
+	w.WriteString(s)
+	w.WriteByte('`')
+}
+
+func (sqliteDialector) Explain(sql string, vars ...any) string {
+	return logger.ExplainSQL(sql, nil, `"`, vars...)
+}
+
+// fakeRows are the canned result of the queries of a sqliteDialector, and
+// the queries it got.
+type fakeRows struct {
+	mu      sync.Mutex
+	columns []string
+	values  [][]driver.Value
+	queries []string
+	args    [][]any
+}
+
+// last returns the last query and its arguments.
+func (r *fakeRows) last() (string, []any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.queries[len(r.queries)-1], r.args[len(r.args)-1]
+}
+
+// set replaces the result of the next queries.
+func (r *fakeRows) set(columns []string, values ...[]driver.Value) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.columns, r.values = columns, values
+}
+
+type fakeConnector struct{ rows *fakeRows }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+
+func (c fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct{ rows *fakeRows }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+
+func (c fakeConn) Close() error { return nil }
+
+func (c fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.rows.mu.Lock()
+	defer c.rows.mu.Unlock()
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.rows.queries = append(c.rows.queries, query)
+	c.rows.args = append(c.rows.args, values)
+	return &fakeResult{columns: c.rows.columns, values: c.rows.values}, nil
+}
+
+type fakeResult struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeResult) Columns() []string { return r.columns }
+
+func (r *fakeResult) Close() error { return nil }
+
+func (r *fakeResult) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -1,8 +1,9 @@
 package dbu
 
 import (
+	"fmt"
+	"reflect"
 	"unicode"
-	"unicode/utf8"
 )
 
 // toSnakeCase converts a camelCase or PascalCase string to snake_case.
@@ -31,20 +32,29 @@ func toSnakeCase(str string) string {
 	return string(output)
 }
 
-// firstCharToLower converts the first character of a string to lowercase.
-// It handles empty strings and single-rune strings.
-// This is used as the default key naming fallback for BuildMongoUpdateMap.
-func firstCharToLower(s string) string {
-	if s == "" {
-		return ""
+// structValue returns the struct x or x points to, or an invalid value for
+// a nil pointer, and an error naming the function fn if x is not a struct
+// or pointer to struct.
+func structValue(x any, fn string) (reflect.Value, error) {
+	if x == nil {
+		return reflect.Value{}, fmt.Errorf("%s input must be a struct or pointer to struct, got nil", fn)
 	}
-	r, size := utf8.DecodeRuneInString(s)
-	if r == utf8.RuneError && size <= 1 {
-		return s // Handle errors or empty/invalid runes
+
+	val := reflect.ValueOf(x)
+
+	// Handle nil input pointer
+	if val.Kind() == reflect.Pointer && val.IsNil() {
+		return reflect.Value{}, nil
 	}
-	lc := unicode.ToLower(r)
-	if r == lc {
-		return s // First character is already lowercase or not a letter
+
+	// Dereference pointer if necessary
+	if val.Kind() == reflect.Pointer {
+		val = val.Elem()
+	}
+
+	// Ensure it's a struct
+	if val.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%s input must be a struct or pointer to struct, got %s", fn, val.Kind())
 	}
-	return string(lc) + s[size:]
+	return val, nil
 }
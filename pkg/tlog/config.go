@@ -10,4 +10,18 @@ type Config struct {
 	TimeFormat  string
 	ForceText   bool
 	ForceJSON   bool
+	Dev         bool             // human-friendly stderr output for development, overrides ForceText/ForceJSON
+	Syslog      *SyslogOptions   // also send logs to syslog if not nil
+	Journald    *JournaldOptions // also send logs to journald if not nil
+	OTLP        *OTLPOptions     // also export logs to an OpenTelemetry collector if not nil
+	Mask        *MaskOptions     // mask secrets in attributes of all handlers if not nil
+
+	// Dispatch controls how records reach the handlers above: parallel or
+	// async fan-out, error reporting and disabling of failing handlers.
+	// Default: handlers are called sequentially.
+	Dispatch *MultiHandlerOptions
+
+	// Sampling rate-limits and samples records before they are dispatched,
+	// and logs summaries of the suppressed ones. Default: no sampling.
+	Sampling *SamplingOptions
 }
@@ -0,0 +1,472 @@
+package tlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/byte4cat/nbx/v2/pkg/tlog/tlogtest"
+	"github.com/stretchr/testify/require"
+)
+
+// quiet keeps the stderr handler out of the way when another sink is tested.
+var quiet = slog.Level(100)
+
+// TestConformance runs testing/slogtest against MultiHandler and every
+// handler tlog.New can produce.
+func TestConformance(t *testing.T) {
+	t.Run("stderr json", func(t *testing.T) {
+		var buf bytes.Buffer
+		slogtest.Run(t,
+			func(t *testing.T) slog.Handler {
+				buf.Reset()
+				h, _ := newHandlerTo(&Config{ForceJSON: true}, &buf, false)
+				return h
+			},
+			func(t *testing.T) map[string]any { return parseJSONLine(t, buf.Bytes()) },
+		)
+	})
+
+	t.Run("stderr tint", func(t *testing.T) {
+		var buf bytes.Buffer
+		slogtest.Run(t,
+			func(t *testing.T) slog.Handler {
+				buf.Reset()
+				h, _ := newHandlerTo(&Config{ForceText: true}, &buf, false)
+				return h
+			},
+			func(t *testing.T) map[string]any { return parseTintLine(t, buf.String()) },
+		)
+	})
+
+	t.Run("stderr dev", func(t *testing.T) {
+		var buf bytes.Buffer
+		slogtest.Run(t,
+			func(t *testing.T) slog.Handler {
+				buf.Reset()
+				h, _ := newHandlerTo(&Config{Dev: true, TimeFormat: time.RFC3339}, &buf, false)
+				return h
+			},
+			func(t *testing.T) map[string]any { return parseDevOutput(t, buf.String()) },
+		)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		var path string
+		slogtest.Run(t,
+			func(t *testing.T) slog.Handler {
+				path = filepath.Join(t.TempDir(), "app.log")
+				h, release := newHandlerTo(&Config{StderrLevel: quiet, FileLevel: slog.LevelInfo, LogFilePath: path}, io.Discard, false)
+				t.Cleanup(func() { _ = release() })
+				return h
+			},
+			func(t *testing.T) map[string]any {
+				data, err := os.ReadFile(path)
+				require.NoError(t, err)
+				return parseJSONLine(t, data)
+			},
+		)
+	})
+
+	for name, mode := range map[string]DispatchMode{
+		"dispatch sequential": DispatchSequential,
+		"dispatch parallel":   DispatchParallel,
+		"dispatch async":      DispatchAsync,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				buf     bytes.Buffer
+				release func() error
+			)
+			slogtest.Run(t,
+				func(t *testing.T) slog.Handler {
+					buf.Reset()
+					var h slog.Handler
+					h, release = newHandlerTo(&Config{ForceJSON: true, Dispatch: &MultiHandlerOptions{Mode: mode}}, &buf, false)
+					return h
+				},
+				func(t *testing.T) map[string]any {
+					require.NoError(t, release()) // drains async queues
+					return parseJSONLine(t, buf.Bytes())
+				},
+			)
+		})
+	}
+
+	t.Run("sampling", func(t *testing.T) {
+		var buf bytes.Buffer
+		slogtest.Run(t,
+			func(t *testing.T) slog.Handler {
+				buf.Reset()
+				h, release := newHandlerTo(&Config{ForceJSON: true, Sampling: &SamplingOptions{First: 100, Tick: time.Hour}}, &buf, false)
+				t.Cleanup(func() { _ = release() })
+				return h
+			},
+			func(t *testing.T) map[string]any { return parseJSONLine(t, buf.Bytes()) },
+		)
+	})
+
+	t.Run("syslog", func(t *testing.T) {
+		var pc net.PacketConn
+		slogtest.Run(t,
+			func(t *testing.T) slog.Handler {
+				var err error
+				pc, err = net.ListenPacket("udp", "127.0.0.1:0")
+				require.NoError(t, err)
+				t.Cleanup(func() { pc.Close() })
+
+				h, release := newHandlerTo(&Config{
+					StderrLevel: quiet,
+					Syslog:      &SyslogOptions{Network: "udp", Addr: pc.LocalAddr().String(), AddSource: true},
+				}, io.Discard, false)
+				t.Cleanup(func() { _ = release() })
+				return h
+			},
+			func(t *testing.T) map[string]any {
+				buf := make([]byte, 4096)
+				_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+				n, _, err := pc.ReadFrom(buf)
+				require.NoError(t, err)
+				return parseSyslog(t, string(buf[:n]))
+			},
+		)
+	})
+
+	t.Run("journald", func(t *testing.T) {
+		var conn *net.UnixConn
+		slogtest.Run(t,
+			func(t *testing.T) slog.Handler {
+				path := filepath.Join(t.TempDir(), "journal.sock")
+				var err error
+				conn, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+				require.NoError(t, err)
+				t.Cleanup(func() { conn.Close() })
+
+				h, release := newHandlerTo(&Config{
+					StderrLevel: quiet,
+					Journald:    &JournaldOptions{Addr: path, AddSource: true},
+				}, io.Discard, false)
+				t.Cleanup(func() { _ = release() })
+				return h
+			},
+			func(t *testing.T) map[string]any {
+				buf := make([]byte, 4096)
+				_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+				n, err := conn.Read(buf)
+				require.NoError(t, err)
+				return parseJournal(t, buf[:n])
+			},
+		)
+	})
+
+	t.Run("otlp", func(t *testing.T) {
+		var (
+			collector *otlpCollector
+			release   func() error
+		)
+		slogtest.Run(t,
+			func(t *testing.T) slog.Handler {
+				collector = newOTLPCollector(t)
+				var h slog.Handler
+				h, release = newHandlerTo(&Config{
+					StderrLevel: quiet,
+					OTLP:        &OTLPOptions{Endpoint: collector.URL, AddSource: true},
+				}, io.Discard, false)
+				return h
+			},
+			func(t *testing.T) map[string]any {
+				require.NoError(t, release()) // flushes the batch
+				records := collector.records()
+				require.Len(t, records, 1)
+				return parseOTLP(records[0])
+			},
+		)
+	})
+
+	t.Run("MultiHandler", func(t *testing.T) {
+		var capture *tlogtest.Handler
+		slogtest.Run(t,
+			func(t *testing.T) slog.Handler {
+				capture = tlogtest.NewHandler(&tlogtest.Options{AddSource: true})
+				return &MultiHandler{handlers: []slog.Handler{capture}}
+			},
+			func(t *testing.T) map[string]any {
+				records := capture.Records()
+				require.Len(t, records, 1)
+				return tlogtest.ToMap(records[0])
+			},
+		)
+	})
+}
+
+func parseJSONLine(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	require.NotEmpty(t, lines[0])
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &m))
+	return m
+}
+
+var ansi = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// parseTintLine parses "[time] LVL [source] msg k=v G.k=v ...".
+func parseTintLine(t *testing.T, line string) map[string]any {
+	t.Helper()
+	line = strings.TrimSpace(ansi.ReplaceAllString(line, ""))
+	tokens := splitQuoted(line)
+	require.NotEmpty(t, tokens)
+
+	m := map[string]any{}
+	if _, err := time.Parse(time.RFC3339, tokens[0]); err == nil {
+		m[slog.TimeKey] = tokens[0]
+		tokens = tokens[1:]
+	}
+	m[slog.LevelKey] = tokens[0]
+	tokens = tokens[1:]
+	if len(tokens) > 0 && strings.Contains(tokens[0], ".go:") {
+		m[slog.SourceKey] = tokens[0]
+		tokens = tokens[1:]
+	}
+
+	var msg []string
+	for len(tokens) > 0 && !strings.Contains(tokens[0], "=") {
+		msg = append(msg, tokens[0])
+		tokens = tokens[1:]
+	}
+	m[slog.MessageKey] = strings.Join(msg, " ")
+
+	for _, tok := range tokens {
+		k, v, _ := strings.Cut(tok, "=")
+		if uq, err := strconv.Unquote(v); err == nil {
+			v = uq
+		}
+		setPath(m, strings.Split(k, "."), v)
+	}
+	return m
+}
+
+// parseDevOutput parses the header line of DevHandler and the indented
+// "key: value" lines below it.
+func parseDevOutput(t *testing.T, out string) map[string]any {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	tokens := strings.Fields(lines[0])
+	require.NotEmpty(t, tokens)
+
+	m := map[string]any{}
+	if _, err := time.Parse(time.RFC3339, tokens[0]); err == nil {
+		m[slog.TimeKey] = tokens[0]
+		tokens = tokens[1:]
+	}
+	m[slog.LevelKey] = tokens[0]
+	tokens = tokens[1:]
+	if n := len(tokens); n > 0 && strings.Contains(tokens[n-1], ".go:") {
+		m[slog.SourceKey] = tokens[n-1]
+		tokens = tokens[:n-1]
+	}
+	m[slog.MessageKey] = strings.Join(tokens, " ")
+
+	var path []string
+	for _, line := range lines[1:] {
+		depth := (len(line) - len(strings.TrimLeft(line, " "))) / 2
+		key, value, _ := strings.Cut(strings.TrimSpace(line), ":")
+		path = append(path[:depth-1], key)
+		if value == "" {
+			continue // group, its attributes follow
+		}
+		setPath(m, path, strings.TrimPrefix(value, " "))
+	}
+	return m
+}
+
+// splitQuoted splits s on spaces outside of double-quoted strings.
+func splitQuoted(s string) []string {
+	var (
+		tokens  []string
+		cur     strings.Builder
+		quoted  bool
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+var syslogLevels = map[int]string{7: "DEBUG", 6: "INFO", 5: "INFO+2", 4: "WARN", 3: "ERROR", 2: "ERROR+4"}
+
+// parseSyslog parses "<PRI>1 TIMESTAMP HOST APP PROCID MSGID SD MSG".
+func parseSyslog(t *testing.T, msg string) map[string]any {
+	t.Helper()
+	header := strings.SplitN(msg, " ", 7)
+	require.Len(t, header, 7, msg)
+
+	m := map[string]any{}
+	pri, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(header[0], "<"), ">1"))
+	require.NoError(t, err, msg)
+	m[slog.LevelKey] = syslogLevels[pri%8]
+	if header[1] != "-" {
+		m[slog.TimeKey] = header[1]
+	}
+
+	rest := header[6]
+	if strings.HasPrefix(rest, "-") {
+		rest = strings.TrimPrefix(rest, "-")
+	} else {
+		// [SDID name="value" ...]
+		require.True(t, strings.HasPrefix(rest, "["), msg)
+		i := strings.IndexByte(rest, ' ')
+		end := strings.IndexByte(rest, ']')
+		if end < i || i < 0 {
+			rest = rest[end+1:]
+		} else {
+			rest = rest[i+1:]
+			for {
+				eq := strings.Index(rest, `="`)
+				name := rest[:eq]
+				rest = rest[eq+2:]
+				var val strings.Builder
+				j := 0
+				for ; rest[j] != '"'; j++ {
+					if rest[j] == '\\' {
+						j++
+					}
+					val.WriteByte(rest[j])
+				}
+				setPath(m, strings.Split(name, "."), val.String())
+				rest = rest[j+1:]
+				if rest[0] == ']' {
+					rest = rest[1:]
+					break
+				}
+				rest = rest[1:]
+			}
+		}
+	}
+	m[slog.MessageKey] = strings.TrimPrefix(rest, " ")
+	return m
+}
+
+// parseJournal decodes a native protocol datagram. Journal field names are
+// upper-case, so attribute names are mapped back following slogtest's
+// convention of upper-case group names and lower-case keys.
+func parseJournal(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	m := map[string]any{}
+	r := bufio.NewReader(bytes.NewReader(data))
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			var n uint64
+			require.NoError(t, binary.Read(r, binary.LittleEndian, &n))
+			b := make([]byte, n+1)
+			_, err := io.ReadFull(r, b)
+			require.NoError(t, err)
+			value = string(b[:n])
+		}
+
+		switch name {
+		case "MESSAGE":
+			m[slog.MessageKey] = value
+		case "PRIORITY":
+			p, _ := strconv.Atoi(value)
+			m[slog.LevelKey] = syslogLevels[p]
+		case "SYSLOG_TIMESTAMP":
+			m[slog.TimeKey] = value
+		case "CODE_FILE":
+			m[slog.SourceKey] = value
+		case "SYSLOG_IDENTIFIER", "CODE_LINE", "CODE_FUNC":
+		default:
+			path := strings.Split(name, "_")
+			path[len(path)-1] = strings.ToLower(path[len(path)-1])
+			setPath(m, path, value)
+		}
+	}
+	return m
+}
+
+func parseOTLP(rec map[string]any) map[string]any {
+	m := map[string]any{}
+	if ts, ok := rec["timeUnixNano"]; ok {
+		m[slog.TimeKey] = ts
+	}
+	m[slog.LevelKey] = rec["severityText"]
+	m[slog.MessageKey] = rec["body"].(map[string]any)["stringValue"]
+
+	attrs, _ := rec["attributes"].([]any)
+	for k, v := range otlpAttrsToMap(attrs) {
+		if k == "code.filepath" {
+			k = slog.SourceKey
+		}
+		m[k] = v
+	}
+	return m
+}
+
+func otlpAttrsToMap(attrs []any) map[string]any {
+	m := map[string]any{}
+	for _, a := range attrs {
+		kv := a.(map[string]any)
+		v := kv["value"].(map[string]any)
+		if kvlist, ok := v["kvlistValue"].(map[string]any); ok {
+			values, _ := kvlist["values"].([]any)
+			m[kv["key"].(string)] = otlpAttrsToMap(values)
+			continue
+		}
+		for _, x := range v {
+			m[kv["key"].(string)] = x
+		}
+	}
+	return m
+}
+
+// setPath stores v in m under the nested keys of path.
+func setPath(m map[string]any, path []string, v any) {
+	for _, k := range path[:len(path)-1] {
+		sub, ok := m[k].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			m[k] = sub
+		}
+		m = sub
+	}
+	m[path[len(path)-1]] = v
+}
@@ -0,0 +1,106 @@
+package tlog
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sinkConn is a network connection shared by a handler and all of its
+// WithAttrs/WithGroup clones. It serialises writes and redials once when a
+// write fails, so a restarted log daemon does not break the handler.
+type sinkConn struct {
+	mu      sync.Mutex
+	network string
+	addr    string
+	framed  bool // prefix each message with its length (RFC 6587 octet counting)
+	timeout time.Duration
+	conn    net.Conn
+	closed  bool
+}
+
+var errHandlerClosed = errors.New("tlog: handler closed")
+
+func dialSink(network, addr string, framed bool, timeout time.Duration) (*sinkConn, error) {
+	c := &sinkConn{
+		network: network,
+		addr:    addr,
+		framed:  framed,
+		timeout: timeout,
+	}
+	if err := c.dial(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *sinkConn) dial() error {
+	conn, err := net.DialTimeout(c.network, c.addr, c.timeout)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	return nil
+}
+
+func (c *sinkConn) write(msg []byte) error {
+	if c.framed {
+		frame := make([]byte, 0, len(msg)+8)
+		frame = strconv.AppendInt(frame, int64(len(msg)), 10)
+		frame = append(frame, ' ')
+		msg = append(frame, msg...)
+	}
+	return c.send(func(conn net.Conn) error {
+		_, err := conn.Write(msg)
+		return err
+	})
+}
+
+// send calls fn with the connection, for writes other than a plain
+// message, under the same locking and redialing as write.
+func (c *sinkConn) send(fn func(net.Conn) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errHandlerClosed
+	}
+
+	if c.conn != nil {
+		if err := c.sendLocked(fn); err == nil {
+			return nil
+		}
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+
+	if err := c.dial(); err != nil {
+		return err
+	}
+	return c.sendLocked(fn)
+}
+
+func (c *sinkConn) sendLocked(fn func(net.Conn) error) error {
+	if c.timeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	return fn(c.conn)
+}
+
+func (c *sinkConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errHandlerClosed
+	}
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
@@ -0,0 +1,398 @@
+package tlog
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiDim   = "\x1b[2m"
+	ansiBold  = "\x1b[1m"
+)
+
+// devKeyColors are the 256-colour palette entries keys are coloured with.
+var devKeyColors = []int{33, 38, 43, 69, 74, 79, 105, 110, 115, 141, 146, 171, 176, 181, 207, 212, 217}
+
+type DevOptions struct {
+	Level      slog.Leveler // default: Debug
+	AddSource  bool
+	NoColor    bool
+	TimeFormat string // default: "15:04:05.000"
+
+	// ReplaceAttr is called on every non-group attribute except the built-in
+	// time, level, message and source.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+}
+
+// DevHandler is a slog.Handler for reading logs in a terminal during
+// development. Each attribute is printed on its own line below the message,
+// groups as indented trees, errors with one line per wrapped error, and
+// structs, maps and slices as indented JSON:
+//
+//	12:30:45.123 ERR request failed service/api.go:42
+//	  req:
+//	    id: 42
+//	  err: handle request
+//	    ↳ query users
+//	    ↳ connection refused
+type DevHandler struct {
+	opts DevOptions
+	mu   *sync.Mutex
+	w    io.Writer
+	goa  *groupOrAttrs
+}
+
+// NewDevHandler returns a DevHandler writing to w.
+func NewDevHandler(w io.Writer, opts *DevOptions) *DevHandler {
+	var o DevOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelDebug
+	}
+	if o.TimeFormat == "" {
+		o.TimeFormat = "15:04:05.000"
+	}
+	return &DevHandler{opts: o, mu: &sync.Mutex{}, w: w}
+}
+
+func (h *DevHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.opts.Level.Level()
+}
+
+func (h *DevHandler) Handle(_ context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+
+	if !r.Time.IsZero() {
+		h.write(&buf, ansiDim, r.Time.Format(h.opts.TimeFormat))
+		buf.WriteByte(' ')
+	}
+	h.write(&buf, devLevelColor(r.Level), devLevel(r.Level))
+	buf.WriteByte(' ')
+	h.write(&buf, ansiBold, r.Message)
+	if h.opts.AddSource && r.PC != 0 {
+		buf.WriteByte(' ')
+		h.writeSource(&buf, recordSource(r, nil))
+	}
+	buf.WriteByte('\n')
+
+	h.writeNodes(&buf, h.tree(r).children, 1)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *DevHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.goa = &groupOrAttrs{attrs: attrs, next: h.goa}
+	return &h2
+}
+
+func (h *DevHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.goa = &groupOrAttrs{group: name, next: h.goa}
+	return &h2
+}
+
+// devNode is an attribute, or a group when it has children.
+type devNode struct {
+	key      string
+	value    slog.Value
+	children []*devNode
+	group    bool
+}
+
+// tree nests the WithAttrs and record attributes under their groups.
+// Groups without attributes are left out.
+func (h *DevHandler) tree(r slog.Record) *devNode {
+	var chain []*groupOrAttrs
+	for g := h.goa; g != nil; g = g.next {
+		chain = append(chain, g)
+	}
+
+	root := &devNode{group: true}
+	var groups []string
+	add := func(attrs []slog.Attr) {
+		tmp := &devNode{group: true}
+		for _, a := range attrs {
+			h.addAttr(tmp, groups, a)
+		}
+		if len(tmp.children) > 0 {
+			parent := root
+			for _, g := range groups {
+				parent = parent.child(g)
+			}
+			parent.children = append(parent.children, tmp.children...)
+		}
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].group != "" {
+			groups = append(groups, chain[i].group)
+			continue
+		}
+		add(chain[i].attrs)
+	}
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	add(attrs)
+
+	return root
+}
+
+// child returns the group child key of n, adding it if needed.
+func (n *devNode) child(key string) *devNode {
+	for _, c := range n.children {
+		if c.group && c.key == key {
+			return c
+		}
+	}
+	c := &devNode{key: key, group: true}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (h *DevHandler) addAttr(parent *devNode, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup && h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		parent.children = append(parent.children, &devNode{key: a.Key, value: a.Value})
+		return
+	}
+
+	attrs := a.Value.Group()
+	if a.Key == "" {
+		for _, ga := range attrs {
+			h.addAttr(parent, groups, ga)
+		}
+		return
+	}
+	g := &devNode{key: a.Key, group: true}
+	groups = append(groups[:len(groups):len(groups)], a.Key)
+	for _, ga := range attrs {
+		h.addAttr(g, groups, ga)
+	}
+	if len(g.children) > 0 {
+		parent.children = append(parent.children, g)
+	}
+}
+
+func (h *DevHandler) writeNodes(buf *bytes.Buffer, nodes []*devNode, depth int) {
+	for _, n := range nodes {
+		indent := strings.Repeat("  ", depth)
+		buf.WriteString(indent)
+		h.write(buf, devKeyColor(n.key), n.key)
+		buf.WriteByte(':')
+
+		if n.group {
+			buf.WriteByte('\n')
+			h.writeNodes(buf, n.children, depth+1)
+			continue
+		}
+
+		if err, ok := n.value.Any().(error); ok && n.value.Kind() == slog.KindAny {
+			h.writeError(buf, err, indent)
+			continue
+		}
+
+		lines := strings.Split(devValue(n.value), "\n")
+		if len(lines) == 1 {
+			buf.WriteByte(' ')
+			buf.WriteString(lines[0])
+			buf.WriteByte('\n')
+			continue
+		}
+		buf.WriteByte('\n')
+		for _, line := range lines {
+			buf.WriteString(indent + "  " + line + "\n")
+		}
+	}
+}
+
+// writeError writes the message of err after its key and each error it
+// wraps on its own line below, without the text repeated from the
+// wrapping message. Joined errors are written as a list.
+func (h *DevHandler) writeError(buf *bytes.Buffer, err error, indent string) {
+	for first := true; err != nil; first = false {
+		msg := err.Error()
+
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs := joined.Unwrap()
+			msgs := make([]string, 0, len(errs))
+			for _, e := range errs {
+				if e != nil {
+					msgs = append(msgs, e.Error())
+				}
+			}
+			// errors.Join has no message of its own, fmt.Errorf with several %w has
+			if msg != strings.Join(msgs, "\n") {
+				h.writeErrorLine(buf, msg, indent, first)
+			} else if first {
+				buf.WriteByte('\n')
+			}
+			for _, e := range errs {
+				if e != nil {
+					buf.WriteString(indent + "  ")
+					h.write(buf, ansiDim, "-")
+					h.writeError(buf, e, indent+"  ")
+				}
+			}
+			return
+		}
+
+		next := errors.Unwrap(err)
+		if next != nil {
+			msg = strings.TrimSuffix(msg, ": "+next.Error())
+		}
+		h.writeErrorLine(buf, msg, indent, first)
+		err = next
+	}
+}
+
+func (h *DevHandler) writeErrorLine(buf *bytes.Buffer, msg, indent string, first bool) {
+	for i, line := range strings.Split(msg, "\n") {
+		switch {
+		case first && i == 0:
+			buf.WriteString(" " + line + "\n")
+		case i == 0:
+			buf.WriteString(indent + "  ")
+			h.write(buf, ansiDim, "↳")
+			buf.WriteString(" " + line + "\n")
+		case first:
+			buf.WriteString(indent + "  " + line + "\n")
+		default:
+			buf.WriteString(indent + "    " + line + "\n")
+		}
+	}
+}
+
+// writeSource writes file:line, relative to the working directory when the
+// file is below it, so terminals and editors can open it. With colours it is
+// also an OSC 8 hyperlink to the file.
+func (h *DevHandler) writeSource(buf *bytes.Buffer, src *slog.Source) {
+	file := src.File
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	text := file + ":" + strconv.Itoa(src.Line)
+
+	if h.opts.NoColor {
+		buf.WriteString(text)
+		return
+	}
+	buf.WriteString("\x1b]8;;file://" + filepath.ToSlash(src.File) + "\x1b\\")
+	h.write(buf, ansiDim, text)
+	buf.WriteString("\x1b]8;;\x1b\\")
+}
+
+// write writes s in colour unless colours are disabled.
+func (h *DevHandler) write(buf *bytes.Buffer, color, s string) {
+	if h.opts.NoColor || color == "" {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(color + s + ansiReset)
+}
+
+// devValue formats v, rendering structs, maps and slices as indented JSON.
+func devValue(v slog.Value) string {
+	if v.Kind() != slog.KindAny {
+		if v.Kind() == slog.KindString && v.String() == "" {
+			return `""`
+		}
+		return v.String()
+	}
+
+	x := v.Any()
+	switch x.(type) {
+	case fmt.Stringer, encoding.TextMarshaler, []byte:
+		return fmt.Sprint(x)
+	}
+
+	rv := reflect.ValueOf(x)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if b, err := json.MarshalIndent(x, "", "  "); err == nil {
+			return string(b)
+		}
+		return fmt.Sprintf("%+v", x)
+	}
+	return fmt.Sprint(x)
+}
+
+func devLevel(l slog.Level) string {
+	str := func(base string, delta slog.Level) string {
+		if delta == 0 {
+			return base
+		}
+		return fmt.Sprintf("%s%+d", base, delta)
+	}
+	switch {
+	case l < slog.LevelInfo:
+		return str("DBG", l-slog.LevelDebug)
+	case l < slog.LevelWarn:
+		return str("INF", l-slog.LevelInfo)
+	case l < slog.LevelError:
+		return str("WRN", l-slog.LevelWarn)
+	default:
+		return str("ERR", l-slog.LevelError)
+	}
+}
+
+func devLevelColor(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return "\x1b[94m"
+	case l < slog.LevelWarn:
+		return "\x1b[92m"
+	case l < slog.LevelError:
+		return "\x1b[93m"
+	default:
+		return "\x1b[91m"
+	}
+}
+
+// devKeyColor picks the colour of key from its hash, so a key has the same
+// colour in every record.
+func devKeyColor(key string) string {
+	f := fnv.New32a()
+	_, _ = f.Write([]byte(key))
+	return "\x1b[38;5;" + strconv.Itoa(devKeyColors[f.Sum32()%uint32(len(devKeyColors))]) + "m"
+}
@@ -0,0 +1,124 @@
+package tlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevHandler(t *testing.T) {
+	t.Run("[SUCCESS] should render groups as indented trees", func(t *testing.T) {
+		var buf bytes.Buffer
+		h := NewDevHandler(&buf, &DevOptions{NoColor: true})
+
+		logger := slog.New(h).With("service", "api").WithGroup("req")
+		r := slog.NewRecord(testTime, slog.LevelWarn+1, "slow request", 0)
+		r.AddAttrs(
+			slog.Int("ms", 1500),
+			slog.Group("user", slog.String("id", "u1"), slog.String("name", "")),
+			slog.Group("empty"),
+			slog.String("query", "select 1\nfrom dual"),
+		)
+		require.NoError(t, logger.Handler().Handle(context.Background(), r))
+
+		expected := "12:30:45.123 WRN+1 slow request\n" +
+			"  service: api\n" +
+			"  req:\n" +
+			"    ms: 1500\n" +
+			"    user:\n" +
+			"      id: u1\n" +
+			"      name: \"\"\n" +
+			"    query:\n" +
+			"      select 1\n" +
+			"      from dual\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("[SUCCESS] should expand wrapped and joined errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		h := NewDevHandler(&buf, &DevOptions{NoColor: true})
+
+		refused := errors.New("connection refused")
+		err := fmt.Errorf("handle request: %w", fmt.Errorf("query users: %w", refused))
+		joined := fmt.Errorf("shutdown: %w", errors.Join(errors.New("close db"), fmt.Errorf("flush: %w", refused)))
+
+		r := slog.NewRecord(testTime, slog.LevelError, "failed", 0)
+		r.AddAttrs(slog.Any("err", err), slog.Any("cleanup", joined))
+		require.NoError(t, h.Handle(context.Background(), r))
+
+		expected := "12:30:45.123 ERR failed\n" +
+			"  err: handle request\n" +
+			"    ↳ query users\n" +
+			"    ↳ connection refused\n" +
+			"  cleanup: shutdown\n" +
+			"    - close db\n" +
+			"    - flush\n" +
+			"      ↳ connection refused\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("[SUCCESS] should print structs and maps as indented JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		h := NewDevHandler(&buf, &DevOptions{NoColor: true})
+
+		type user struct {
+			ID   int      `json:"id"`
+			Tags []string `json:"tags"`
+		}
+		r := slog.NewRecord(testTime, slog.LevelInfo, "loaded", 0)
+		r.AddAttrs(slog.Any("user", &user{ID: 7, Tags: []string{"a"}}))
+		require.NoError(t, h.Handle(context.Background(), r))
+
+		expected := "12:30:45.123 INF loaded\n" +
+			"  user:\n" +
+			"    {\n" +
+			"      \"id\": 7,\n" +
+			"      \"tags\": [\n" +
+			"        \"a\"\n" +
+			"      ]\n" +
+			"    }\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("[SUCCESS] should colour keys consistently and link the source", func(t *testing.T) {
+		var buf bytes.Buffer
+		h := NewDevHandler(&buf, &DevOptions{AddSource: true})
+
+		pc, file, line, _ := runtime.Caller(0)
+		r := slog.NewRecord(testTime, slog.LevelInfo, "msg", pc)
+		r.AddAttrs(slog.String("id", "1"), slog.Group("g", slog.String("id", "2")))
+		require.NoError(t, h.Handle(context.Background(), r))
+
+		out := buf.String()
+		idKey := devKeyColor("id") + "id" + ansiReset
+		assert.Equal(t, 2, strings.Count(out, idKey))
+		assert.Contains(t, out, "\x1b]8;;file://"+file+"\x1b\\")
+		assert.Contains(t, out, fmt.Sprintf("dev_test.go:%d", line))
+	})
+
+	t.Run("[SUCCESS] should fall back to plain text without a TTY", func(t *testing.T) {
+		var buf bytes.Buffer
+		h, _ := newHandlerTo(&Config{Dev: true}, &buf, false)
+		slog.New(h).Info("plain", "k", "v")
+		assert.NotContains(t, buf.String(), "\x1b")
+		assert.Contains(t, buf.String(), "INF plain dev_test.go:")
+
+		buf.Reset()
+		h, _ = newHandlerTo(&Config{Dev: true}, &buf, true)
+		slog.New(h).Info("colour")
+		assert.Contains(t, buf.String(), "\x1b[")
+
+		buf.Reset()
+		h, _ = newHandlerTo(&Config{Dev: true, NoColor: true}, &buf, true)
+		slog.New(h).Info("no colour")
+		assert.NotContains(t, buf.String(), "\x1b")
+	})
+}
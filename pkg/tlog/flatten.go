@@ -0,0 +1,63 @@
+package tlog
+
+import (
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// flatAttr is a resolved, non-group attribute whose key already carries the
+// names of all enclosing groups.
+type flatAttr struct {
+	Key   string
+	Value slog.Value
+}
+
+// appendFlatAttr resolves a and appends it to dst as flat key/value pairs,
+// joining groups and nested group names with sep. Empty attrs and empty
+// groups are dropped and groups with an empty key are inlined. If rep is not
+// nil it is applied to every non-group attr, as slog.HandlerOptions.ReplaceAttr.
+func appendFlatAttr(dst []flatAttr, groups []string, sep string, a slog.Attr, rep func([]string, slog.Attr) slog.Attr) []flatAttr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup && rep != nil {
+		a = rep(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return dst
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return dst
+		}
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range attrs {
+			dst = appendFlatAttr(dst, groups, sep, ga, rep)
+		}
+		return dst
+	}
+
+	key := a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, sep) + sep + key
+	}
+	return append(dst, flatAttr{Key: key, Value: a.Value})
+}
+
+// recordSource returns the source location of r, passed through rep.
+// r.PC must not be zero.
+func recordSource(r slog.Record, rep func([]string, slog.Attr) slog.Attr) *slog.Source {
+	fs := runtime.CallersFrames([]uintptr{r.PC})
+	f, _ := fs.Next()
+	src := &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
+	if rep != nil {
+		if s, ok := rep(nil, slog.Any(slog.SourceKey, src)).Value.Any().(*slog.Source); ok {
+			src = s
+		}
+	}
+	return src
+}
@@ -2,17 +2,123 @@ package tlog
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// DispatchMode controls how MultiHandler passes a record to its handlers.
+type DispatchMode int
+
+const (
+	// DispatchSequential calls the handlers one after another.
+	DispatchSequential DispatchMode = iota
+	// DispatchParallel calls the handlers concurrently and waits for all of them.
+	DispatchParallel
+	// DispatchAsync queues the record for each handler and returns at once.
+	// Each handler is served by its own goroutine, so a slow sink only
+	// delays itself. Records are dropped when a handler's queue is full.
+	DispatchAsync
+)
+
+// ErrQueueFull is reported when DispatchAsync drops a record because the
+// handler's queue is full.
+var ErrQueueFull = errors.New("tlog: handler queue full, record dropped")
+
+type MultiHandlerOptions struct {
+	Mode      DispatchMode
+	QueueSize int // per handler queue length for DispatchAsync, default: 1024
+
+	// OnError receives handler failures: the errors.Join of all failures of a
+	// record for synchronous modes, each failure on its own for DispatchAsync.
+	OnError func(err error)
+
+	// FailureThreshold is the number of consecutive failures after which a
+	// handler is disabled for Cooldown. Zero never disables handlers.
+	FailureThreshold int
+	Cooldown         time.Duration // default: 30s
+}
+
+// HandlerHealth is a snapshot of the health of one handler of a MultiHandler.
+type HandlerHealth struct {
+	Index               int       // position in the handler list
+	Handler             string    // handler type, e.g. "*slog.JSONHandler"
+	Healthy             bool      // false while the handler is disabled
+	ConsecutiveFailures int       // failures since the last success
+	LastError           error     // most recent failure, nil after a success
+	DisabledUntil       time.Time // zero unless the handler is disabled
+	Dropped             uint64    // records dropped because the queue was full
+}
+
 // MultiHandler implements slog.Handler, send/save logs to multi places.
 type MultiHandler struct {
 	handlers []slog.Handler
+	state    *multiState // nil for a plain sequential MultiHandler
+}
+
+// multiState is shared by a MultiHandler and all of its WithAttrs/WithGroup
+// clones: health and queues belong to the underlying sinks, not to a clone.
+type multiState struct {
+	opts  MultiHandlerOptions
+	sinks []*sinkState
+
+	mu     sync.RWMutex // guards closed against sends on closed queues
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type sinkState struct {
+	name  string
+	queue chan asyncRecord
+
+	mu            sync.Mutex
+	failures      int
+	lastErr       error
+	disabledUntil time.Time
+	dropped       atomic.Uint64
+}
+
+type asyncRecord struct {
+	h   slog.Handler
+	ctx context.Context
+	r   slog.Record
+}
+
+// NewMultiHandler returns a MultiHandler dispatching to handlers according
+// to opts. With DispatchAsync call Close to drain the queues on shutdown.
+func NewMultiHandler(opts *MultiHandlerOptions, handlers ...slog.Handler) *MultiHandler {
+	var o MultiHandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 1024
+	}
+	if o.Cooldown <= 0 {
+		o.Cooldown = 30 * time.Second
+	}
+
+	st := &multiState{opts: o, sinks: make([]*sinkState, len(handlers))}
+	for i, h := range handlers {
+		s := &sinkState{name: fmt.Sprintf("%T", h)}
+		if o.Mode == DispatchAsync {
+			s.queue = make(chan asyncRecord, o.QueueSize)
+			st.wg.Add(1)
+			go st.worker(i, s)
+		}
+		st.sinks[i] = s
+	}
+
+	return &MultiHandler{handlers: handlers, state: st}
 }
 
 func (m *MultiHandler) Enabled(ctx context.Context, l slog.Level) bool {
-	for _, h := range m.handlers {
-		if h.Enabled(ctx, l) {
+	now := time.Now()
+	for i, h := range m.handlers {
+		if m.available(i, now) && h.Enabled(ctx, l) {
 			return true
 		}
 	}
@@ -20,12 +126,26 @@ func (m *MultiHandler) Enabled(ctx context.Context, l slog.Level) bool {
 }
 
 func (m *MultiHandler) Handle(ctx context.Context, r slog.Record) error {
-	for _, h := range m.handlers {
-		if h.Enabled(ctx, r.Level) {
-			_ = h.Handle(ctx, r.Clone())
+	if m.state == nil {
+		var errs []error
+		for i, h := range m.handlers {
+			if h.Enabled(ctx, r.Level) {
+				if err := h.Handle(ctx, r.Clone()); err != nil {
+					errs = append(errs, handlerError(i, h, err))
+				}
+			}
 		}
+		return errors.Join(errs...)
+	}
+
+	switch m.state.opts.Mode {
+	case DispatchAsync:
+		return m.handleAsync(ctx, r)
+	case DispatchParallel:
+		return m.handleParallel(ctx, r)
+	default:
+		return m.handleSequential(ctx, r)
 	}
-	return nil
 }
 
 func (m *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
@@ -33,7 +153,7 @@ func (m *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	for i, h := range m.handlers {
 		newHandlers[i] = h.WithAttrs(attrs)
 	}
-	return &MultiHandler{newHandlers}
+	return &MultiHandler{newHandlers, m.state}
 }
 
 func (m *MultiHandler) WithGroup(name string) slog.Handler {
@@ -41,5 +161,180 @@ func (m *MultiHandler) WithGroup(name string) slog.Handler {
 	for i, h := range m.handlers {
 		newHandlers[i] = h.WithGroup(name)
 	}
-	return &MultiHandler{newHandlers}
+	return &MultiHandler{newHandlers, m.state}
+}
+
+// Health reports the health of each handler. It returns nil for a
+// MultiHandler not created by NewMultiHandler.
+func (m *MultiHandler) Health() []HandlerHealth {
+	if m.state == nil {
+		return nil
+	}
+	now := time.Now()
+	out := make([]HandlerHealth, len(m.state.sinks))
+	for i, s := range m.state.sinks {
+		s.mu.Lock()
+		out[i] = HandlerHealth{
+			Index:               i,
+			Handler:             s.name,
+			Healthy:             !now.Before(s.disabledUntil),
+			ConsecutiveFailures: s.failures,
+			LastError:           s.lastErr,
+			Dropped:             s.dropped.Load(),
+		}
+		if !out[i].Healthy {
+			out[i].DisabledUntil = s.disabledUntil
+		}
+		s.mu.Unlock()
+	}
+	return out
+}
+
+// Close drains the async queues and waits for the pending records to be
+// handled, or for ctx to be done. Records handled after Close are dropped.
+func (m *MultiHandler) Close(ctx context.Context) error {
+	if m.state == nil {
+		return nil
+	}
+	st := m.state
+
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+		return errHandlerClosed
+	}
+	st.closed = true
+	for _, s := range st.sinks {
+		if s.queue != nil {
+			close(s.queue)
+		}
+	}
+	st.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		st.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *MultiHandler) handleSequential(ctx context.Context, r slog.Record) error {
+	now := time.Now()
+	var errs []error
+	for i, h := range m.handlers {
+		if !m.available(i, now) || !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := m.state.observe(i, h.Handle(ctx, r.Clone())); err != nil {
+			errs = append(errs, handlerError(i, h, err))
+		}
+	}
+	return m.state.report(errors.Join(errs...))
+}
+
+func (m *MultiHandler) handleParallel(ctx context.Context, r slog.Record) error {
+	now := time.Now()
+	errs := make([]error, len(m.handlers))
+	var wg sync.WaitGroup
+	for i, h := range m.handlers {
+		if !m.available(i, now) || !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, h slog.Handler, r slog.Record) {
+			defer wg.Done()
+			if err := m.state.observe(i, h.Handle(ctx, r)); err != nil {
+				errs[i] = handlerError(i, h, err)
+			}
+		}(i, h, r.Clone())
+	}
+	wg.Wait()
+	return m.state.report(errors.Join(errs...))
+}
+
+func (m *MultiHandler) handleAsync(ctx context.Context, r slog.Record) error {
+	st := m.state
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	if st.closed {
+		return errHandlerClosed
+	}
+
+	// the caller's context may be canceled before the record is handled
+	ctx = context.WithoutCancel(ctx)
+
+	now := time.Now()
+	var errs []error
+	for i, h := range m.handlers {
+		if !m.available(i, now) || !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		select {
+		case st.sinks[i].queue <- asyncRecord{h: h, ctx: ctx, r: r.Clone()}:
+		default:
+			st.sinks[i].dropped.Add(1)
+			errs = append(errs, handlerError(i, h, ErrQueueFull))
+		}
+	}
+	return st.report(errors.Join(errs...))
+}
+
+func (st *multiState) worker(i int, s *sinkState) {
+	defer st.wg.Done()
+	for rec := range s.queue {
+		if err := st.observe(i, rec.h.Handle(rec.ctx, rec.r)); err != nil {
+			st.report(handlerError(i, rec.h, err))
+		}
+	}
+}
+
+// available reports whether handler i is not disabled by its health state.
+func (m *MultiHandler) available(i int, now time.Time) bool {
+	if m.state == nil || m.state.opts.FailureThreshold <= 0 {
+		return true
+	}
+	s := m.state.sinks[i]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !now.Before(s.disabledUntil)
+}
+
+// observe records the outcome of a Handle call on handler i and returns err.
+// A handler is disabled for Cooldown once it failed FailureThreshold times in
+// a row; after the cooldown the next record probes it again.
+func (st *multiState) observe(i int, err error) error {
+	s := st.sinks[i]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		s.failures = 0
+		s.lastErr = nil
+		s.disabledUntil = time.Time{}
+		return nil
+	}
+
+	s.failures++
+	s.lastErr = err
+	if t := st.opts.FailureThreshold; t > 0 && s.failures >= t {
+		s.disabledUntil = time.Now().Add(st.opts.Cooldown)
+	}
+	return err
+}
+
+func (st *multiState) report(err error) error {
+	if err != nil && st.opts.OnError != nil {
+		st.opts.OnError(err)
+	}
+	return err
+}
+
+func handlerError(i int, h slog.Handler, err error) error {
+	return fmt.Errorf("tlog: handler %d (%T): %w", i, h, err)
 }
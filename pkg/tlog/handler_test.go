@@ -0,0 +1,204 @@
+package tlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubHandler counts records and fails or blocks on demand.
+type stubHandler struct {
+	level   slog.Level
+	err     atomic.Pointer[error]
+	delay   time.Duration
+	block   chan struct{}
+	handled atomic.Int32
+}
+
+func (h *stubHandler) Enabled(_ context.Context, l slog.Level) bool { return l >= h.level }
+
+func (h *stubHandler) Handle(context.Context, slog.Record) error {
+	if h.block != nil {
+		<-h.block
+	}
+	time.Sleep(h.delay)
+	h.handled.Add(1)
+	if err := h.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func (h *stubHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *stubHandler) WithGroup(string) slog.Handler      { return h }
+
+func (h *stubHandler) fail(err error) { h.err.Store(&err) }
+
+func record(msg string) slog.Record {
+	return slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)
+}
+
+func TestMultiHandler(t *testing.T) {
+	t.Run("[SUCCESS] should join handler errors and report them", func(t *testing.T) {
+		errA, errB := errors.New("a failed"), errors.New("b failed")
+		a, b, c := &stubHandler{}, &stubHandler{}, &stubHandler{}
+		a.fail(errA)
+		b.fail(errB)
+
+		var reported error
+		m := NewMultiHandler(&MultiHandlerOptions{OnError: func(err error) { reported = err }}, a, b, c)
+
+		err := m.Handle(context.Background(), record("msg"))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errA)
+		assert.ErrorIs(t, err, errB)
+		assert.Equal(t, err, reported)
+		assert.EqualValues(t, 1, c.handled.Load())
+	})
+
+	t.Run("[SUCCESS] should return errors from a plain MultiHandler", func(t *testing.T) {
+		errA := errors.New("a failed")
+		a := &stubHandler{}
+		a.fail(errA)
+
+		m := &MultiHandler{handlers: []slog.Handler{a, &stubHandler{}}}
+		assert.ErrorIs(t, m.Handle(context.Background(), record("msg")), errA)
+	})
+
+	t.Run("[SUCCESS] should call handlers concurrently in parallel mode", func(t *testing.T) {
+		slow1 := &stubHandler{delay: 100 * time.Millisecond}
+		slow2 := &stubHandler{delay: 100 * time.Millisecond}
+		m := NewMultiHandler(&MultiHandlerOptions{Mode: DispatchParallel}, slow1, slow2)
+
+		start := time.Now()
+		require.NoError(t, m.Handle(context.Background(), record("msg")))
+		assert.Less(t, time.Since(start), 190*time.Millisecond)
+		assert.EqualValues(t, 1, slow1.handled.Load())
+		assert.EqualValues(t, 1, slow2.handled.Load())
+	})
+
+	t.Run("[SUCCESS] should not let a slow sink delay others in async mode", func(t *testing.T) {
+		slow := &stubHandler{block: make(chan struct{})}
+		var buf bytes.Buffer
+		fast := slog.NewTextHandler(&syncWriter{w: &buf}, nil)
+
+		m := NewMultiHandler(&MultiHandlerOptions{Mode: DispatchAsync}, slow, fast)
+		logger := slog.New(m)
+
+		logger.Info("one")
+		logger.Info("two")
+		require.Eventually(t, func() bool {
+			return bytes.Count(syncRead(&buf), []byte("msg=")) == 2
+		}, time.Second, 5*time.Millisecond)
+		assert.Zero(t, slow.handled.Load())
+
+		close(slow.block)
+		require.NoError(t, m.Close(context.Background()))
+		assert.EqualValues(t, 2, slow.handled.Load())
+	})
+
+	t.Run("[FAILURE] should drop records when an async queue is full", func(t *testing.T) {
+		slow := &stubHandler{block: make(chan struct{})}
+
+		var mu sync.Mutex
+		var reported []error
+		m := NewMultiHandler(&MultiHandlerOptions{
+			Mode:      DispatchAsync,
+			QueueSize: 1,
+			OnError: func(err error) {
+				mu.Lock()
+				reported = append(reported, err)
+				mu.Unlock()
+			},
+		}, slow)
+
+		var dropped int
+		for range 5 {
+			if errors.Is(m.Handle(context.Background(), record("msg")), ErrQueueFull) {
+				dropped++
+			}
+		}
+		// one record in the worker, one in the queue
+		assert.GreaterOrEqual(t, dropped, 3)
+		assert.EqualValues(t, dropped, m.Health()[0].Dropped)
+
+		close(slow.block)
+		require.NoError(t, m.Close(context.Background()))
+		assert.ErrorIs(t, m.Handle(context.Background(), record("late")), errHandlerClosed)
+
+		mu.Lock()
+		assert.Len(t, reported, dropped)
+		mu.Unlock()
+	})
+
+	t.Run("[SUCCESS] should disable a failing handler and probe it after the cooldown", func(t *testing.T) {
+		broken := &stubHandler{}
+		broken.fail(errors.New("disk full"))
+		healthy := &stubHandler{}
+
+		m := NewMultiHandler(&MultiHandlerOptions{FailureThreshold: 2, Cooldown: 50 * time.Millisecond}, broken, healthy)
+
+		for range 4 {
+			_ = m.Handle(context.Background(), record("msg"))
+		}
+		assert.EqualValues(t, 2, broken.handled.Load(), "handler should be skipped once disabled")
+		assert.EqualValues(t, 4, healthy.handled.Load())
+
+		health := m.Health()
+		assert.False(t, health[0].Healthy)
+		assert.Equal(t, 2, health[0].ConsecutiveFailures)
+		assert.EqualError(t, health[0].LastError, "disk full")
+		assert.False(t, health[0].DisabledUntil.IsZero())
+		assert.Equal(t, "*tlog.stubHandler", health[0].Handler)
+		assert.True(t, health[1].Healthy)
+
+		broken.err.Store(nil)
+		time.Sleep(60 * time.Millisecond)
+		require.NoError(t, m.Handle(context.Background(), record("msg")))
+		assert.EqualValues(t, 3, broken.handled.Load())
+
+		health = m.Health()
+		assert.True(t, health[0].Healthy)
+		assert.Zero(t, health[0].ConsecutiveFailures)
+		assert.NoError(t, health[0].LastError)
+	})
+
+	t.Run("[SUCCESS] should share health between clones", func(t *testing.T) {
+		broken := &stubHandler{}
+		broken.fail(errors.New("boom"))
+
+		m := NewMultiHandler(&MultiHandlerOptions{FailureThreshold: 1}, broken)
+		clone := m.WithAttrs([]slog.Attr{slog.String("k", "v")}).WithGroup("g")
+
+		_ = clone.Handle(context.Background(), record("msg"))
+		assert.False(t, m.Health()[0].Healthy)
+		assert.False(t, m.Enabled(context.Background(), slog.LevelInfo))
+	})
+}
+
+// syncWriter guards a buffer written by an async worker and read by the test.
+type syncWriter struct {
+	w *bytes.Buffer
+}
+
+var syncBufMu sync.Mutex
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	syncBufMu.Lock()
+	defer syncBufMu.Unlock()
+	return w.w.Write(p)
+}
+
+func syncRead(buf *bytes.Buffer) []byte {
+	syncBufMu.Lock()
+	defer syncBufMu.Unlock()
+	return bytes.Clone(buf.Bytes())
+}
@@ -0,0 +1,193 @@
+package tlog
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultJournaldSocket is the journald native protocol socket.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+type JournaldOptions struct {
+	Addr       string       // default: DefaultJournaldSocket
+	Level      slog.Leveler // default: Info
+	Identifier string       // SYSLOG_IDENTIFIER, default: base name of os.Args[0]
+
+	AddSource   bool
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+
+	// WriteTimeout bounds each datagram write, default: 5s.
+	WriteTimeout time.Duration
+}
+
+// JournaldHandler is a slog.Handler that sends records to systemd-journald
+// using its native protocol. The message, priority and source location are
+// written to the well-known MESSAGE, PRIORITY and CODE_* fields; attributes
+// become journal fields named after their upper-cased key, with group names
+// joined by "_" (for example group "req" and key "id" become REQ_ID).
+// Entries too big for a datagram, such as long stack traces, are passed in
+// a sealed memfd, as the protocol expects.
+type JournaldHandler struct {
+	opts   JournaldOptions
+	conn   *sinkConn
+	groups []string
+	attrs  []flatAttr
+}
+
+// NewJournaldHandler connects to the journald socket described by opts.
+func NewJournaldHandler(opts *JournaldOptions) (*JournaldHandler, error) {
+	var o JournaldOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Addr == "" {
+		o.Addr = DefaultJournaldSocket
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelInfo
+	}
+	if o.Identifier == "" {
+		o.Identifier = filepath.Base(os.Args[0])
+	}
+	if o.WriteTimeout == 0 {
+		o.WriteTimeout = 5 * time.Second
+	}
+
+	conn, err := dialSink("unixgram", o.Addr, false, o.WriteTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("tlog: dial journald %s: %w", o.Addr, err)
+	}
+
+	return &JournaldHandler{opts: o, conn: conn}, nil
+}
+
+func (h *JournaldHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.opts.Level.Level()
+}
+
+func (h *JournaldHandler) Handle(_ context.Context, r slog.Record) error {
+	return sendJournalEntry(h.conn, h.format(r))
+}
+
+func (h *JournaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := h.clone()
+	h2.attrs = h.appendAttrs(h2.attrs, attrs...)
+	return h2
+}
+
+func (h *JournaldHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	return h2
+}
+
+// Close closes the journald socket.
+func (h *JournaldHandler) Close() error {
+	return h.conn.Close()
+}
+
+func (h *JournaldHandler) clone() *JournaldHandler {
+	h2 := *h
+	h2.groups = h.groups[:len(h.groups):len(h.groups)]
+	h2.attrs = h.attrs[:len(h.attrs):len(h.attrs)]
+	return &h2
+}
+
+func (h *JournaldHandler) appendAttrs(dst []flatAttr, attrs ...slog.Attr) []flatAttr {
+	for _, a := range attrs {
+		dst = appendFlatAttr(dst, h.groups, "_", a, h.opts.ReplaceAttr)
+	}
+	return dst
+}
+
+func (h *JournaldHandler) format(r slog.Record) []byte {
+	buf := make([]byte, 0, 256)
+	buf = appendJournalField(buf, "MESSAGE", r.Message)
+	buf = appendJournalField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(r.Level)))
+	buf = appendJournalField(buf, "SYSLOG_IDENTIFIER", h.opts.Identifier)
+	if !r.Time.IsZero() {
+		// journald stamps entries on receipt; keep the time the record was made
+		buf = appendJournalField(buf, "SYSLOG_TIMESTAMP", r.Time.Format(time.RFC3339Nano))
+	}
+
+	if h.opts.AddSource && r.PC != 0 {
+		src := recordSource(r, h.opts.ReplaceAttr)
+		buf = appendJournalField(buf, "CODE_FILE", src.File)
+		buf = appendJournalField(buf, "CODE_LINE", strconv.Itoa(src.Line))
+		buf = appendJournalField(buf, "CODE_FUNC", src.Function)
+	}
+
+	for _, a := range h.attrs {
+		buf = appendJournalField(buf, journalFieldName(a.Key), attrValueString(a.Value))
+	}
+	if r.NumAttrs() > 0 {
+		var attrs []flatAttr
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = h.appendAttrs(attrs, a)
+			return true
+		})
+		for _, a := range attrs {
+			buf = appendJournalField(buf, journalFieldName(a.Key), attrValueString(a.Value))
+		}
+	}
+	return buf
+}
+
+// appendJournalField appends one field in the native protocol encoding.
+// Values containing a newline use the binary form:
+// NAME '\n' little-endian uint64 length, value, '\n'.
+func appendJournalField(buf []byte, name, value string) []byte {
+	buf = append(buf, name...)
+	if !strings.ContainsRune(value, '\n') {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+		return append(buf, '\n')
+	}
+	buf = append(buf, '\n')
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(value)))
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
+
+// journalFieldName converts an attribute key into a valid journal field
+// name: upper-case letters, digits and underscores, not starting with an
+// underscore or digit, at most 64 characters.
+func journalFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		default:
+			c = '_'
+		}
+		b = append(b, c)
+	}
+
+	name := strings.TrimLeft(string(b), "_")
+	if name == "" {
+		name = "EMPTY"
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "F_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
@@ -0,0 +1,62 @@
+//go:build linux
+
+package tlog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// sendJournalEntry sends entry in one datagram or, when it is too big for
+// one, in a sealed memfd passed with SCM_RIGHTS.
+func sendJournalEntry(c *sinkConn, entry []byte) error {
+	return c.send(func(conn net.Conn) error {
+		_, err := conn.Write(entry)
+		if !errors.Is(err, syscall.EMSGSIZE) {
+			return err
+		}
+		return sendJournalMemfd(conn, entry)
+	})
+}
+
+func sendJournalMemfd(conn net.Conn, entry []byte) error {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("tlog: journal entry of %d bytes is too big for a datagram", len(entry))
+	}
+
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("tlog: create memfd for journal entry: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), "journal-entry")
+	defer f.Close()
+
+	if _, err := f.Write(entry); err != nil {
+		return fmt.Errorf("tlog: write journal entry to memfd: %w", err)
+	}
+	// journald only accepts sealed memfds, so the entry cannot change under it
+	seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+		return fmt.Errorf("tlog: seal journal entry memfd: %w", err)
+	}
+	// net refuses WriteMsgUnix on connected datagram sockets
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := unix.UnixRights(int(f.Fd()))
+	var sendErr error
+	if err := raw.Write(func(s uintptr) bool {
+		sendErr = unix.Sendmsg(int(s), nil, rights, nil, 0)
+		return sendErr != unix.EAGAIN
+	}); err != nil {
+		return err
+	}
+	return sendErr
+}
@@ -0,0 +1,60 @@
+//go:build linux
+
+package tlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestJournaldHandler_memfd(t *testing.T) {
+	t.Run("[SUCCESS] should pass entries too big for a datagram in a sealed memfd", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal.sock")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+		require.NoError(t, err)
+		defer conn.Close()
+
+		h, err := NewJournaldHandler(&JournaldOptions{Addr: path, Identifier: "x"})
+		require.NoError(t, err)
+		defer h.Close()
+
+		trace := strings.Repeat("goroutine 1 [running]:\n", 1<<16) // 1.5 MB
+		r := slog.NewRecord(time.Time{}, slog.LevelError, "panic", 0)
+		r.Add("trace", trace)
+		require.NoError(t, h.Handle(context.Background(), r))
+
+		buf, oob := make([]byte, 16), make([]byte, unix.CmsgSpace(4))
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		fds, err := unix.ParseUnixRights(&msgs[0])
+		require.NoError(t, err)
+		require.Len(t, fds, 1)
+		f := os.NewFile(uintptr(fds[0]), "journal-entry")
+		defer f.Close()
+
+		seals, err := unix.FcntlInt(f.Fd(), unix.F_GET_SEALS, 0)
+		require.NoError(t, err)
+		assert.NotZero(t, seals&unix.F_SEAL_WRITE)
+
+		entry, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<22))
+		require.NoError(t, err)
+		assert.Equal(t, string(h.format(r)), string(entry))
+	})
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package tlog
+
+// sendJournalEntry sends entry in one datagram. journald only runs on
+// Linux, where entries too big for a datagram are passed in a memfd.
+func sendJournalEntry(c *sinkConn, entry []byte) error {
+	return c.write(entry)
+}
@@ -0,0 +1,96 @@
+package tlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournaldHandler(t *testing.T) {
+	listen := func(t *testing.T) (*net.UnixConn, string) {
+		path := filepath.Join(t.TempDir(), "journal.sock")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return conn, path
+	}
+
+	read := func(t *testing.T, conn *net.UnixConn) []byte {
+		buf := make([]byte, 4096)
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		return buf[:n]
+	}
+
+	t.Run("[SUCCESS] should map record and attributes to journal fields", func(t *testing.T) {
+		conn, path := listen(t)
+
+		h, err := NewJournaldHandler(&JournaldOptions{Addr: path, Identifier: "tlog-test"})
+		require.NoError(t, err)
+		defer h.Close()
+
+		logger := slog.New(h).With("service", "api").WithGroup("req")
+		r := slog.NewRecord(testTime, slog.LevelError, "request failed", 0)
+		r.Add("id", 42, "user-agent", "curl", slog.Group("", "_inline", true))
+		require.NoError(t, logger.Handler().Handle(context.Background(), r))
+
+		expected := "MESSAGE=request failed\n" +
+			"PRIORITY=3\n" +
+			"SYSLOG_IDENTIFIER=tlog-test\n" +
+			"SYSLOG_TIMESTAMP=2025-05-01T12:30:45.123456Z\n" +
+			"SERVICE=api\n" +
+			"REQ_ID=42\n" +
+			"REQ_USER_AGENT=curl\n" +
+			"REQ__INLINE=true\n"
+		assert.Equal(t, expected, string(read(t, conn)))
+	})
+
+	t.Run("[SUCCESS] should use the binary encoding for multi-line values", func(t *testing.T) {
+		conn, path := listen(t)
+
+		h, err := NewJournaldHandler(&JournaldOptions{Addr: path, Identifier: "x", Level: slog.LevelDebug})
+		require.NoError(t, err)
+		defer h.Close()
+
+		r := slog.NewRecord(time.Time{}, slog.LevelDebug, "line1\nline2", 0)
+		require.NoError(t, h.Handle(context.Background(), r))
+
+		var expected bytes.Buffer
+		expected.WriteString("MESSAGE\n")
+		_ = binary.Write(&expected, binary.LittleEndian, uint64(len("line1\nline2")))
+		expected.WriteString("line1\nline2\n")
+		expected.WriteString("PRIORITY=7\nSYSLOG_IDENTIFIER=x\n")
+		assert.Equal(t, expected.Bytes(), read(t, conn))
+	})
+
+	t.Run("[FAILURE] should fail when the socket does not exist", func(t *testing.T) {
+		_, err := NewJournaldHandler(&JournaldOptions{Addr: filepath.Join(t.TempDir(), "missing.sock")})
+		assert.Error(t, err)
+	})
+}
+
+func Test_journalFieldName(t *testing.T) {
+	t.Run("[SUCCESS] should produce valid journal field names", func(t *testing.T) {
+		cases := map[string]string{
+			"key":        "KEY",
+			"http.path":  "HTTP_PATH",
+			"_private":   "PRIVATE",
+			"9lives":     "F_9LIVES",
+			"":           "EMPTY",
+			"café":       "CAF__",
+			"MixedCase1": "MIXEDCASE1",
+		}
+		for in, expected := range cases {
+			assert.Equal(t, expected, journalFieldName(in), in)
+		}
+	})
+}
@@ -0,0 +1,414 @@
+package tlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// maxMaskDepth bounds the traversal of self-referencing values.
+const maxMaskDepth = 32
+
+type MaskOptions struct {
+	// DenyKeys are attribute keys, struct field names and map keys whose
+	// values are masked, compared case-insensitively, e.g. "password".
+	// Struct fields tagged `log:"secret"` are always masked.
+	DenyKeys []string
+	Mask     string // replacement value, default: "***"
+}
+
+// Masker masks sensitive values before they are logged. Struct values are
+// rewritten to groups with their secret fields masked; what to mask is
+// computed once per type, so values of types without secrets are passed
+// through at the cost of a map lookup.
+type Masker struct {
+	deny  map[string]bool
+	mask  string
+	plans sync.Map // reflect.Type -> *maskPlan
+}
+
+// maskPlan describes how to mask the values of one type.
+type maskPlan struct {
+	needed bool        // the type contains something to mask
+	iface  bool        // the type contains interfaces, masked if their values need it
+	fields []maskField // for structs, with embedded structs inlined
+	elem   *maskPlan   // for pointers, slices, arrays and maps
+	keys   bool        // for maps with string keys: some keys may be denied
+}
+
+type maskField struct {
+	index  []int // as for reflect.Value.FieldByIndex
+	name   string
+	typ    reflect.Type
+	secret bool
+	plan   *maskPlan // nil for secret fields
+}
+
+// NewMasker returns a Masker for opts.
+func NewMasker(opts *MaskOptions) *Masker {
+	var o MaskOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Mask == "" {
+		o.Mask = "***"
+	}
+	m := &Masker{deny: map[string]bool{}, mask: o.Mask}
+	for _, k := range o.DenyKeys {
+		m.deny[strings.ToLower(k)] = true
+	}
+	return m
+}
+
+// ReplaceAttr is a slog.HandlerOptions.ReplaceAttr masking attributes with
+// denied keys and the secret fields of struct values. Combine it with other
+// ReplaceAttr functions with ChainReplaceAttr.
+func (m *Masker) ReplaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if m.deny[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, m.mask)
+	}
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+	x := a.Value.Any()
+	if x == nil {
+		return a
+	}
+	v := reflect.ValueOf(x)
+	if p := m.plan(v.Type()); m.needsMask(v, p, 0) {
+		a.Value = m.maskValue(v, p, 0)
+	}
+	return a
+}
+
+// Value returns v as a slog.LogValuer with its secrets masked, for handlers
+// not created with the Masker's ReplaceAttr:
+//
+//	logger.Info("login", "user", masker.Value(user))
+func (m *Masker) Value(v any) slog.LogValuer {
+	return maskedValue{m: m, v: v}
+}
+
+type maskedValue struct {
+	m *Masker
+	v any
+}
+
+func (mv maskedValue) LogValue() slog.Value {
+	return mv.m.ReplaceAttr(nil, slog.Any("", mv.v)).Value
+}
+
+// ChainReplaceAttr returns a ReplaceAttr calling fns in order, each with
+// the attribute returned by the previous one. Nil functions are skipped and
+// the chain stops once an attribute is dropped.
+func ChainReplaceAttr(fns ...func(groups []string, a slog.Attr) slog.Attr) func(groups []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		for _, fn := range fns {
+			if fn == nil {
+				continue
+			}
+			a = fn(groups, a)
+			if a.Equal(slog.Attr{}) {
+				return a
+			}
+		}
+		return a
+	}
+}
+
+// plan returns the cached plan for t.
+func (m *Masker) plan(t reflect.Type) *maskPlan {
+	if p, ok := m.plans.Load(t); ok {
+		return p.(*maskPlan)
+	}
+
+	building := map[reflect.Type]*maskPlan{}
+	p := m.build(t, building)
+
+	// needed and iface propagate from the types a type refers to, which may
+	// refer back
+	for changed := true; changed; {
+		changed = false
+		for _, bp := range building {
+			if !bp.needed && bp.refersTo(func(p *maskPlan) bool { return p.needed }) {
+				bp.needed = true
+				changed = true
+			}
+			if !bp.iface && bp.refersTo(func(p *maskPlan) bool { return p.iface }) {
+				bp.iface = true
+				changed = true
+			}
+		}
+	}
+	for bt, bp := range building {
+		m.plans.Store(bt, bp)
+	}
+	return p
+}
+
+// build creates the plans of t and the types it refers to that are not
+// cached yet, marking only what needs masking directly.
+func (m *Masker) build(t reflect.Type, building map[reflect.Type]*maskPlan) *maskPlan {
+	if p, ok := m.plans.Load(t); ok {
+		return p.(*maskPlan)
+	}
+	if p, ok := building[t]; ok {
+		return p
+	}
+	p := &maskPlan{}
+	building[t] = p
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		if t.Kind() != reflect.Pointer && t.Elem().Kind() == reflect.Uint8 {
+			break // []byte
+		}
+		p.elem = m.build(t.Elem(), building)
+	case reflect.Map:
+		p.keys = len(m.deny) > 0 && t.Key().Kind() == reflect.String
+		p.needed = p.keys
+		p.elem = m.build(t.Elem(), building)
+	case reflect.Interface:
+		// the dynamic type is planned when a value is masked
+		p.iface = true
+	case reflect.Struct:
+		p.fields = m.structFields(t, nil)
+		for i := range p.fields {
+			f := &p.fields[i]
+			if f.secret {
+				p.needed = true
+				continue
+			}
+			f.plan = m.build(f.typ, building)
+		}
+	}
+	return p
+}
+
+func (p *maskPlan) refersTo(has func(*maskPlan) bool) bool {
+	if p.elem != nil && has(p.elem) {
+		return true
+	}
+	for _, f := range p.fields {
+		if f.plan != nil && has(f.plan) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsMask reports whether v, of a type with plan p, has something to
+// mask: always if p is needed, else if an interface in v holds a value
+// whose dynamic type needs it. Values nested too deep to check are masked,
+// which cuts them off.
+func (m *Masker) needsMask(v reflect.Value, p *maskPlan, depth int) bool {
+	if p.needed {
+		return true
+	}
+	if !p.iface || !v.IsValid() {
+		return false
+	}
+	if depth > maxMaskDepth {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return false
+		}
+		e := v.Elem()
+		if v.Kind() == reflect.Interface {
+			p = m.plan(e.Type())
+		} else {
+			p = p.elem
+		}
+		return m.needsMask(e, p, depth+1)
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if m.needsMask(v.Index(i), p.elem, depth+1) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if m.needsMask(iter.Value(), p.elem, depth+1) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for _, f := range p.fields {
+			if fv, ok := fieldValue(v, f.index); ok && fv.CanInterface() && m.needsMask(fv, f.plan, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// structFields lists the exported fields of t as encoding/json would,
+// inlining embedded structs without a JSON name.
+func (m *Masker) structFields(t reflect.Type, index []int) []maskField {
+	var fields []maskField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		idx := append(index[:len(index):len(index)], i)
+
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, m.structFields(ft, idx)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, maskField{
+			index:  idx,
+			name:   name,
+			typ:    sf.Type,
+			secret: sf.Tag.Get("log") == "secret" || m.deny[strings.ToLower(name)] || m.deny[strings.ToLower(sf.Name)],
+		})
+	}
+	return fields
+}
+
+// maskValue returns v with its secrets masked: structs become groups at the
+// top level and maskedStructs below, maps and slices are copied.
+func (m *Masker) maskValue(v reflect.Value, p *maskPlan, depth int) slog.Value {
+	x := m.mask1(v, p, depth)
+	if s, ok := x.(maskedStruct); ok {
+		return s.LogValue()
+	}
+	return slog.AnyValue(x)
+}
+
+func (m *Masker) mask1(v reflect.Value, p *maskPlan, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxMaskDepth {
+		return m.mask
+	}
+	if !m.needsMask(v, p, depth) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return m.mask1(v.Elem(), p.elem, depth+1)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		e := v.Elem()
+		return m.mask1(e, m.plan(e.Type()), depth+1)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = m.mask1(v.Index(i), p.elem, depth+1)
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			if p.keys && m.deny[strings.ToLower(k)] {
+				out[k] = m.mask
+				continue
+			}
+			out[k] = m.mask1(iter.Value(), p.elem, depth+1)
+		}
+		return out
+	case reflect.Struct:
+		out := make(maskedStruct, 0, len(p.fields))
+		for _, f := range p.fields {
+			fv, ok := fieldValue(v, f.index)
+			if !ok || !fv.CanInterface() {
+				continue // nil embedded pointer or field of an unexported embedded struct
+			}
+			var x any = m.mask
+			if !f.secret {
+				x = m.mask1(fv, f.plan, depth+1)
+			}
+			out = append(out, maskedField{name: f.name, value: x})
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// maskedStruct is a masked struct value. It is logged as a group and
+// marshaled to JSON as an object with the fields in declaration order.
+type maskedStruct []maskedField
+
+type maskedField struct {
+	name  string
+	value any
+}
+
+func (s maskedStruct) LogValue() slog.Value {
+	attrs := make([]slog.Attr, len(s))
+	for i, f := range s {
+		attrs[i] = slog.Any(f.name, f.value)
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (s maskedStruct) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range s {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(f.name)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
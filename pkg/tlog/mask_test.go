@@ -0,0 +1,153 @@
+package tlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type maskCredentials struct {
+	User     string `json:"user"`
+	Password string `json:"password" log:"secret"`
+}
+
+type maskAccount struct {
+	ID int `json:"id"`
+	maskCredentials
+	Token   string            `json:"token"`
+	Headers map[string]string `json:"headers"`
+	Backup  []*maskCredentials
+	Note    any    `json:"note,omitempty"`
+	Ignored string `json:"-"`
+}
+
+type maskNode struct {
+	Name string
+	Next *maskNode
+	Key  string `log:"secret"`
+}
+
+type maskResult struct {
+	Name string
+	Err  error
+	Meta any
+}
+
+type maskPlain struct {
+	Name  string
+	Count int
+}
+
+func TestMasker(t *testing.T) {
+	m := NewMasker(&MaskOptions{DenyKeys: []string{"Token", "authorization", "api_key"}})
+
+	logJSON := func(t *testing.T, args ...any) map[string]any {
+		t.Helper()
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: m.ReplaceAttr})).Info("msg", args...)
+
+		var out map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+		return out
+	}
+
+	t.Run("[SUCCESS] should mask secret fields and denied keys", func(t *testing.T) {
+		acc := &maskAccount{
+			ID:              1,
+			maskCredentials: maskCredentials{User: "ann", Password: "hunter2"},
+			Token:           "t0k3n",
+			Headers:         map[string]string{"Authorization": "Bearer x", "Accept": "*/*"},
+			Backup:          []*maskCredentials{{User: "bob", Password: "pw"}},
+			Note:            maskCredentials{User: "cy", Password: "pw"},
+			Ignored:         "x",
+		}
+		out := logJSON(t, "account", acc, "api_key", "k", "user", "plain")
+
+		assert.Equal(t, map[string]any{
+			"id":       float64(1),
+			"user":     "ann",
+			"password": "***",
+			"token":    "***",
+			"headers":  map[string]any{"Authorization": "***", "Accept": "*/*"},
+			"Backup":   []any{map[string]any{"user": "bob", "password": "***"}},
+			"note":     map[string]any{"user": "cy", "password": "***"},
+		}, out["account"])
+		assert.Equal(t, "***", out["api_key"])
+		assert.Equal(t, "plain", out["user"])
+	})
+
+	t.Run("[SUCCESS] should handle self-referencing types", func(t *testing.T) {
+		n := &maskNode{Name: "a", Key: "s1"}
+		n.Next = &maskNode{Name: "b", Key: "s2"}
+		out := logJSON(t, "node", n)
+
+		assert.Equal(t, map[string]any{
+			"Name": "a",
+			"Key":  "***",
+			"Next": map[string]any{"Name": "b", "Key": "***", "Next": nil},
+		}, out["node"])
+	})
+
+	t.Run("[SUCCESS] should pass through and cache types without secrets", func(t *testing.T) {
+		v := maskPlain{Name: "x", Count: 2}
+		a := m.ReplaceAttr(nil, slog.Any("v", v))
+		assert.Equal(t, v, a.Value.Any())
+
+		p, ok := m.plans.Load(reflect.TypeOf(v))
+		require.True(t, ok)
+		assert.False(t, p.(*maskPlan).needed)
+
+		allocs := testing.AllocsPerRun(100, func() { m.ReplaceAttr(nil, slog.Any("v", v)) })
+		assert.LessOrEqual(t, allocs, 1.0)
+	})
+
+	t.Run("[SUCCESS] should pass through interfaces holding values without secrets", func(t *testing.T) {
+		v := maskResult{Name: "x", Err: errors.New("timeout"), Meta: maskPlain{Name: "y"}}
+		a := m.ReplaceAttr(nil, slog.Any("v", v))
+		assert.Equal(t, v, a.Value.Any())
+
+		allocs := testing.AllocsPerRun(100, func() { m.ReplaceAttr(nil, slog.Any("v", v)) })
+		assert.LessOrEqual(t, allocs, 1.0)
+
+		v.Meta = &maskCredentials{User: "al", Password: "pw"}
+		assert.Equal(t, map[string]any{
+			"Name": "x",
+			"Err":  "timeout",
+			"Meta": map[string]any{"user": "al", "password": "***"},
+		}, logJSON(t, "v", v)["v"])
+	})
+
+	t.Run("[SUCCESS] should mask through a LogValuer", func(t *testing.T) {
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Info("msg", "creds", m.Value(maskCredentials{User: "u", Password: "p"}))
+		assert.Contains(t, buf.String(), `"creds":{"user":"u","password":"***"}`)
+	})
+
+	t.Run("[SUCCESS] should chain with the source shortening of tlog.New", func(t *testing.T) {
+		var buf bytes.Buffer
+		h, _ := newHandlerTo(&Config{ForceJSON: true, Mask: &MaskOptions{DenyKeys: []string{"token"}}}, &buf, false)
+		slog.New(h).Info("login", "creds", maskCredentials{User: "u", Password: "p"}, "token", "t")
+
+		out := buf.String()
+		assert.Contains(t, out, `"creds":{"user":"u","password":"***"}`)
+		assert.Contains(t, out, `"token":"***"`)
+		assert.Contains(t, out, `"file":"tlog/mask_test.go"`)
+	})
+
+	t.Run("[SUCCESS] should stop the chain once an attribute is dropped", func(t *testing.T) {
+		called := false
+		chain := ChainReplaceAttr(
+			func([]string, slog.Attr) slog.Attr { return slog.Attr{} },
+			nil,
+			func(_ []string, a slog.Attr) slog.Attr { called = true; return a },
+		)
+		assert.Equal(t, slog.Attr{}, chain(nil, slog.String("k", "v")))
+		assert.False(t, called)
+	})
+}
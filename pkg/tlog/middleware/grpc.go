@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultGRPCLevel maps codes like go-grpc-middleware does for servers:
+// client mistakes at Info, transient failures at Warn and server faults at
+// Error.
+func DefaultGRPCLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.Unauthenticated:
+		return slog.LevelInfo
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.Unavailable:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor that logs each unary
+// call through logger once it returns.
+func UnaryServerInterceptor(logger *slog.Logger, opts *Options) grpc.UnaryServerInterceptor {
+	o := opts.withDefaults()
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx, reqLogger := o.grpcRequest(ctx, logger)
+
+		resp, err := handler(ctx, req)
+		o.logCall(ctx, reqLogger, info.FullMethod, "unary", start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a gRPC interceptor that logs each
+// streaming call through logger once it ends.
+func StreamServerInterceptor(logger *slog.Logger, opts *Options) grpc.StreamServerInterceptor {
+	o := opts.withDefaults()
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, reqLogger := o.grpcRequest(ss.Context(), logger)
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		o.logCall(ctx, reqLogger, info.FullMethod, "stream", start, err)
+		return err
+	}
+}
+
+// grpcRequest reads or generates the request ID, sends it back in the
+// response header and sets up the request-scoped logger.
+func (o Options) grpcRequest(ctx context.Context, logger *slog.Logger) (context.Context, *slog.Logger) {
+	key := strings.ToLower(o.RequestIDHeader)
+
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(key); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = o.NewRequestID()
+	}
+	// fails only outside of a server transport, e.g. in tests
+	_ = grpc.SetHeader(ctx, metadata.Pairs(key, id))
+
+	return withRequest(ctx, logger, id)
+}
+
+func (o Options) logCall(ctx context.Context, logger *slog.Logger, fullMethod, kind string, start time.Time, err error) {
+	if o.Skip != nil && o.Skip(fullMethod) {
+		return
+	}
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	code := status.Code(err)
+
+	attrs := []slog.Attr{
+		slog.String("grpc.service", service),
+		slog.String("grpc.method", method),
+		slog.String("grpc.kind", kind),
+		slog.String("grpc.code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	logger.LogAttrs(ctx, o.GRPCLevel(code), "grpc call", attrs...)
+}
+
+// serverStream replaces the context of a stream with the request-scoped one.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// InterceptorLogger adapts logger to the go-grpc-middleware logging
+// interceptors, like adapter.NewInterceptorLogger does for zap. The
+// request-scoped logger of the context is preferred when there is one.
+func InterceptorLogger(logger *slog.Logger) logging.Logger {
+	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		l := logger
+		if ctxLogger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			l = ctxLogger
+		}
+		l.Log(ctx, slog.Level(lvl), msg, fields...)
+	})
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/byte4cat/nbx/v2/pkg/tlog/tlogtest"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func TestGRPC(t *testing.T) {
+	capture := tlogtest.NewHandler(nil)
+	logger := slog.New(capture)
+
+	t.Run("[SUCCESS] should log unary calls with the request ID from metadata", func(t *testing.T) {
+		capture.Reset()
+		interceptor := UnaryServerInterceptor(logger, nil)
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "abc"))
+		info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Users/Get"}
+		resp, err := interceptor(ctx, "req", info, func(ctx context.Context, req any) (any, error) {
+			id, _ := RequestIDFromContext(ctx)
+			FromContext(ctx).Info("inside")
+			return id, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "abc", resp)
+
+		assert.Equal(t, 1, capture.Count(tlogtest.Message("inside"), tlogtest.HasAttr(RequestIDKey, "abc")))
+		call, ok := capture.Find(tlogtest.Message("grpc call"))
+		require.True(t, ok)
+		assert.Equal(t, slog.LevelInfo, call.Level)
+		assert.Equal(t, "pkg.Users", call.Attrs["grpc.service"])
+		assert.Equal(t, "Get", call.Attrs["grpc.method"])
+		assert.Equal(t, "OK", call.Attrs["grpc.code"])
+		assert.Equal(t, "abc", call.Attrs[RequestIDKey])
+	})
+
+	t.Run("[SUCCESS] should map codes to levels", func(t *testing.T) {
+		capture.Reset()
+		interceptor := UnaryServerInterceptor(logger, nil)
+		info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Users/Get"}
+
+		for _, err := range []error{
+			status.Error(codes.NotFound, "no user"),
+			status.Error(codes.Unavailable, "db down"),
+			errors.New("plain error"),
+		} {
+			_, _ = interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) { return nil, err })
+		}
+
+		assert.Equal(t, 1, capture.Count(tlogtest.Level(slog.LevelInfo), tlogtest.HasAttr("grpc.code", "NotFound")))
+		assert.Equal(t, 1, capture.Count(tlogtest.Level(slog.LevelWarn), tlogtest.HasAttr("grpc.code", "Unavailable")))
+		assert.Equal(t, 1, capture.Count(tlogtest.Level(slog.LevelError), tlogtest.HasAttr("grpc.code", "Unknown")))
+	})
+
+	t.Run("[SUCCESS] should put the scoped logger into the stream context", func(t *testing.T) {
+		capture.Reset()
+		interceptor := StreamServerInterceptor(logger, &Options{NewRequestID: func() string { return "gen" }})
+
+		ss := &fakeStream{ctx: context.Background()}
+		info := &grpc.StreamServerInfo{FullMethod: "/pkg.Users/Watch"}
+		err := interceptor(nil, ss, info, func(_ any, stream grpc.ServerStream) error {
+			FromContext(stream.Context()).Info("inside")
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, capture.Count(tlogtest.Message("inside"), tlogtest.HasAttr(RequestIDKey, "gen")))
+		assert.Equal(t, 1, capture.Count(tlogtest.Message("grpc call"), tlogtest.HasAttr("grpc.kind", "stream")))
+	})
+
+	t.Run("[SUCCESS] should adapt slog to go-grpc-middleware logging", func(t *testing.T) {
+		capture.Reset()
+		ctx, _ := withRequest(context.Background(), logger, "abc")
+		InterceptorLogger(logger).Log(ctx, logging.LevelWarn, "finished call", "grpc.code", "OK")
+
+		rec, ok := capture.Find(tlogtest.Message("finished call"))
+		require.True(t, ok)
+		assert.Equal(t, slog.LevelWarn, rec.Level)
+		assert.Equal(t, "abc", rec.Attrs[RequestIDKey])
+		assert.Equal(t, "OK", rec.Attrs["grpc.code"])
+	})
+}
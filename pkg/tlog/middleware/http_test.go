@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/byte4cat/nbx/v2/pkg/tlog/tlogtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP(t *testing.T) {
+	capture := tlogtest.NewHandler(nil)
+	mw := HTTP(slog.New(capture), &Options{
+		NewRequestID: func() string { return "generated" },
+		Skip:         func(path string) bool { return path == "/healthz" },
+	})
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("inside", "path", r.URL.Path)
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/boom":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte("hello"))
+		}
+	}))
+
+	serve := func(path, requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if requestID != "" {
+			req.Header.Set(DefaultRequestIDHeader, requestID)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("[SUCCESS] should log the request with a request-scoped logger", func(t *testing.T) {
+		capture.Reset()
+		rec := serve("/hello", "abc")
+		assert.Equal(t, "abc", rec.Header().Get(DefaultRequestIDHeader))
+
+		inside, ok := capture.Find(tlogtest.Message("inside"))
+		require.True(t, ok)
+		assert.Equal(t, "abc", inside.Attrs[RequestIDKey])
+
+		access, ok := capture.Find(tlogtest.Message("http request"))
+		require.True(t, ok)
+		assert.Equal(t, slog.LevelInfo, access.Level)
+		assert.Equal(t, "abc", access.Attrs[RequestIDKey])
+		assert.Equal(t, "GET", access.Attrs["method"])
+		assert.Equal(t, int64(200), access.Attrs["status"])
+		assert.Equal(t, int64(5), access.Attrs["bytes"])
+	})
+
+	t.Run("[SUCCESS] should generate missing request IDs", func(t *testing.T) {
+		capture.Reset()
+		rec := serve("/hello", "")
+		assert.Equal(t, "generated", rec.Header().Get(DefaultRequestIDHeader))
+		assert.Equal(t, 2, capture.Count(tlogtest.HasAttr(RequestIDKey, "generated")))
+	})
+
+	t.Run("[SUCCESS] should map status codes to levels", func(t *testing.T) {
+		capture.Reset()
+		serve("/missing", "")
+		serve("/boom", "")
+
+		assert.Equal(t, 1, capture.Count(tlogtest.Level(slog.LevelWarn), tlogtest.HasAttr("status", 404)))
+		assert.Equal(t, 1, capture.Count(tlogtest.Level(slog.LevelError), tlogtest.HasAttr("status", 500)))
+	})
+
+	t.Run("[SUCCESS] should skip the access log but keep the scoped logger", func(t *testing.T) {
+		capture.Reset()
+		serve("/healthz", "id")
+		assert.Equal(t, 0, capture.Count(tlogtest.Message("http request")))
+		assert.Equal(t, 1, capture.Count(tlogtest.Message("inside"), tlogtest.HasAttr(RequestIDKey, "id")))
+	})
+
+	t.Run("[SUCCESS] should log hijacked connections without status and size", func(t *testing.T) {
+		capture.Reset()
+		srv := httptest.NewServer(mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, buf, err := http.NewResponseController(w).Hijack()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+			_ = buf.Flush()
+		})))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/ws")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		require.Eventually(t, func() bool {
+			return capture.Count(tlogtest.Message("http request")) == 1
+		}, time.Second, 5*time.Millisecond)
+		access, _ := capture.Find(tlogtest.Message("http request"))
+		assert.Equal(t, true, access.Attrs["hijacked"])
+		assert.NotContains(t, access.Attrs, "status")
+		assert.NotContains(t, access.Attrs, "bytes")
+	})
+
+	t.Run("[FAILURE] should not hijack writers without support", func(t *testing.T) {
+		rw := &responseWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+		_, _, err := rw.Hijack()
+		assert.ErrorIs(t, err, http.ErrNotSupported)
+		assert.False(t, rw.hijacked)
+	})
+}
@@ -0,0 +1,96 @@
+// Package middleware logs net/http requests and gRPC calls through a
+// *slog.Logger. Each request gets a logger carrying its request ID, which
+// handlers retrieve with FromContext:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+//		middleware.FromContext(r.Context()).Info("hello")
+//	})
+//	http.ListenAndServe(":8080", middleware.HTTP(logger, nil)(mux))
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+)
+
+// DefaultRequestIDHeader is the HTTP header and, lower-cased, the gRPC
+// metadata key the request ID is read from and written to.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the attribute key of the request ID.
+const RequestIDKey = "request_id"
+
+type Options struct {
+	RequestIDHeader string        // default: DefaultRequestIDHeader
+	NewRequestID    func() string // for requests without an ID, default: 16 random bytes in hex
+
+	HTTPLevel func(status int) slog.Level      // default: DefaultHTTPLevel
+	GRPCLevel func(code codes.Code) slog.Level // default: DefaultGRPCLevel
+
+	// Skip excludes requests from the access log, e.g. health checks. The
+	// request-scoped logger is still set up. gRPC requests are matched by
+	// their full method name, HTTP requests by their path.
+	Skip func(name string) bool
+}
+
+func (o *Options) withDefaults() Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.RequestIDHeader == "" {
+		opts.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if opts.NewRequestID == nil {
+		opts.NewRequestID = newRequestID
+	}
+	if opts.HTTPLevel == nil {
+		opts.HTTPLevel = DefaultHTTPLevel
+	}
+	if opts.GRPCLevel == nil {
+		opts.GRPCLevel = DefaultGRPCLevel
+	}
+	return opts
+}
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the request-scoped logger of ctx, or slog.Default
+// outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// withRequest stores the request ID and a logger carrying it in ctx.
+func withRequest(ctx context.Context, logger *slog.Logger, id string) (context.Context, *slog.Logger) {
+	logger = logger.With(RequestIDKey, id)
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return NewContext(ctx, logger), logger
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,593 @@
+package tlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultOTLPEndpoint is the default OTLP/HTTP logs endpoint of a local collector.
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/logs"
+
+const otlpScopeName = "github.com/byte4cat/nbx/v2/pkg/tlog"
+
+type OTLPOptions struct {
+	Endpoint string            // default: DefaultOTLPEndpoint
+	Headers  map[string]string // extra request headers, e.g. authorization
+	Level    slog.Leveler      // default: Info
+
+	ServiceName   string      // service.name resource attribute, default: base name of os.Args[0]
+	ResourceAttrs []slog.Attr // additional resource attributes
+
+	BatchSize     int           // records per export request, default: 512
+	FlushInterval time.Duration // max time a record waits in the batch, default: 5s
+	MaxQueueSize  int           // records kept while exports are pending, default: 4 * BatchSize
+	MaxRetries    int           // retries on 5xx or 429 responses, default: 3, negative disables retries
+	RetryBackoff  time.Duration // initial backoff, doubled per retry, default: 500ms
+
+	HTTPClient *http.Client // default: client with a 10s timeout
+
+	AddSource   bool
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+
+	// TraceContext extracts the trace context of a record from its context.
+	// Default: TraceContextFromContext.
+	TraceContext func(ctx context.Context) (TraceContext, bool)
+
+	// ErrorHandler is called with export errors, since they happen in the
+	// background. Default: errors are dropped.
+	ErrorHandler func(err error)
+}
+
+// TraceContext identifies the span a log record was emitted in.
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+type traceContextKey struct{}
+
+// ContextWithTraceContext returns a copy of ctx carrying tc, for services that
+// do not use an OpenTelemetry SDK to propagate spans.
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext returns the TraceContext stored by ContextWithTraceContext.
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// OTLPHandler is a slog.Handler that batches records and exports them to an
+// OpenTelemetry collector as OTLP/HTTP JSON. Groups become nested kvlist
+// values, source locations become code.* attributes.
+//
+// Records are exported in the background; call Close to flush the pending
+// batch before the process exits.
+type OTLPHandler struct {
+	exp  *otlpExporter
+	opts *OTLPOptions
+	goa  *groupOrAttrs
+}
+
+// groupOrAttrs is one WithGroup or WithAttrs call, linked to the calls made
+// before it.
+type groupOrAttrs struct {
+	group  string
+	attrs  []slog.Attr
+	groups []string // groups in effect for attrs
+	next   *groupOrAttrs
+}
+
+// NewOTLPHandler creates the handler and starts its background exporter.
+func NewOTLPHandler(opts *OTLPOptions) (*OTLPHandler, error) {
+	var o OTLPOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Endpoint == "" {
+		o.Endpoint = DefaultOTLPEndpoint
+	}
+	if u, err := url.Parse(o.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("tlog: invalid OTLP endpoint %q", o.Endpoint)
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelInfo
+	}
+	if o.ServiceName == "" {
+		o.ServiceName = filepath.Base(os.Args[0])
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 512
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = 5 * time.Second
+	}
+	if o.MaxQueueSize <= 0 {
+		o.MaxQueueSize = 4 * o.BatchSize
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	} else if o.MaxRetries == 0 {
+		o.MaxRetries = 3
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = 500 * time.Millisecond
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if o.TraceContext == nil {
+		o.TraceContext = TraceContextFromContext
+	}
+
+	resource := []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{StringValue: &o.ServiceName}}}
+	for _, a := range o.ResourceAttrs {
+		resource = appendOTLPAttr(resource, nil, a, nil)
+	}
+
+	exp := &otlpExporter{
+		opts:     &o,
+		resource: resource,
+		flushC:   make(chan chan struct{}),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go exp.run()
+
+	return &OTLPHandler{exp: exp, opts: &o}, nil
+}
+
+func (h *OTLPHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.opts.Level.Level()
+}
+
+func (h *OTLPHandler) Handle(ctx context.Context, r slog.Record) error {
+	rec := otlpLogRecord{
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       otlpSeverity(r.Level),
+		SeverityText:         r.Level.String(),
+		Body:                 otlpAnyValue{StringValue: &r.Message},
+		Attributes:           h.attributes(r),
+	}
+	if !r.Time.IsZero() {
+		rec.TimeUnixNano = strconv.FormatInt(r.Time.UnixNano(), 10)
+	}
+	if tc, ok := h.opts.TraceContext(ctx); ok {
+		rec.TraceID = hex.EncodeToString(tc.TraceID[:])
+		rec.SpanID = hex.EncodeToString(tc.SpanID[:])
+		rec.Flags = uint32(tc.Flags)
+	}
+
+	return h.exp.enqueue(rec)
+}
+
+func (h *OTLPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.goa = &groupOrAttrs{attrs: attrs, groups: h.groups(), next: h.goa}
+	return &h2
+}
+
+func (h *OTLPHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.goa = &groupOrAttrs{group: name, next: h.goa}
+	return &h2
+}
+
+// Flush exports all pending records and waits until the export finished or
+// ctx is done.
+func (h *OTLPHandler) Flush(ctx context.Context) error {
+	return h.exp.flush(ctx)
+}
+
+// Close flushes pending records and stops the background exporter.
+// Records handled after Close are dropped with an error.
+func (h *OTLPHandler) Close(ctx context.Context) error {
+	return h.exp.close(ctx)
+}
+
+func (h *OTLPHandler) groups() []string {
+	var groups []string
+	for g := h.goa; g != nil; g = g.next {
+		if g.group != "" {
+			groups = append(groups, g.group)
+		}
+	}
+	for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+		groups[i], groups[j] = groups[j], groups[i]
+	}
+	return groups
+}
+
+// attributes builds the record attributes from the innermost group outwards,
+// so that WithGroup nests everything added after it into a kvlist.
+func (h *OTLPHandler) attributes(r slog.Record) []otlpKeyValue {
+	groups := h.groups()
+
+	var attrs []otlpKeyValue
+	if h.opts.AddSource && r.PC != 0 {
+		src := recordSource(r, h.opts.ReplaceAttr)
+		line := strconv.Itoa(src.Line)
+		attrs = append(attrs,
+			otlpKeyValue{Key: "code.filepath", Value: otlpAnyValue{StringValue: &src.File}},
+			otlpKeyValue{Key: "code.lineno", Value: otlpAnyValue{IntValue: &line}},
+			otlpKeyValue{Key: "code.function", Value: otlpAnyValue{StringValue: &src.Function}},
+		)
+	}
+
+	var inner []otlpKeyValue
+	r.Attrs(func(a slog.Attr) bool {
+		inner = appendOTLPAttr(inner, groups, a, h.opts.ReplaceAttr)
+		return true
+	})
+	for g := h.goa; g != nil; g = g.next {
+		if g.group != "" {
+			if len(inner) > 0 {
+				inner = []otlpKeyValue{{Key: g.group, Value: otlpAnyValue{KvlistValue: &otlpKeyValueList{Values: inner}}}}
+			}
+			continue
+		}
+		var prefix []otlpKeyValue
+		for _, a := range g.attrs {
+			prefix = appendOTLPAttr(prefix, g.groups, a, h.opts.ReplaceAttr)
+		}
+		inner = append(prefix, inner...)
+	}
+
+	return append(attrs, inner...)
+}
+
+// otlpSeverity maps slog levels onto OTLP severity numbers:
+// Debug=5, Info=9, Warn=13, Error=17.
+func otlpSeverity(l slog.Level) int {
+	return min(max(int(l)+9, 1), 24)
+}
+
+// appendOTLPAttr resolves a and appends it to dst; groups become kvlist values.
+func appendOTLPAttr(dst []otlpKeyValue, groups []string, a slog.Attr, rep func([]string, slog.Attr) slog.Attr) []otlpKeyValue {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup && rep != nil {
+		a = rep(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return dst
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return dst
+		}
+		if a.Key == "" {
+			for _, ga := range attrs {
+				dst = appendOTLPAttr(dst, groups, ga, rep)
+			}
+			return dst
+		}
+		var values []otlpKeyValue
+		groups = append(groups[:len(groups):len(groups)], a.Key)
+		for _, ga := range attrs {
+			values = appendOTLPAttr(values, groups, ga, rep)
+		}
+		if len(values) == 0 {
+			return dst
+		}
+		return append(dst, otlpKeyValue{Key: a.Key, Value: otlpAnyValue{KvlistValue: &otlpKeyValueList{Values: values}}})
+	}
+
+	return append(dst, otlpKeyValue{Key: a.Key, Value: otlpValue(a.Value)})
+}
+
+func otlpValue(v slog.Value) otlpAnyValue {
+	switch v.Kind() {
+	case slog.KindString:
+		s := v.String()
+		return otlpAnyValue{StringValue: &s}
+	case slog.KindBool:
+		b := v.Bool()
+		return otlpAnyValue{BoolValue: &b}
+	case slog.KindInt64:
+		s := strconv.FormatInt(v.Int64(), 10)
+		return otlpAnyValue{IntValue: &s}
+	case slog.KindUint64:
+		s := strconv.FormatUint(v.Uint64(), 10)
+		if v.Uint64() > math.MaxInt64 {
+			return otlpAnyValue{StringValue: &s}
+		}
+		return otlpAnyValue{IntValue: &s}
+	case slog.KindFloat64:
+		f := otlpDouble(v.Float64())
+		return otlpAnyValue{DoubleValue: &f}
+	case slog.KindDuration:
+		s := strconv.FormatInt(int64(v.Duration()), 10)
+		return otlpAnyValue{IntValue: &s}
+	case slog.KindTime:
+		s := v.Time().Format(time.RFC3339Nano)
+		return otlpAnyValue{StringValue: &s}
+	}
+
+	switch x := v.Any().(type) {
+	case []byte:
+		return otlpAnyValue{BytesValue: x}
+	case error:
+		s := x.Error()
+		return otlpAnyValue{StringValue: &s}
+	}
+	s := attrValueString(v)
+	return otlpAnyValue{StringValue: &s}
+}
+
+// otlpDouble is a double value, encoded as proto3 JSON does: NaN and the
+// infinities, which encoding/json rejects, become "NaN", "Infinity" and
+// "-Infinity".
+type otlpDouble float64
+
+func (d otlpDouble) MarshalJSON() ([]byte, error) {
+	f := float64(d)
+	switch {
+	case math.IsNaN(f):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(f, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(f, -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return json.Marshal(f)
+}
+
+// OTLP/HTTP JSON payload, see
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/logs/v1/logs.proto
+type (
+	otlpLogsData struct {
+		ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+	}
+	otlpResourceLogs struct {
+		Resource  otlpResource    `json:"resource"`
+		ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpScopeLogs struct {
+		Scope      otlpScope       `json:"scope"`
+		LogRecords []otlpLogRecord `json:"logRecords"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpLogRecord struct {
+		TimeUnixNano         string         `json:"timeUnixNano,omitempty"`
+		ObservedTimeUnixNano string         `json:"observedTimeUnixNano,omitempty"`
+		SeverityNumber       int            `json:"severityNumber"`
+		SeverityText         string         `json:"severityText"`
+		Body                 otlpAnyValue   `json:"body"`
+		Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+		TraceID              string         `json:"traceId,omitempty"`
+		SpanID               string         `json:"spanId,omitempty"`
+		Flags                uint32         `json:"flags,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpKeyValueList struct {
+		Values []otlpKeyValue `json:"values"`
+	}
+	otlpAnyValue struct {
+		StringValue *string           `json:"stringValue,omitempty"`
+		BoolValue   *bool             `json:"boolValue,omitempty"`
+		IntValue    *string           `json:"intValue,omitempty"` // int64 is a string in proto3 JSON
+		DoubleValue *otlpDouble       `json:"doubleValue,omitempty"`
+		BytesValue  []byte            `json:"bytesValue,omitempty"`
+		KvlistValue *otlpKeyValueList `json:"kvlistValue,omitempty"`
+	}
+)
+
+// otlpExporter owns the pending batch and the goroutine that exports it.
+// It is shared by a handler and all of its WithAttrs/WithGroup clones.
+type otlpExporter struct {
+	opts     *OTLPOptions
+	resource []otlpKeyValue
+
+	mu      sync.Mutex
+	pending []otlpLogRecord
+	closed  bool
+	dropped int
+
+	flushC  chan chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+var errOTLPQueueFull = errors.New("tlog: OTLP queue full, record dropped")
+
+func (e *otlpExporter) enqueue(rec otlpLogRecord) error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return errHandlerClosed
+	}
+	if len(e.pending) >= e.opts.MaxQueueSize {
+		e.dropped++
+		e.mu.Unlock()
+		return errOTLPQueueFull
+	}
+	e.pending = append(e.pending, rec)
+	full := len(e.pending) >= e.opts.BatchSize
+	e.mu.Unlock()
+
+	if full {
+		// Wake the exporter without waiting for the export to finish.
+		select {
+		case e.flushC <- nil:
+		default:
+		}
+	}
+	return nil
+}
+
+func (e *otlpExporter) run() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(e.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ack := <-e.flushC:
+			e.exportAll()
+			if ack != nil {
+				close(ack)
+			}
+		case <-ticker.C:
+			e.exportAll()
+		case <-e.done:
+			e.exportAll()
+			return
+		}
+	}
+}
+
+// exportAll sends the pending records in batches of at most BatchSize.
+func (e *otlpExporter) exportAll() {
+	for {
+		e.mu.Lock()
+		n := min(len(e.pending), e.opts.BatchSize)
+		if n == 0 {
+			e.mu.Unlock()
+			return
+		}
+		batch := e.pending[:n:n]
+		e.pending = e.pending[n:]
+		dropped := e.dropped
+		e.dropped = 0
+		e.mu.Unlock()
+
+		if dropped > 0 {
+			e.reportError(fmt.Errorf("tlog: OTLP queue full, dropped %d records", dropped))
+		}
+		if err := e.export(batch); err != nil {
+			e.reportError(err)
+		}
+	}
+}
+
+func (e *otlpExporter) export(batch []otlpLogRecord) error {
+	body, err := json.Marshal(otlpLogsData{ResourceLogs: []otlpResourceLogs{{
+		Resource: otlpResource{Attributes: e.resource},
+		ScopeLogs: []otlpScopeLogs{{
+			Scope:      otlpScope{Name: otlpScopeName},
+			LogRecords: batch,
+		}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("tlog: marshal OTLP logs: %w", err)
+	}
+
+	backoff := e.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := e.post(body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= e.opts.MaxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends one export request and reports whether a failure is retryable.
+func (e *otlpExporter) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, e.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.opts.HTTPClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("tlog: OTLP export: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("tlog: OTLP export: %s", resp.Status)
+	default:
+		return false, fmt.Errorf("tlog: OTLP export: %s", resp.Status)
+	}
+}
+
+func (e *otlpExporter) reportError(err error) {
+	if e.opts.ErrorHandler != nil {
+		e.opts.ErrorHandler(err)
+	}
+}
+
+func (e *otlpExporter) flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case e.flushC <- ack:
+	case <-e.stopped:
+		return errHandlerClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *otlpExporter) close(ctx context.Context) error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return errHandlerClosed
+	}
+	e.closed = true
+	e.mu.Unlock()
+
+	close(e.done)
+	select {
+	case <-e.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
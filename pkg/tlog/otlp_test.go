@@ -0,0 +1,246 @@
+package tlog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// otlpCollector is a stand-in for the OTLP/HTTP logs endpoint of a collector.
+type otlpCollector struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []map[string]any
+	headers  []http.Header
+	statuses []int // responses to send, in order; 200 once exhausted
+}
+
+func newOTLPCollector(t *testing.T, statuses ...int) *otlpCollector {
+	c := &otlpCollector{statuses: statuses}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if len(c.statuses) > 0 {
+			status := c.statuses[0]
+			c.statuses = c.statuses[1:]
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.requests = append(c.requests, payload)
+		c.headers = append(c.headers, r.Header.Clone())
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *otlpCollector) records() []map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []map[string]any
+	for _, req := range c.requests {
+		for _, rl := range req["resourceLogs"].([]any) {
+			for _, sl := range rl.(map[string]any)["scopeLogs"].([]any) {
+				for _, lr := range sl.(map[string]any)["logRecords"].([]any) {
+					out = append(out, lr.(map[string]any))
+				}
+			}
+		}
+	}
+	return out
+}
+
+func TestOTLPHandler(t *testing.T) {
+	t.Run("[SUCCESS] should export records with severity, attributes, groups and trace context", func(t *testing.T) {
+		collector := newOTLPCollector(t)
+
+		h, err := NewOTLPHandler(&OTLPOptions{
+			Endpoint:    collector.URL + "/v1/logs",
+			Headers:     map[string]string{"Authorization": "Bearer token"},
+			ServiceName: "orders",
+			Level:       slog.LevelDebug,
+			AddSource:   true,
+		})
+		require.NoError(t, err)
+
+		tc := TraceContext{Flags: 1}
+		copy(tc.TraceID[:], []byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36})
+		copy(tc.SpanID[:], []byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7})
+		ctx := ContextWithTraceContext(context.Background(), tc)
+
+		logger := slog.New(h).With("tenant", "acme").WithGroup("req")
+		logger.WarnContext(ctx, "slow", "ms", 1500, slog.Group("user", "id", "u1"), "ok", true)
+
+		require.NoError(t, h.Close(context.Background()))
+
+		records := collector.records()
+		require.Len(t, records, 1)
+		rec := records[0]
+
+		assert.EqualValues(t, 13, rec["severityNumber"])
+		assert.Equal(t, "WARN", rec["severityText"])
+		assert.Equal(t, map[string]any{"stringValue": "slow"}, rec["body"])
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", rec["traceId"])
+		assert.Equal(t, "00f067aa0ba902b7", rec["spanId"])
+		assert.EqualValues(t, 1, rec["flags"])
+		assert.NotEmpty(t, rec["timeUnixNano"])
+
+		attrs := rec["attributes"].([]any)
+		keys := make([]string, 0, len(attrs))
+		for _, a := range attrs {
+			keys = append(keys, a.(map[string]any)["key"].(string))
+		}
+		assert.Equal(t, []string{"code.filepath", "code.lineno", "code.function", "tenant", "req"}, keys)
+
+		req := attrs[4].(map[string]any)["value"].(map[string]any)["kvlistValue"].(map[string]any)["values"].([]any)
+		assert.Equal(t, map[string]any{"key": "ms", "value": map[string]any{"intValue": "1500"}}, req[0])
+		assert.Equal(t, map[string]any{"key": "user", "value": map[string]any{"kvlistValue": map[string]any{
+			"values": []any{map[string]any{"key": "id", "value": map[string]any{"stringValue": "u1"}}},
+		}}}, req[1])
+		assert.Equal(t, map[string]any{"key": "ok", "value": map[string]any{"boolValue": true}}, req[2])
+
+		collector.mu.Lock()
+		assert.Equal(t, "Bearer token", collector.headers[0].Get("Authorization"))
+		assert.Equal(t, "application/json", collector.headers[0].Get("Content-Type"))
+		resource := collector.requests[0]["resourceLogs"].([]any)[0].(map[string]any)["resource"].(map[string]any)
+		collector.mu.Unlock()
+		assert.Equal(t, []any{map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "orders"}}}, resource["attributes"])
+	})
+
+	t.Run("[SUCCESS] should split exports by batch size", func(t *testing.T) {
+		collector := newOTLPCollector(t)
+
+		h, err := NewOTLPHandler(&OTLPOptions{Endpoint: collector.URL, BatchSize: 2, FlushInterval: time.Hour})
+		require.NoError(t, err)
+
+		logger := slog.New(h)
+		for range 5 {
+			logger.Info("msg")
+		}
+		require.NoError(t, h.Flush(context.Background()))
+		require.NoError(t, h.Close(context.Background()))
+
+		assert.Len(t, collector.records(), 5)
+		collector.mu.Lock()
+		assert.Len(t, collector.requests, 3)
+		collector.mu.Unlock()
+	})
+
+	t.Run("[SUCCESS] should export non-finite doubles as proto3 JSON strings", func(t *testing.T) {
+		collector := newOTLPCollector(t)
+
+		h, err := NewOTLPHandler(&OTLPOptions{Endpoint: collector.URL, FlushInterval: time.Hour})
+		require.NoError(t, err)
+
+		logger := slog.New(h)
+		logger.Info("ok one")
+		logger.Info("ratio", "nan", math.NaN(), "inf", math.Inf(1), "-inf", math.Inf(-1), "r", 0.5)
+		logger.Info("ok two")
+		require.NoError(t, h.Close(context.Background()))
+
+		records := collector.records()
+		require.Len(t, records, 3)
+		assert.Equal(t, []any{
+			map[string]any{"key": "nan", "value": map[string]any{"doubleValue": "NaN"}},
+			map[string]any{"key": "inf", "value": map[string]any{"doubleValue": "Infinity"}},
+			map[string]any{"key": "-inf", "value": map[string]any{"doubleValue": "-Infinity"}},
+			map[string]any{"key": "r", "value": map[string]any{"doubleValue": 0.5}},
+		}, records[1]["attributes"])
+	})
+
+	t.Run("[SUCCESS] should flush on interval", func(t *testing.T) {
+		collector := newOTLPCollector(t)
+
+		h, err := NewOTLPHandler(&OTLPOptions{Endpoint: collector.URL, FlushInterval: 20 * time.Millisecond})
+		require.NoError(t, err)
+		defer h.Close(context.Background())
+
+		slog.New(h).Info("tick")
+
+		assert.Eventually(t, func() bool { return len(collector.records()) == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("[SUCCESS] should retry on 5xx responses", func(t *testing.T) {
+		collector := newOTLPCollector(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+		var errs atomic.Int32
+		h, err := NewOTLPHandler(&OTLPOptions{
+			Endpoint:     collector.URL,
+			RetryBackoff: time.Millisecond,
+			ErrorHandler: func(error) { errs.Add(1) },
+		})
+		require.NoError(t, err)
+
+		slog.New(h).Error("retried")
+		require.NoError(t, h.Close(context.Background()))
+
+		assert.Len(t, collector.records(), 1)
+		assert.Zero(t, errs.Load())
+	})
+
+	t.Run("[FAILURE] should not retry on 4xx responses and report the error", func(t *testing.T) {
+		collector := newOTLPCollector(t, http.StatusBadRequest)
+
+		var reported []error
+		h, err := NewOTLPHandler(&OTLPOptions{
+			Endpoint:     collector.URL,
+			RetryBackoff: time.Millisecond,
+			ErrorHandler: func(err error) { reported = append(reported, err) },
+		})
+		require.NoError(t, err)
+
+		slog.New(h).Error("rejected")
+		require.NoError(t, h.Close(context.Background()))
+
+		assert.Empty(t, collector.records())
+		require.Len(t, reported, 1)
+		assert.Contains(t, reported[0].Error(), "400")
+	})
+
+	t.Run("[FAILURE] should reject records after close", func(t *testing.T) {
+		collector := newOTLPCollector(t)
+
+		h, err := NewOTLPHandler(&OTLPOptions{Endpoint: collector.URL})
+		require.NoError(t, err)
+		require.NoError(t, h.Close(context.Background()))
+
+		err = h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "late", 0))
+		assert.ErrorIs(t, err, errHandlerClosed)
+	})
+
+	t.Run("[FAILURE] should reject an invalid endpoint", func(t *testing.T) {
+		_, err := NewOTLPHandler(&OTLPOptions{Endpoint: "localhost"})
+		assert.Error(t, err)
+	})
+}
+
+func Test_otlpSeverity(t *testing.T) {
+	t.Run("[SUCCESS] should map slog levels to OTLP severity numbers", func(t *testing.T) {
+		assert.Equal(t, 5, otlpSeverity(slog.LevelDebug))
+		assert.Equal(t, 9, otlpSeverity(slog.LevelInfo))
+		assert.Equal(t, 13, otlpSeverity(slog.LevelWarn))
+		assert.Equal(t, 17, otlpSeverity(slog.LevelError))
+		assert.Equal(t, 1, otlpSeverity(slog.LevelDebug-10))
+		assert.Equal(t, 24, otlpSeverity(slog.LevelError+20))
+	})
+}
@@ -0,0 +1,360 @@
+package tlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// FileConfig is the on-disk form of Config read by NewReloadable, as YAML
+// (.yaml, .yml) or JSON (any other extension). Levels use the slog level
+// names ("debug", "info", "warn", "error", optionally with an offset such as
+// "info+2").
+//
+//	stderrLevel: debug
+//	fileLevel: warn
+//	logFilePath: /var/log/app/service.log
+//	noColor: true
+//	forceJSON: true
+type FileConfig struct {
+	StderrLevel string `json:"stderrLevel" yaml:"stderrLevel"`
+	FileLevel   string `json:"fileLevel" yaml:"fileLevel"`
+	LogFilePath string `json:"logFilePath" yaml:"logFilePath"`
+	NoColor     bool   `json:"noColor" yaml:"noColor"`
+	TimeFormat  string `json:"timeFormat" yaml:"timeFormat"`
+	ForceText   bool   `json:"forceText" yaml:"forceText"`
+	ForceJSON   bool   `json:"forceJSON" yaml:"forceJSON"`
+}
+
+type reloadOptions struct {
+	pollInterval time.Duration
+	sighup       bool
+	onError      func(error)
+}
+
+// ReloadOption configures NewReloadable.
+type ReloadOption func(*reloadOptions)
+
+// WithPollInterval sets how often the config file is checked for changes,
+// default: 1s. A non-positive interval disables file watching.
+func WithPollInterval(d time.Duration) ReloadOption {
+	return func(o *reloadOptions) { o.pollInterval = d }
+}
+
+// WithSIGHUP also reloads the config file when the process receives SIGHUP.
+func WithSIGHUP() ReloadOption {
+	return func(o *reloadOptions) { o.sighup = true }
+}
+
+// WithReloadErrorHandler sets the function called when a reload fails.
+// Default: the error is logged through the current logger.
+func WithReloadErrorHandler(fn func(error)) ReloadOption {
+	return func(o *reloadOptions) { o.onError = fn }
+}
+
+// Reloadable is a logger whose configuration follows a config file.
+//
+// The *slog.Logger returned by Logger, and every logger derived from it with
+// With or WithGroup, stays valid across reloads: level changes take effect
+// immediately and format, colour or file changes swap the handler tree
+// underneath it. Records being handled during a swap complete on the old
+// tree before its log file is closed.
+type Reloadable struct {
+	path string
+	opts reloadOptions
+
+	stderrLevel slog.LevelVar
+	fileLevel   slog.LevelVar
+
+	mu      sync.RWMutex
+	cfg     FileConfig
+	handler slog.Handler
+	release func() error
+	gen     uint64
+
+	logger    *slog.Logger
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewReloadable reads the config file at path, builds a logger from it and
+// starts watching the file for changes.
+func NewReloadable(path string, opts ...ReloadOption) (*Reloadable, error) {
+	o := reloadOptions{pollInterval: time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r := &Reloadable{
+		path: path,
+		opts: o,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	// stat before reading, so a change made while starting up is not missed
+	last, _ := os.Stat(path)
+
+	fc, err := readFileConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.apply(fc); err != nil {
+		return nil, err
+	}
+	r.logger = slog.New(&reloadHandler{r: r})
+
+	go r.watch(last)
+
+	return r, nil
+}
+
+// Logger returns the logger backed by the current configuration.
+func (r *Reloadable) Logger() *slog.Logger {
+	return r.logger
+}
+
+// Config returns the configuration currently in effect.
+func (r *Reloadable) Config() FileConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cfg
+}
+
+// Reload re-reads the config file and applies it. On error the previous
+// configuration stays in effect.
+func (r *Reloadable) Reload() error {
+	fc, err := readFileConfig(r.path)
+	if err != nil {
+		return err
+	}
+	return r.apply(fc)
+}
+
+// Close stops watching the config file and releases the log file.
+func (r *Reloadable) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.release != nil {
+			err = r.release()
+		}
+	})
+	return err
+}
+
+// apply installs fc. Level-only changes update the level vars in place;
+// anything else rebuilds the handler tree.
+func (r *Reloadable) apply(fc FileConfig) error {
+	stderrLevel, err := parseLevel(fc.StderrLevel, slog.LevelInfo)
+	if err != nil {
+		return fmt.Errorf("tlog: stderrLevel: %w", err)
+	}
+	fileLevel, err := parseLevel(fc.FileLevel, slog.LevelError)
+	if err != nil {
+		return fmt.Errorf("tlog: fileLevel: %w", err)
+	}
+
+	r.mu.Lock()
+	r.stderrLevel.Set(stderrLevel)
+	r.fileLevel.Set(fileLevel)
+
+	levelsOnly := r.handler != nil &&
+		fc.LogFilePath == r.cfg.LogFilePath && fc.NoColor == r.cfg.NoColor &&
+		fc.TimeFormat == r.cfg.TimeFormat && fc.ForceText == r.cfg.ForceText &&
+		fc.ForceJSON == r.cfg.ForceJSON
+	r.cfg = fc
+	if levelsOnly {
+		r.mu.Unlock()
+		return nil
+	}
+
+	h, release := newHandler(&Config{
+		StderrLevel: &r.stderrLevel,
+		FileLevel:   &r.fileLevel,
+		LogFilePath: fc.LogFilePath,
+		NoColor:     fc.NoColor,
+		TimeFormat:  fc.TimeFormat,
+		ForceText:   fc.ForceText,
+		ForceJSON:   fc.ForceJSON,
+	})
+	oldRelease := r.release
+	r.handler, r.release = h, release
+	r.gen++
+	r.mu.Unlock()
+
+	// No record can still be in the old tree once the write lock was taken.
+	if oldRelease != nil {
+		return oldRelease()
+	}
+	return nil
+}
+
+func (r *Reloadable) watch(last os.FileInfo) {
+	defer close(r.done)
+
+	var tick <-chan time.Time
+	if r.opts.pollInterval > 0 {
+		ticker := time.NewTicker(r.opts.pollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var hup chan os.Signal
+	if r.opts.sighup {
+		hup = make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+	}
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-hup:
+			r.reportError(r.Reload())
+		case <-tick:
+			fi, err := os.Stat(r.path)
+			if err != nil {
+				r.reportError(fmt.Errorf("tlog: stat config: %w", err))
+				continue
+			}
+			if last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+				continue
+			}
+			last = fi
+			r.reportError(r.Reload())
+		}
+	}
+}
+
+func (r *Reloadable) reportError(err error) {
+	if err == nil {
+		return
+	}
+	if r.opts.onError != nil {
+		r.opts.onError(err)
+		return
+	}
+	r.logger.Error("tlog: reload config failed", "path", r.path, "error", err)
+}
+
+// current returns the handler tree in effect and its generation.
+// r.mu must be held.
+func (r *Reloadable) current() (slog.Handler, uint64) {
+	return r.handler, r.gen
+}
+
+func readFileConfig(path string) (FileConfig, error) {
+	var fc FileConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fc, fmt.Errorf("tlog: read config: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fc)
+	default:
+		err = json.Unmarshal(data, &fc)
+	}
+	if err != nil {
+		return fc, fmt.Errorf("tlog: parse config %s: %w", path, err)
+	}
+	return fc, nil
+}
+
+func parseLevel(s string, def slog.Level) (slog.Level, error) {
+	if s == "" {
+		return def, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return def, err
+	}
+	return l, nil
+}
+
+// reloadHandler is the handler behind Reloadable.Logger. It replays its
+// WithAttrs/WithGroup calls onto the current handler tree, caching the
+// result until the next reload.
+type reloadHandler struct {
+	r     *Reloadable
+	goa   *groupOrAttrs
+	cache atomic.Pointer[reloadCache]
+}
+
+type reloadCache struct {
+	gen     uint64
+	handler slog.Handler
+}
+
+func (h *reloadHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	h.r.mu.RLock()
+	defer h.r.mu.RUnlock()
+	return h.resolve().Enabled(ctx, l)
+}
+
+func (h *reloadHandler) Handle(ctx context.Context, rec slog.Record) error {
+	h.r.mu.RLock()
+	defer h.r.mu.RUnlock()
+	return h.resolve().Handle(ctx, rec)
+}
+
+func (h *reloadHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &reloadHandler{r: h.r, goa: &groupOrAttrs{attrs: attrs, next: h.goa}}
+}
+
+func (h *reloadHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &reloadHandler{r: h.r, goa: &groupOrAttrs{group: name, next: h.goa}}
+}
+
+// resolve returns the current handler tree with h's attrs and groups
+// applied. h.r.mu must be held for reading.
+func (h *reloadHandler) resolve() slog.Handler {
+	root, gen := h.r.current()
+	if h.goa == nil {
+		return root
+	}
+	if c := h.cache.Load(); c != nil && c.gen == gen {
+		return c.handler
+	}
+
+	var chain []*groupOrAttrs
+	for g := h.goa; g != nil; g = g.next {
+		chain = append(chain, g)
+	}
+	handler := root
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].group != "" {
+			handler = handler.WithGroup(chain[i].group)
+		} else {
+			handler = handler.WithAttrs(chain[i].attrs)
+		}
+	}
+	h.cache.Store(&reloadCache{gen: gen, handler: handler})
+	return handler
+}
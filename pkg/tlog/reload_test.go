@@ -0,0 +1,145 @@
+package tlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadable(t *testing.T) {
+	writes := 0
+	writeConfig := func(t *testing.T, path, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		// make sure the poller sees a new mtime even on coarse file systems
+		writes++
+		future := time.Now().Add(time.Duration(writes) * time.Second)
+		require.NoError(t, os.Chtimes(path, future, future))
+	}
+
+	readLog := func(t *testing.T, path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return ""
+		}
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("[SUCCESS] should swap levels and log file without recreating the logger", func(t *testing.T) {
+		dir := t.TempDir()
+		cfgPath := filepath.Join(dir, "log.yaml")
+		first := filepath.Join(dir, "first.log")
+		second := filepath.Join(dir, "second.log")
+
+		writeConfig(t, cfgPath, "stderrLevel: error+8\nfileLevel: error\nlogFilePath: "+first+"\n")
+
+		r, err := NewReloadable(cfgPath, WithPollInterval(10*time.Millisecond))
+		require.NoError(t, err)
+		defer r.Close()
+
+		logger := r.Logger().With("svc", "api")
+		logger.Warn("dropped warn")
+		logger.Error("first error")
+
+		writeConfig(t, cfgPath, "stderrLevel: error+8\nfileLevel: warn\nlogFilePath: "+second+"\n")
+		require.Eventually(t, func() bool { return r.Config().LogFilePath == second }, 2*time.Second, 10*time.Millisecond)
+
+		logger.Warn("second warn")
+
+		firstLog := readLog(t, first)
+		assert.Contains(t, firstLog, `"msg":"first error"`)
+		assert.Contains(t, firstLog, `"svc":"api"`)
+		assert.NotContains(t, firstLog, "dropped warn")
+		assert.NotContains(t, firstLog, "second warn")
+
+		secondLog := readLog(t, second)
+		assert.Contains(t, secondLog, `"msg":"second warn"`)
+		assert.Contains(t, secondLog, `"svc":"api"`)
+	})
+
+	t.Run("[SUCCESS] should apply level-only changes from json on Reload", func(t *testing.T) {
+		dir := t.TempDir()
+		cfgPath := filepath.Join(dir, "log.json")
+		logPath := filepath.Join(dir, "app.log")
+
+		writeConfig(t, cfgPath, `{"stderrLevel":"error+8","fileLevel":"error","logFilePath":"`+logPath+`"}`)
+
+		r, err := NewReloadable(cfgPath, WithPollInterval(0))
+		require.NoError(t, err)
+		defer r.Close()
+
+		logger := r.Logger().WithGroup("g")
+		logger.Info("before")
+
+		writeConfig(t, cfgPath, `{"stderrLevel":"error+8","fileLevel":"info","logFilePath":"`+logPath+`"}`)
+		require.NoError(t, r.Reload())
+		logger.Info("after", "k", "v")
+
+		log := readLog(t, logPath)
+		assert.NotContains(t, log, "before")
+		assert.Contains(t, log, `"msg":"after"`)
+		assert.Contains(t, log, `"g":{"k":"v"}`)
+	})
+
+	t.Run("[SUCCESS] should not drop records while reloading concurrently", func(t *testing.T) {
+		dir := t.TempDir()
+		cfgPath := filepath.Join(dir, "log.yaml")
+		logPath := filepath.Join(dir, "app.log")
+
+		writeConfig(t, cfgPath, "stderrLevel: error+8\nfileLevel: info\nlogFilePath: "+logPath+"\n")
+
+		r, err := NewReloadable(cfgPath, WithPollInterval(0))
+		require.NoError(t, err)
+		defer r.Close()
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					r.Logger().Info("record")
+				}
+			}()
+		}
+		for i := range 20 {
+			format := "forceJSON: true"
+			if i%2 == 0 {
+				format = "forceText: true\nnoColor: true"
+			}
+			writeConfig(t, cfgPath, "stderrLevel: error+8\nfileLevel: info\nlogFilePath: "+logPath+"\n"+format+"\n")
+			require.NoError(t, r.Reload())
+		}
+		wg.Wait()
+
+		assert.Equal(t, 400, strings.Count(readLog(t, logPath), `"msg":"record"`))
+	})
+
+	t.Run("[FAILURE] should keep the previous config when the file is invalid", func(t *testing.T) {
+		dir := t.TempDir()
+		cfgPath := filepath.Join(dir, "log.yaml")
+
+		writeConfig(t, cfgPath, "stderrLevel: error+8\nfileLevel: warn\n")
+
+		r, err := NewReloadable(cfgPath, WithPollInterval(0))
+		require.NoError(t, err)
+		defer r.Close()
+
+		writeConfig(t, cfgPath, "stderrLevel: loud\n")
+		assert.Error(t, r.Reload())
+		assert.Equal(t, "warn", r.Config().FileLevel)
+	})
+
+	t.Run("[FAILURE] should fail when the config file is missing", func(t *testing.T) {
+		_, err := NewReloadable(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+}
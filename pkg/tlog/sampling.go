@@ -0,0 +1,274 @@
+package tlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket: Burst records may be logged at once, after
+// which the bucket refills at Rate records per second.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type SamplingOptions struct {
+	// Key returns the key records are counted under.
+	// Default: the level and the message of the record.
+	Key func(ctx context.Context, r slog.Record) string
+
+	// Limits is the token bucket of each key, by level. A record uses the
+	// limit of the highest level at or below its own; records below all
+	// configured levels are not rate-limited.
+	Limits map[slog.Level]RateLimit
+
+	// First records of each key are logged per Tick, then every Thereafter-th.
+	// Zero First disables sampling, zero Thereafter drops the rest.
+	First      int
+	Thereafter int
+	Tick       time.Duration // default: 1s
+
+	// SummaryInterval is how often a summary record with the number of
+	// suppressed records of each key is logged. Negative disables summaries.
+	// Default: 1m.
+	SummaryInterval time.Duration
+	SummaryLevel    slog.Level // default: Info (zero value)
+}
+
+// SamplingHandler drops repeated records before they reach the next handler,
+// which may be a MultiHandler. Call Close to stop the summary goroutine and
+// log the last summary.
+type SamplingHandler struct {
+	next  slog.Handler
+	state *samplingState
+}
+
+// samplingState is shared by a SamplingHandler and its WithAttrs/WithGroup
+// clones, so a key is counted the same way wherever it is logged from.
+type samplingState struct {
+	opts   SamplingOptions
+	levels []slog.Level // configured limit levels, ascending
+	root   slog.Handler // receives summaries without the clones' attrs
+
+	mu     sync.Mutex
+	keys   map[string]*samplingKey
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+type samplingKey struct {
+	level   slog.Level
+	message string
+
+	tickStart time.Time
+	tickCount int
+
+	tokens     float64
+	lastRefill time.Time
+	lastSeen   time.Time
+
+	suppressed uint64
+}
+
+// NewSamplingHandler returns a handler passing the records allowed by opts
+// to next.
+func NewSamplingHandler(next slog.Handler, opts *SamplingOptions) *SamplingHandler {
+	var o SamplingOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Key == nil {
+		o.Key = func(_ context.Context, r slog.Record) string {
+			return r.Level.String() + "\x00" + r.Message
+		}
+	}
+	if o.Tick <= 0 {
+		o.Tick = time.Second
+	}
+	if o.SummaryInterval == 0 {
+		o.SummaryInterval = time.Minute
+	}
+
+	st := &samplingState{
+		opts: o,
+		root: next,
+		keys: map[string]*samplingKey{},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for l := range o.Limits {
+		st.levels = append(st.levels, l)
+	}
+	slices.Sort(st.levels)
+
+	go st.summarize()
+
+	return &SamplingHandler{next: next, state: st}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.state.allow(h.state.opts.Key(ctx, r), r, time.Now()) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{next: h.next.WithAttrs(attrs), state: h.state}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{next: h.next.WithGroup(name), state: h.state}
+}
+
+// Close stops the summary goroutine and logs the summary of the records
+// suppressed since the last one.
+func (h *SamplingHandler) Close(ctx context.Context) error {
+	st := h.state
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+		return errHandlerClosed
+	}
+	st.closed = true
+	close(st.stop)
+	st.mu.Unlock()
+
+	select {
+	case <-st.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return st.flushSummary(ctx)
+}
+
+// allow reports whether r passes sampling and the rate limit of key, and
+// counts it as suppressed otherwise.
+func (st *samplingState) allow(key string, r slog.Record, now time.Time) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	k, ok := st.keys[key]
+	if !ok {
+		k = &samplingKey{level: r.Level, message: r.Message, tickStart: now, lastRefill: now}
+		if limit, ok := st.limit(r.Level); ok {
+			k.tokens = float64(limit.Burst)
+		}
+		st.keys[key] = k
+	}
+	k.lastSeen = now
+
+	if !st.sample(k, now) || !st.take(k, r.Level, now) {
+		k.suppressed++
+		return false
+	}
+	return true
+}
+
+// sample applies the First/Thereafter policy within the current tick.
+func (st *samplingState) sample(k *samplingKey, now time.Time) bool {
+	if st.opts.First <= 0 {
+		return true
+	}
+	if now.Sub(k.tickStart) >= st.opts.Tick {
+		k.tickStart = now
+		k.tickCount = 0
+	}
+	k.tickCount++
+	if k.tickCount <= st.opts.First {
+		return true
+	}
+	return st.opts.Thereafter > 0 && (k.tickCount-st.opts.First)%st.opts.Thereafter == 0
+}
+
+// take removes a token from the bucket of k, refilled for the time since
+// the last call.
+func (st *samplingState) take(k *samplingKey, l slog.Level, now time.Time) bool {
+	limit, ok := st.limit(l)
+	if !ok {
+		return true
+	}
+	k.tokens += now.Sub(k.lastRefill).Seconds() * limit.Rate
+	k.tokens = min(k.tokens, float64(limit.Burst))
+	k.lastRefill = now
+	if k.tokens < 1 {
+		return false
+	}
+	k.tokens--
+	return true
+}
+
+func (st *samplingState) limit(l slog.Level) (RateLimit, bool) {
+	for i := len(st.levels) - 1; i >= 0; i-- {
+		if st.levels[i] <= l {
+			return st.opts.Limits[st.levels[i]], true
+		}
+	}
+	return RateLimit{}, false
+}
+
+func (st *samplingState) summarize() {
+	defer close(st.done)
+	// without summaries the ticker still forgets idle keys
+	interval := st.opts.SummaryInterval
+	if interval < 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = st.flushSummary(context.Background())
+		case <-st.stop:
+			return
+		}
+	}
+}
+
+// flushSummary logs one record per key with suppressed records and resets
+// the counts. Keys idle for longer than a minute and a whole interval are
+// forgotten, so the key map does not grow with every message ever logged.
+func (st *samplingState) flushSummary(ctx context.Context) error {
+	now := time.Now()
+	idle := max(st.opts.SummaryInterval, st.opts.Tick, time.Minute)
+
+	var records []slog.Record
+	st.mu.Lock()
+	for key, k := range st.keys {
+		if k.suppressed > 0 && st.opts.SummaryInterval > 0 {
+			r := slog.NewRecord(now, st.opts.SummaryLevel, "tlog: suppressed log records", 0)
+			r.AddAttrs(slog.Group("suppressed",
+				slog.String("message", k.message),
+				slog.String("level", k.level.String()),
+				slog.Uint64("count", k.suppressed),
+			))
+			records = append(records, r)
+		}
+		k.suppressed = 0
+		if now.Sub(k.lastSeen) > idle {
+			delete(st.keys, key)
+		}
+	}
+	st.mu.Unlock()
+
+	if !st.root.Enabled(ctx, st.opts.SummaryLevel) {
+		return nil
+	}
+	var errs []error
+	for _, r := range records {
+		if err := st.root.Handle(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package tlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/byte4cat/nbx/v2/pkg/tlog/tlogtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	t.Run("[SUCCESS] should log the first N then every Mth record per key", func(t *testing.T) {
+		capture := tlogtest.NewHandler(nil)
+		h := NewSamplingHandler(capture, &SamplingOptions{First: 2, Thereafter: 3, Tick: time.Hour, SummaryInterval: -1})
+		defer h.Close(context.Background())
+		logger := slog.New(h)
+
+		for i := range 10 {
+			logger.Info("flood", "i", i)
+		}
+		logger.Info("other")
+
+		assert.Equal(t, 4, capture.Count(tlogtest.Message("flood"))) // 1, 2, 5, 8
+		rec, ok := capture.Find(tlogtest.Message("flood"), tlogtest.HasAttr("i", 4))
+		assert.True(t, ok, rec)
+		assert.Equal(t, 1, capture.Count(tlogtest.Message("other")))
+	})
+
+	t.Run("[SUCCESS] should rate-limit with a token bucket per level", func(t *testing.T) {
+		capture := tlogtest.NewHandler(nil)
+		h := NewSamplingHandler(capture, &SamplingOptions{
+			Limits: map[slog.Level]RateLimit{
+				slog.LevelDebug: {Rate: 0.001, Burst: 1},
+				slog.LevelWarn:  {Rate: 0.001, Burst: 3},
+			},
+			SummaryInterval: -1,
+		})
+		defer h.Close(context.Background())
+		logger := slog.New(h)
+
+		for range 5 {
+			logger.Info("info")
+			logger.Error("error") // uses the Warn bucket
+		}
+
+		assert.Equal(t, 1, capture.Count(tlogtest.Message("info")))
+		assert.Equal(t, 3, capture.Count(tlogtest.Message("error")))
+	})
+
+	t.Run("[SUCCESS] should refill the bucket over time", func(t *testing.T) {
+		capture := tlogtest.NewHandler(nil)
+		h := NewSamplingHandler(capture, &SamplingOptions{
+			Limits:          map[slog.Level]RateLimit{slog.LevelInfo: {Rate: 50, Burst: 1}},
+			SummaryInterval: -1,
+		})
+		defer h.Close(context.Background())
+		logger := slog.New(h)
+
+		logger.Info("tick")
+		logger.Info("tick")
+		time.Sleep(40 * time.Millisecond)
+		logger.Info("tick")
+
+		assert.Equal(t, 2, capture.Count(tlogtest.Message("tick")))
+	})
+
+	t.Run("[SUCCESS] should count keys across clones and log summaries", func(t *testing.T) {
+		capture := tlogtest.NewHandler(nil)
+		h := NewSamplingHandler(capture, &SamplingOptions{
+			First:           1,
+			Tick:            time.Hour,
+			SummaryInterval: 20 * time.Millisecond,
+			SummaryLevel:    slog.LevelWarn,
+		})
+		defer h.Close(context.Background())
+
+		slog.New(h).Info("flood")
+		slog.New(h).With("a", 1).WithGroup("g").Info("flood")
+		slog.New(h).Info("flood")
+
+		require.Eventually(t, func() bool {
+			return capture.Count(tlogtest.HasKey("suppressed")) == 1
+		}, time.Second, 5*time.Millisecond)
+
+		rec, _ := capture.Find(tlogtest.HasKey("suppressed"))
+		assert.Equal(t, slog.LevelWarn, rec.Level)
+		assert.Equal(t, map[string]any{
+			"suppressed": map[string]any{"message": "flood", "level": "INFO", "count": uint64(2)},
+		}, rec.Attrs)
+	})
+
+	t.Run("[SUCCESS] should log the last summary on Close", func(t *testing.T) {
+		capture := tlogtest.NewHandler(nil)
+		h := NewSamplingHandler(capture, &SamplingOptions{First: 1, Tick: time.Hour})
+
+		logger := slog.New(h)
+		logger.Info("flood")
+		logger.Info("flood")
+
+		require.NoError(t, h.Close(context.Background()))
+		assert.Equal(t, 1, capture.Count(tlogtest.HasAttr("suppressed.count", 1)))
+		assert.ErrorIs(t, h.Close(context.Background()), errHandlerClosed)
+	})
+
+	t.Run("[SUCCESS] should sample before a MultiHandler from tlog.New", func(t *testing.T) {
+		var buf bytes.Buffer
+		h, release := newHandlerTo(&Config{
+			ForceJSON: true,
+			Dispatch:  &MultiHandlerOptions{Mode: DispatchAsync},
+			Sampling:  &SamplingOptions{First: 1, Tick: time.Hour},
+		}, &syncWriter{w: &buf}, false)
+
+		logger := slog.New(h)
+		for range 3 {
+			logger.Info("flood")
+		}
+		require.NoError(t, release())
+
+		out := string(syncRead(&buf))
+		assert.Contains(t, out, `"msg":"flood"`)
+		assert.Contains(t, out, `"suppressed":{"message":"flood","level":"INFO","count":2}`)
+	})
+}
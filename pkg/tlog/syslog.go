@@ -0,0 +1,331 @@
+package tlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Facility is a syslog facility code as defined in RFC 5424.
+type Facility int
+
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// DefaultSyslogSDID is the structured data ID used for record attributes
+// when SyslogOptions.SDID is empty. 32473 is the enterprise number reserved
+// by IANA for documentation and private use.
+const DefaultSyslogSDID = "slog@32473"
+
+// syslogSockets are the well-known local syslog sockets, tried in order when
+// no address is configured.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+type SyslogOptions struct {
+	// Network is one of "unixgram", "unix", "udp" or "tcp".
+	// Stream networks ("unix", "tcp") use RFC 6587 octet-counting framing.
+	// Defaults to "unixgram" on the local syslog socket.
+	Network string
+	// Addr is the socket path or host:port of the syslog daemon.
+	// Defaults to the first local socket found for "unixgram"/"unix".
+	Addr string
+
+	Level    slog.Leveler // default: Info
+	Facility Facility     // default: FacilityUser, FacilityKern is reserved for the kernel
+	AppName  string       // default: base name of os.Args[0]
+	Hostname string       // default: os.Hostname()
+	SDID     string       // structured data ID for attributes, default: DefaultSyslogSDID
+
+	AddSource   bool
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+
+	// DialTimeout bounds connecting and writing, default: 5s.
+	DialTimeout time.Duration
+}
+
+// SyslogHandler is a slog.Handler that writes RFC 5424 messages to a syslog
+// daemon. Record attributes are sent as SD-PARAMs of a single SD-ELEMENT,
+// with group names joined by ".".
+type SyslogHandler struct {
+	opts   SyslogOptions
+	conn   *sinkConn
+	procID string
+	groups []string
+	attrs  []flatAttr
+}
+
+// NewSyslogHandler connects to the syslog daemon described by opts.
+func NewSyslogHandler(opts *SyslogOptions) (*SyslogHandler, error) {
+	var o SyslogOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelInfo
+	}
+	if o.Facility == 0 {
+		o.Facility = FacilityUser
+	}
+	if o.AppName == "" {
+		o.AppName = filepath.Base(os.Args[0])
+	}
+	if o.Hostname == "" {
+		o.Hostname, _ = os.Hostname()
+	}
+	if o.SDID == "" {
+		o.SDID = DefaultSyslogSDID
+	}
+	if o.DialTimeout == 0 {
+		o.DialTimeout = 5 * time.Second
+	}
+
+	var (
+		conn *sinkConn
+		err  error
+	)
+	switch o.Network {
+	case "", "unixgram", "unix":
+		if o.Network == "" {
+			o.Network = "unixgram"
+		}
+		framed := o.Network == "unix"
+		if o.Addr != "" {
+			conn, err = dialSink(o.Network, o.Addr, framed, o.DialTimeout)
+			break
+		}
+		err = errors.New("tlog: no local syslog socket found")
+		for _, path := range syslogSockets {
+			if conn, err = dialSink(o.Network, path, framed, o.DialTimeout); err == nil {
+				o.Addr = path
+				break
+			}
+		}
+	case "udp", "udp4", "udp6":
+		conn, err = dialSink(o.Network, o.Addr, false, o.DialTimeout)
+	case "tcp", "tcp4", "tcp6":
+		conn, err = dialSink(o.Network, o.Addr, true, o.DialTimeout)
+	default:
+		return nil, fmt.Errorf("tlog: unsupported syslog network %q", o.Network)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tlog: dial syslog %s %s: %w", o.Network, o.Addr, err)
+	}
+
+	return &SyslogHandler{
+		opts:   o,
+		conn:   conn,
+		procID: strconv.Itoa(os.Getpid()),
+	}, nil
+}
+
+func (h *SyslogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.opts.Level.Level()
+}
+
+func (h *SyslogHandler) Handle(_ context.Context, r slog.Record) error {
+	return h.conn.write(h.format(r))
+}
+
+func (h *SyslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := h.clone()
+	h2.attrs = h.appendAttrs(h2.attrs, attrs...)
+	return h2
+}
+
+func (h *SyslogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	return h2
+}
+
+// Close closes the connection to the syslog daemon.
+func (h *SyslogHandler) Close() error {
+	return h.conn.Close()
+}
+
+func (h *SyslogHandler) clone() *SyslogHandler {
+	h2 := *h
+	h2.groups = h.groups[:len(h.groups):len(h.groups)]
+	h2.attrs = h.attrs[:len(h.attrs):len(h.attrs)]
+	return &h2
+}
+
+func (h *SyslogHandler) appendAttrs(dst []flatAttr, attrs ...slog.Attr) []flatAttr {
+	for _, a := range attrs {
+		dst = appendFlatAttr(dst, h.groups, ".", a, h.opts.ReplaceAttr)
+	}
+	return dst
+}
+
+// format renders r as
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ELEMENT] MSG
+func (h *SyslogHandler) format(r slog.Record) []byte {
+	buf := make([]byte, 0, 256)
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(h.opts.Facility)*8+int64(syslogSeverity(r.Level)), 10)
+	buf = append(buf, ">1 "...)
+
+	if r.Time.IsZero() {
+		buf = append(buf, '-')
+	} else {
+		buf = r.Time.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+	}
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, h.opts.Hostname, 255)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, h.opts.AppName, 48)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, h.procID, 128)
+	buf = append(buf, " - "...)
+
+	attrs := h.attrs
+	if h.opts.AddSource && r.PC != 0 {
+		src := recordSource(r, h.opts.ReplaceAttr)
+		loc := slog.String(slog.SourceKey, src.File+":"+strconv.Itoa(src.Line))
+		attrs = append(attrs[:len(attrs):len(attrs)], flatAttr{Key: loc.Key, Value: loc.Value})
+	}
+	if r.NumAttrs() > 0 {
+		attrs = attrs[:len(attrs):len(attrs)]
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = h.appendAttrs(attrs, a)
+			return true
+		})
+	}
+
+	if len(attrs) == 0 {
+		buf = append(buf, '-')
+	} else {
+		buf = append(buf, '[')
+		buf = append(buf, h.opts.SDID...)
+		for _, a := range attrs {
+			buf = append(buf, ' ')
+			buf = appendSDName(buf, a.Key)
+			buf = append(buf, `="`...)
+			buf = appendSDValue(buf, attrValueString(a.Value))
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ']')
+	}
+
+	if r.Message != "" {
+		buf = append(buf, ' ')
+		buf = append(buf, r.Message...)
+	}
+	return buf
+}
+
+// syslogSeverity maps a slog level to an RFC 5424 severity.
+func syslogSeverity(l slog.Level) int {
+	switch {
+	case l >= slog.LevelError+4:
+		return 2 // critical
+	case l >= slog.LevelError:
+		return 3 // error
+	case l >= slog.LevelWarn:
+		return 4 // warning
+	case l >= slog.LevelInfo+2:
+		return 5 // notice
+	case l >= slog.LevelInfo:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
+
+// appendHeaderField appends a header field limited to printable US-ASCII,
+// or the nil value "-" when s is empty.
+func appendHeaderField(buf []byte, s string, max int) []byte {
+	if s == "" {
+		return append(buf, '-')
+	}
+	n := 0
+	for i := 0; i < len(s) && n < max; i++ {
+		c := s[i]
+		if c < 33 || c > 126 {
+			c = '_'
+		}
+		buf = append(buf, c)
+		n++
+	}
+	return buf
+}
+
+// appendSDName appends an SD-NAME: at most 32 printable US-ASCII characters
+// except '=', ' ', ']' and '"'.
+func appendSDName(buf []byte, s string) []byte {
+	if s == "" {
+		return append(buf, '_')
+	}
+	for i := 0; i < len(s) && i < 32; i++ {
+		c := s[i]
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendSDValue appends a PARAM-VALUE, escaping '"', '\' and ']'.
+func appendSDValue(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			buf = append(buf, '\\', c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// attrValueString renders v the way text handlers do.
+func attrValueString(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		if s, ok := v.Any().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	return v.String()
+}
@@ -0,0 +1,148 @@
+package tlog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTime = time.Date(2025, 5, 1, 12, 30, 45, 123456000, time.UTC)
+
+func TestSyslogHandler(t *testing.T) {
+	t.Run("[SUCCESS] should write RFC 5424 datagrams over udp", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer pc.Close()
+
+		h, err := NewSyslogHandler(&SyslogOptions{
+			Network:  "udp",
+			Addr:     pc.LocalAddr().String(),
+			Level:    slog.LevelDebug,
+			Facility: FacilityLocal0,
+			AppName:  "tlog-test",
+			Hostname: "host1",
+		})
+		require.NoError(t, err)
+		defer h.Close()
+
+		logger := slog.New(h).With("service", "api").WithGroup("req")
+		r := slog.NewRecord(testTime, slog.LevelWarn, "slow request", 0)
+		r.AddAttrs(slog.Int("ms", 1500), slog.String("path", `/a"b]`))
+		require.NoError(t, logger.Handler().Handle(context.Background(), r))
+
+		buf := make([]byte, 2048)
+		_ = pc.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+
+		// local0 (16) * 8 + warning (4) = 132
+		expected := `<132>1 2025-05-01T12:30:45.123456Z host1 tlog-test ` + h.procID +
+			` - [slog@32473 service="api" req.ms="1500" req.path="/a\"b\]"] slow request`
+		assert.Equal(t, expected, string(buf[:n]))
+	})
+
+	t.Run("[SUCCESS] should use octet framing over tcp", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		received := make(chan []string, 1)
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			received <- readFrames(bufio.NewReader(conn), 2)
+		}()
+
+		h, err := NewSyslogHandler(&SyslogOptions{Network: "tcp", Addr: ln.Addr().String(), Hostname: "h", AppName: "a"})
+		require.NoError(t, err)
+		defer h.Close()
+
+		logger := slog.New(h)
+		logger.Info("first")
+		logger.Error("second", "err", errors.New("boom"))
+
+		select {
+		case frames := <-received:
+			require.Len(t, frames, 2)
+			assert.True(t, strings.HasPrefix(frames[0], "<14>1 "), frames[0])
+			assert.True(t, strings.HasSuffix(frames[0], " - - first"), frames[0])
+			assert.True(t, strings.HasPrefix(frames[1], "<11>1 "), frames[1])
+			assert.True(t, strings.HasSuffix(frames[1], ` - [slog@32473 err="boom"] second`), frames[1])
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for syslog frames")
+		}
+	})
+
+	t.Run("[SUCCESS] should write to a unix datagram socket and filter by level", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "log.sock")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+		require.NoError(t, err)
+		defer conn.Close()
+
+		h, err := NewSyslogHandler(&SyslogOptions{Addr: path, Level: slog.LevelWarn})
+		require.NoError(t, err)
+		defer h.Close()
+
+		logger := slog.New(h)
+		logger.Info("dropped")
+		logger.Warn("kept")
+
+		buf := make([]byte, 2048)
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(string(buf[:n]), " kept"), string(buf[:n]))
+	})
+
+	t.Run("[FAILURE] should reject unsupported networks", func(t *testing.T) {
+		_, err := NewSyslogHandler(&SyslogOptions{Network: "sctp", Addr: "127.0.0.1:514"})
+		assert.Error(t, err)
+	})
+
+	t.Run("[FAILURE] should return an error after close", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer pc.Close()
+
+		h, err := NewSyslogHandler(&SyslogOptions{Network: "udp", Addr: pc.LocalAddr().String()})
+		require.NoError(t, err)
+		require.NoError(t, h.Close())
+
+		err = h.Handle(context.Background(), slog.NewRecord(testTime, slog.LevelInfo, "msg", 0))
+		assert.ErrorIs(t, err, errHandlerClosed)
+	})
+}
+
+func readFrames(r *bufio.Reader, count int) []string {
+	var frames []string
+	for range count {
+		lenStr, err := r.ReadString(' ')
+		if err != nil {
+			return frames
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+		if err != nil {
+			return frames
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return frames
+		}
+		frames = append(frames, string(msg))
+	}
+	return frames
+}
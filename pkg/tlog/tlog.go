@@ -1,9 +1,13 @@
 package tlog
 
 import (
+	"context"
+	"errors"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/lmittmann/tint"
@@ -21,8 +25,43 @@ var DefaultConfig = &Config{
 	ForceJSON:   true,
 }
 
+// New returns a logger built from cfg. The log file and the sinks it opens
+// are never released; with Config.OTLP, Config.Dispatch or Config.Sampling
+// set use NewWithClose instead, so the last summary is logged, the async
+// queues are drained and the last batch is exported on shutdown.
 func New(cfg *Config) *slog.Logger {
-	var handlers []slog.Handler
+	h, _ := newHandler(cfg)
+	return slog.New(h)
+}
+
+// NewWithClose is New also returning a func that releases the logger. The
+// caller must call it on shutdown when Config.OTLP, Config.Dispatch or
+// Config.Sampling is set: it logs the summary of the records suppressed
+// since the last tick and stops the sampler, drains the queues of
+// DispatchAsync, stopping the workers, then exports the pending records
+// and closes the exporter. It also closes the log file and the syslog and
+// journald connections. Records logged after it is called may be dropped.
+// Calling it again does nothing.
+func NewWithClose(cfg *Config) (*slog.Logger, func() error) {
+	h, release := newHandler(cfg)
+	return slog.New(h), sync.OnceValue(release)
+}
+
+// newHandler builds the handler tree for cfg. The returned func releases
+// the log file and connections opened for it.
+func newHandler(cfg *Config) (slog.Handler, func() error) {
+	isTTY := isatty.IsTerminal(os.Stderr.Fd()) ||
+		isatty.IsCygwinTerminal(os.Stderr.Fd())
+
+	return newHandlerTo(cfg, os.Stderr, isTTY)
+}
+
+// newHandlerTo is newHandler writing the console output to stderr.
+func newHandlerTo(cfg *Config, stderr io.Writer, isTTY bool) (slog.Handler, func() error) {
+	var (
+		handlers []slog.Handler
+		closers  []func() error
+	)
 
 	if cfg == nil {
 		cfg = DefaultConfig
@@ -46,6 +85,10 @@ func New(cfg *Config) *slog.Logger {
 	replaceAttr := func(groups []string, a slog.Attr) slog.Attr {
 		if a.Key == slog.SourceKey && a.Value.Kind() == slog.KindAny {
 			if source, ok := a.Value.Any().(*slog.Source); ok {
+				if source.File == "" {
+					// 沒有呼叫位置 (PC 為 0) 時不輸出 source
+					return slog.Attr{}
+				}
 				// 保留最後兩層 (例如 core/http.go)
 				dir := filepath.Base(filepath.Dir(source.File))
 				source.File = filepath.Join(dir, filepath.Base(source.File))
@@ -54,19 +97,41 @@ func New(cfg *Config) *slog.Logger {
 		return a
 	}
 
-	isTTY := isatty.IsTerminal(os.Stderr.Fd()) ||
-		isatty.IsCygwinTerminal(os.Stderr.Fd())
+	var masker *Masker
+	if cfg.Mask != nil {
+		masker = NewMasker(cfg.Mask)
+		replaceAttr = ChainReplaceAttr(masker.ReplaceAttr, replaceAttr)
+	}
+	// sinkReplaceAttr keeps a sink's own ReplaceAttr, still masking secrets
+	sinkReplaceAttr := func(own func([]string, slog.Attr) slog.Attr) func([]string, slog.Attr) slog.Attr {
+		switch {
+		case own == nil:
+			return replaceAttr
+		case masker != nil:
+			return ChainReplaceAttr(masker.ReplaceAttr, own)
+		default:
+			return own
+		}
+	}
 
 	var stderrHandler slog.Handler
-	if (isTTY || cfg.ForceText) && !cfg.NoColor && !cfg.ForceJSON {
-		stderrHandler = tint.NewHandler(os.Stderr, &tint.Options{
+	if cfg.Dev {
+		stderrHandler = NewDevHandler(stderr, &DevOptions{
+			Level:       stderrLevel,
+			AddSource:   true,
+			NoColor:     cfg.NoColor || !isTTY,
+			TimeFormat:  cfg.TimeFormat,
+			ReplaceAttr: replaceAttr,
+		})
+	} else if (isTTY || cfg.ForceText) && !cfg.NoColor && !cfg.ForceJSON {
+		stderrHandler = tint.NewHandler(stderr, &tint.Options{
 			Level:       stderrLevel,
 			TimeFormat:  timeFormat,
 			AddSource:   true,
 			ReplaceAttr: replaceAttr,
 		})
 	} else {
-		stderrHandler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		stderrHandler = slog.NewJSONHandler(stderr, &slog.HandlerOptions{
 			Level:       stderrLevel,
 			AddSource:   true,
 			ReplaceAttr: replaceAttr,
@@ -84,6 +149,8 @@ func New(cfg *Config) *slog.Logger {
 			Compress:   true, // compress old files (.gz)
 		}
 
+		closers = append(closers, fileWriter.Close)
+
 		fileHandler := slog.NewJSONHandler(fileWriter, &slog.HandlerOptions{
 			Level:       fileLevel,
 			AddSource:   true,
@@ -92,5 +159,61 @@ func New(cfg *Config) *slog.Logger {
 		handlers = append(handlers, fileHandler)
 	}
 
-	return slog.New(&MultiHandler{handlers: handlers})
+	if cfg.Syslog != nil {
+		opts := *cfg.Syslog
+		opts.ReplaceAttr = sinkReplaceAttr(opts.ReplaceAttr)
+		if h, err := NewSyslogHandler(&opts); err != nil {
+			slog.New(stderrHandler).Error("tlog: syslog disabled", "error", err)
+		} else {
+			handlers = append(handlers, h)
+			closers = append(closers, h.Close)
+		}
+	}
+
+	if cfg.Journald != nil {
+		opts := *cfg.Journald
+		opts.ReplaceAttr = sinkReplaceAttr(opts.ReplaceAttr)
+		if h, err := NewJournaldHandler(&opts); err != nil {
+			slog.New(stderrHandler).Error("tlog: journald disabled", "error", err)
+		} else {
+			handlers = append(handlers, h)
+			closers = append(closers, h.Close)
+		}
+	}
+
+	if cfg.OTLP != nil {
+		opts := *cfg.OTLP
+		opts.ReplaceAttr = sinkReplaceAttr(opts.ReplaceAttr)
+		if h, err := NewOTLPHandler(&opts); err != nil {
+			slog.New(stderrHandler).Error("tlog: OTLP exporter disabled", "error", err)
+		} else {
+			handlers = append(handlers, h)
+			closers = append(closers, func() error { return h.Close(context.Background()) })
+		}
+	}
+
+	multi := &MultiHandler{handlers: handlers}
+	if cfg.Dispatch != nil {
+		multi = NewMultiHandler(cfg.Dispatch, handlers...)
+		// drain the queues before the sinks are closed
+		closers = append([]func() error{func() error { return multi.Close(context.Background()) }}, closers...)
+	}
+
+	var h slog.Handler = multi
+	if cfg.Sampling != nil {
+		sampling := NewSamplingHandler(multi, cfg.Sampling)
+		// the last summary still goes through the handlers
+		closers = append([]func() error{func() error { return sampling.Close(context.Background()) }}, closers...)
+		h = sampling
+	}
+
+	closeAll := func() error {
+		var errs []error
+		for _, c := range closers {
+			errs = append(errs, c())
+		}
+		return errors.Join(errs...)
+	}
+
+	return h, closeAll
 }
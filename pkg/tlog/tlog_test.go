@@ -0,0 +1,74 @@
+package tlog
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithClose(t *testing.T) {
+	t.Run("[SUCCESS] should export the pending OTLP records on close", func(t *testing.T) {
+		collector := newOTLPCollector(t)
+		logger, closeLogger := NewWithClose(&Config{
+			StderrLevel: slog.LevelError,
+			LogFilePath: filepath.Join(t.TempDir(), "app.log"),
+			OTLP:        &OTLPOptions{Endpoint: collector.URL + "/v1/logs"},
+		})
+
+		logger.Info("shutting down")
+		assert.Empty(t, collector.records())
+
+		require.NoError(t, closeLogger())
+		records := collector.records()
+		require.Len(t, records, 1)
+		assert.Equal(t, map[string]any{"stringValue": "shutting down"}, records[0]["body"])
+		assert.NoError(t, closeLogger())
+	})
+
+	t.Run("[SUCCESS] should drain the async queues on close", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		logger, closeLogger := NewWithClose(&Config{
+			StderrLevel: slog.LevelError,
+			FileLevel:   slog.LevelInfo,
+			LogFilePath: path,
+			Dispatch:    &MultiHandlerOptions{Mode: DispatchAsync},
+		})
+
+		for i := range 100 {
+			logger.Info("queued", "i", i)
+		}
+		require.NoError(t, closeLogger())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 100)
+		assert.Contains(t, lines[99], `"i":99`)
+	})
+
+	t.Run("[SUCCESS] should log the last sampling summary on close", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		logger, closeLogger := NewWithClose(&Config{
+			StderrLevel: slog.LevelError,
+			FileLevel:   slog.LevelInfo,
+			LogFilePath: path,
+			Sampling:    &SamplingOptions{First: 1, Tick: time.Hour},
+		})
+
+		for range 3 {
+			logger.Info("flood")
+		}
+		require.NoError(t, closeLogger())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(string(data), `"msg":"flood"`))
+		assert.Contains(t, string(data), `"suppressed":{"message":"flood","level":"INFO","count":2}`)
+	})
+}
@@ -0,0 +1,298 @@
+// Package tlogtest provides a slog.Handler that captures records in memory,
+// so tests can assert on what code logged.
+//
+//	h := tlogtest.NewHandler(nil)
+//	svc := NewService(slog.New(h))
+//	svc.Do()
+//
+//	rec, ok := h.Find(tlogtest.Level(slog.LevelError), tlogtest.HasAttr("req.id", "42"))
+package tlogtest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	Level     slog.Leveler // default: Debug, so nothing is missed
+	AddSource bool
+}
+
+// Record is a captured slog record. Attrs holds the resolved attributes,
+// including those added with WithAttrs, with every group, including
+// WithGroup groups, as a nested map[string]any.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]any
+	Source  *slog.Source // nil unless Options.AddSource is set
+}
+
+// Attr returns the value at path, with group names and the key joined by
+// ".", e.g. "req.id". Keys that contain dots themselves, e.g. "grpc.code",
+// are found as well.
+func (r Record) Attr(path string) (any, bool) {
+	return lookup(r.Attrs, path)
+}
+
+func lookup(m map[string]any, path string) (any, bool) {
+	if v, ok := m[path]; ok {
+		return v, true
+	}
+	for i := range len(path) {
+		if path[i] != '.' {
+			continue
+		}
+		if sub, ok := m[path[:i]].(map[string]any); ok {
+			if v, ok := lookup(sub, path[i+1:]); ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ToMap returns r in the form testing/slogtest expects: the attributes
+// next to the built-in slog.TimeKey, slog.LevelKey, slog.MessageKey and
+// slog.SourceKey entries. Zero times and missing sources are omitted.
+func ToMap(r Record) map[string]any {
+	m := make(map[string]any, len(r.Attrs)+4)
+	for k, v := range r.Attrs {
+		m[k] = v
+	}
+	if !r.Time.IsZero() {
+		m[slog.TimeKey] = r.Time
+	}
+	m[slog.LevelKey] = r.Level
+	m[slog.MessageKey] = r.Message
+	if r.Source != nil {
+		m[slog.SourceKey] = r.Source
+	}
+	return m
+}
+
+// Handler captures records. Handlers derived with WithAttrs and WithGroup
+// record into the same store.
+type Handler struct {
+	opts  Options
+	store *store
+	goa   *groupOrAttrs
+}
+
+type store struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+	next  *groupOrAttrs
+}
+
+// NewHandler returns a capturing handler.
+func NewHandler(opts *Options) *Handler {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelDebug
+	}
+	return &Handler{opts: o, store: &store{}}
+}
+
+func (h *Handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.opts.Level.Level()
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	rec := Record{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   map[string]any{},
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		fs := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := fs.Next()
+		rec.Source = &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
+	}
+
+	// collect the WithGroup/WithAttrs chain outermost first
+	var chain []*groupOrAttrs
+	for g := h.goa; g != nil; g = g.next {
+		chain = append(chain, g)
+	}
+	slices.Reverse(chain)
+
+	// groups are only created once they receive an attribute
+	var groups []string
+	for _, g := range chain {
+		if g.group != "" {
+			groups = append(groups, g.group)
+			continue
+		}
+		for _, a := range g.attrs {
+			addAttr(rec.Attrs, groups, a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(rec.Attrs, groups, a)
+		return true
+	})
+
+	h.store.mu.Lock()
+	h.store.records = append(h.store.records, rec)
+	h.store.mu.Unlock()
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.goa = &groupOrAttrs{attrs: slices.Clone(attrs), next: h.goa}
+	return &h2
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.goa = &groupOrAttrs{group: name, next: h.goa}
+	return &h2
+}
+
+// Records returns a copy of all captured records in the order they were handled.
+func (h *Handler) Records() []Record {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	return slices.Clone(h.store.records)
+}
+
+// Reset discards all captured records.
+func (h *Handler) Reset() {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	h.store.records = nil
+}
+
+// Filter returns the records matching all matchers.
+func (h *Handler) Filter(matchers ...Matcher) []Record {
+	var out []Record
+	for _, r := range h.Records() {
+		if matchAll(r, matchers) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Find returns the first record matching all matchers.
+func (h *Handler) Find(matchers ...Matcher) (Record, bool) {
+	for _, r := range h.Records() {
+		if matchAll(r, matchers) {
+			return r, true
+		}
+	}
+	return Record{}, false
+}
+
+// Count returns the number of records matching all matchers.
+func (h *Handler) Count(matchers ...Matcher) int {
+	return len(h.Filter(matchers...))
+}
+
+// Matcher selects records in Filter, Find and Count.
+type Matcher func(Record) bool
+
+// Level matches records at exactly level l.
+func Level(l slog.Level) Matcher {
+	return func(r Record) bool { return r.Level == l }
+}
+
+// MinLevel matches records at level l or above.
+func MinLevel(l slog.Level) Matcher {
+	return func(r Record) bool { return r.Level >= l }
+}
+
+// Message matches records whose message is msg.
+func Message(msg string) Matcher {
+	return func(r Record) bool { return r.Message == msg }
+}
+
+// MessageContains matches records whose message contains s.
+func MessageContains(s string) Matcher {
+	return func(r Record) bool { return strings.Contains(r.Message, s) }
+}
+
+// HasKey matches records that have an attribute at path (see Record.Attr).
+func HasKey(path string) Matcher {
+	return func(r Record) bool {
+		_, ok := r.Attr(path)
+		return ok
+	}
+}
+
+// HasAttr matches records whose attribute at path equals value. Values are
+// compared after formatting both with fmt.Sprint, so HasAttr("n", 1) matches
+// an int64 attribute and HasAttr("err", "boom") matches an error.
+func HasAttr(path string, value any) Matcher {
+	want := fmt.Sprint(value)
+	return func(r Record) bool {
+		v, ok := r.Attr(path)
+		return ok && fmt.Sprint(v) == want
+	}
+}
+
+func matchAll(r Record, matchers []Matcher) bool {
+	for _, m := range matchers {
+		if !m(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// addAttr resolves a and stores it in attrs under groups, creating group
+// maps as needed. Empty attrs and empty groups are ignored.
+func addAttr(attrs map[string]any, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		gattrs := a.Value.Group()
+		if len(gattrs) == 0 {
+			return
+		}
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range gattrs {
+			addAttr(attrs, groups, ga)
+		}
+		return
+	}
+
+	m := attrs
+	for _, g := range groups {
+		sub, ok := m[g].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			m[g] = sub
+		}
+		m = sub
+	}
+	m[a.Key] = a.Value.Any()
+}
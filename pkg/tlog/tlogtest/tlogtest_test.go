@@ -0,0 +1,82 @@
+package tlogtest
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Conformance(t *testing.T) {
+	var h *Handler
+	slogtest.Run(t,
+		func(*testing.T) slog.Handler {
+			h = NewHandler(&Options{AddSource: true})
+			return h
+		},
+		func(t *testing.T) map[string]any {
+			records := h.Records()
+			require.Len(t, records, 1)
+			return ToMap(records[0])
+		},
+	)
+}
+
+func TestHandler_Queries(t *testing.T) {
+	h := NewHandler(nil)
+	logger := slog.New(h)
+
+	logger.Debug("starting", "port", 8080)
+	logger.With("svc", "api").WithGroup("req").Info("handled", "id", "42", "status", 200)
+	logger.Error("failed", "err", errors.New("boom"))
+
+	t.Run("[SUCCESS] should capture records in order", func(t *testing.T) {
+		records := h.Records()
+		require.Len(t, records, 3)
+		assert.Equal(t, "starting", records[0].Message)
+		assert.Equal(t, slog.LevelDebug, records[0].Level)
+		assert.Equal(t, map[string]any{
+			"svc": "api",
+			"req": map[string]any{"id": "42", "status": int64(200)},
+		}, records[1].Attrs)
+	})
+
+	t.Run("[SUCCESS] should query by level, message and attributes", func(t *testing.T) {
+		assert.Equal(t, 1, h.Count(Level(slog.LevelError)))
+		assert.Equal(t, 2, h.Count(MinLevel(slog.LevelInfo)))
+		assert.Equal(t, 1, h.Count(MessageContains("start")))
+
+		rec, ok := h.Find(HasAttr("req.id", "42"), HasAttr("req.status", 200))
+		require.True(t, ok)
+		assert.Equal(t, "handled", rec.Message)
+
+		v, ok := rec.Attr("svc")
+		assert.True(t, ok)
+		assert.Equal(t, "api", v)
+
+		_, ok = rec.Attr("req.missing")
+		assert.False(t, ok)
+
+		assert.Len(t, h.Filter(HasAttr("err", "boom")), 1)
+		assert.Len(t, h.Filter(HasKey("port"), Message("starting")), 1)
+		_, ok = h.Find(Message("nothing"))
+		assert.False(t, ok)
+	})
+
+	t.Run("[SUCCESS] should reset captured records", func(t *testing.T) {
+		h.Reset()
+		assert.Empty(t, h.Records())
+	})
+
+	t.Run("[SUCCESS] should respect the level option", func(t *testing.T) {
+		h := NewHandler(&Options{Level: slog.LevelWarn})
+		slog.New(h).Info("ignored")
+		slog.New(h).Warn("kept", "at", time.Duration(0))
+		require.Len(t, h.Records(), 1)
+		assert.Equal(t, "kept", h.Records()[0].Message)
+	})
+}
@@ -0,0 +1,310 @@
+// Code generated by nbx dbugen. DO NOT EDIT.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// RDBUpdateMap returns the map of dbu.BuildRDBUpdateMap(x, skip) without
+// reflection, with the columns named by dbu.DefaultSnakeCaseNamer.
+func (x *Travel) RDBUpdateMap(skip ...string) (map[string]any, error) {
+	m := make(map[string]any)
+	if x == nil {
+		return m, nil
+	}
+	if !slices.Contains(skip, "travel_id") {
+		m["travel_id"] = x.ID
+	}
+	if x.Name != nil && !slices.Contains(skip, "name") {
+		m["name"] = *x.Name
+	}
+	if x.IsPublic != nil && !slices.Contains(skip, "is_public") {
+		m["is_public"] = *x.IsPublic
+	}
+	if x.StartAt != nil && !slices.Contains(skip, "start_at") {
+		m["start_at"] = *x.StartAt
+	}
+	if !slices.Contains(skip, "category_code") {
+		m["category_code"] = x.Category
+	}
+	if !slices.Contains(skip, "destination") {
+		m["destination"] = x.Destination
+	}
+	if !slices.Contains(skip, "note") {
+		m["note"] = x.Note
+	}
+	if !slices.Contains(skip, "hidden") {
+		m["hidden"] = x.Hidden
+	}
+	if !slices.Contains(skip, "origin") {
+		b, err := json.Marshal(x.Origin)
+		if err != nil {
+			return m, fmt.Errorf("failed to marshal field %s (%s) to JSON: %w", "Origin", "origin", err)
+		}
+		m["origin"] = json.RawMessage(b)
+	}
+	if x.Stops != nil && !slices.Contains(skip, "stops") {
+		b, err := json.Marshal(*x.Stops)
+		if err != nil {
+			return m, fmt.Errorf("failed to marshal field %s (%s) to JSON: %w", "Stops", "stops", err)
+		}
+		m["stops"] = json.RawMessage(b)
+	}
+	if !slices.Contains(skip, "extra") {
+		b, err := json.Marshal(x.Extra)
+		if err != nil {
+			return m, fmt.Errorf("failed to marshal field %s (%s) to JSON: %w", "Extra", "extra", err)
+		}
+		m["extra"] = json.RawMessage(b)
+	}
+	if x.Details != nil {
+		if x.Details.Text != nil && !slices.Contains(skip, "details_text") {
+			m["details_text"] = *x.Details.Text
+		}
+		if !slices.Contains(skip, "details_pdf") {
+			m["details_pdf"] = x.Details.PDF
+		}
+		if x.Details.Geo != nil {
+			if !slices.Contains(skip, "details_geo_lat") {
+				m["details_geo_lat"] = x.Details.Geo.Lat
+			}
+			if !slices.Contains(skip, "details_geo_lng") {
+				m["details_geo_lng"] = x.Details.Geo.Lng
+			}
+		}
+	}
+	if !slices.Contains(skip, "street") {
+		m["street"] = x.Address.Street
+	}
+	if x.Address.City != nil && !slices.Contains(skip, "city") {
+		m["city"] = *x.Address.City
+	}
+	if !slices.Contains(skip, "labels") {
+		m["labels"] = x.Labels
+	}
+	if !slices.Contains(skip, "meta") {
+		m["meta"] = x.Meta
+	}
+	if !slices.Contains(skip, "revision") {
+		m["revision"] = x.Revision
+	}
+	if x.Previous != nil && !slices.Contains(skip, "previous") {
+		m["previous"] = *x.Previous
+	}
+	if x.StartGeo != nil && !slices.Contains(skip, "start_geo") {
+		m["start_geo"] = *x.StartGeo
+	}
+	if x.Nickname.IsSet() && !slices.Contains(skip, "nickname") {
+		if v, ok := x.Nickname.Get(); ok {
+			m["nickname"] = v
+		} else {
+			m["nickname"] = nil
+		}
+	}
+	if x.Budget != nil && x.Budget.IsSet() && !slices.Contains(skip, "budget") {
+		if v, ok := x.Budget.Get(); ok {
+			m["budget"] = v
+		} else {
+			m["budget"] = nil
+		}
+	}
+	if x.Itinerary.IsSet() && !slices.Contains(skip, "itinerary") {
+		if v, ok := x.Itinerary.Get(); ok {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return m, fmt.Errorf("failed to marshal field %s (%s) to JSON: %w", "Itinerary", "itinerary", err)
+			}
+			m["itinerary"] = json.RawMessage(b)
+		} else {
+			m["itinerary"] = nil
+		}
+	}
+	return m, nil
+}
+
+// MongoUpdateMap returns the map of dbu.BuildMongoUpdateMap(x, skip)
+// without reflection.
+func (x *Travel) MongoUpdateMap(skip ...string) map[string]any {
+	m := make(map[string]any)
+	if x == nil {
+		return m
+	}
+	if !slices.Contains(skip, "id") {
+		m["id"] = x.ID
+	}
+	if x.Name != nil && !slices.Contains(skip, "name") {
+		m["name"] = *x.Name
+	}
+	if x.IsPublic != nil && !slices.Contains(skip, "isPublic") {
+		m["isPublic"] = *x.IsPublic
+	}
+	if x.StartAt != nil && !slices.Contains(skip, "startAt") {
+		m["startAt"] = *x.StartAt
+	}
+	if !slices.Contains(skip, "category_code") {
+		m["category_code"] = x.Category
+	}
+	if !slices.Contains(skip, "destination") {
+		m["destination"] = x.Destination
+	}
+	if !slices.Contains(skip, "hidden") {
+		m["hidden"] = x.Hidden
+	}
+	if !slices.Contains(skip, "origin") {
+		if !slices.Contains(skip, "origin.street") {
+			m["origin.street"] = x.Origin.Street
+		}
+		if x.Origin.City != nil && !slices.Contains(skip, "origin.city") {
+			m["origin.city"] = *x.Origin.City
+		}
+	}
+	if x.Stops != nil && !slices.Contains(skip, "stops") {
+		m["stops"] = *x.Stops
+	}
+	if !slices.Contains(skip, "extra") {
+		m["extra"] = x.Extra
+	}
+	if x.Details != nil && !slices.Contains(skip, "details") {
+		if x.Details.Text != nil && !slices.Contains(skip, "details.text") {
+			m["details.text"] = *x.Details.Text
+		}
+		if !slices.Contains(skip, "details.pdf") {
+			m["details.pdf"] = x.Details.PDF
+		}
+		if x.Details.Geo != nil && !slices.Contains(skip, "details.geo") {
+			if !slices.Contains(skip, "details.geo.lat") {
+				m["details.geo.lat"] = x.Details.Geo.Lat
+			}
+			if !slices.Contains(skip, "details.geo.lng") {
+				m["details.geo.lng"] = x.Details.Geo.Lng
+			}
+		}
+	}
+	if !slices.Contains(skip, "address") {
+		if !slices.Contains(skip, "address.street") {
+			m["address.street"] = x.Address.Street
+		}
+		if x.Address.City != nil && !slices.Contains(skip, "address.city") {
+			m["address.city"] = *x.Address.City
+		}
+	}
+	if !slices.Contains(skip, "labels") {
+		m["labels"] = x.Labels
+	}
+	if !slices.Contains(skip, "meta") {
+		m["meta"] = x.Meta
+	}
+	if !slices.Contains(skip, "version") {
+		m["version"] = x.Revision.Version
+	}
+	if x.Revision.Editor != nil && !slices.Contains(skip, "editor_geo") {
+		if !slices.Contains(skip, "editor_geo.lat") {
+			m["editor_geo.lat"] = x.Revision.Editor.Lat
+		}
+		if !slices.Contains(skip, "editor_geo.lng") {
+			m["editor_geo.lng"] = x.Revision.Editor.Lng
+		}
+	}
+	if !slices.Contains(skip, "comment") {
+		m["comment"] = x.Revision.Comment
+	}
+	if x.Previous != nil && !slices.Contains(skip, "previous") {
+		m["previous"] = *x.Previous
+	}
+	if x.StartGeo != nil && !slices.Contains(skip, "start_geo") {
+		m["start_geo"] = *x.StartGeo
+	}
+	if x.Nickname.IsSet() && !slices.Contains(skip, "nickname") {
+		if v, ok := x.Nickname.Get(); ok {
+			m["nickname"] = v
+		} else {
+			m["nickname"] = nil
+		}
+	}
+	if x.Budget != nil && x.Budget.IsSet() && !slices.Contains(skip, "budget") {
+		if v, ok := x.Budget.Get(); ok {
+			m["budget"] = v
+		} else {
+			m["budget"] = nil
+		}
+	}
+	if x.Itinerary.IsSet() && !slices.Contains(skip, "itinerary") {
+		if v, ok := x.Itinerary.Get(); ok {
+			m["itinerary"] = v
+		} else {
+			m["itinerary"] = nil
+		}
+	}
+	return m
+}
+
+// RDBUpdateMap returns the map of dbu.BuildRDBUpdateMap(x, skip) without
+// reflection, with the columns named by dbu.DefaultSnakeCaseNamer.
+func (x *Account) RDBUpdateMap(skip ...string) (map[string]any, error) {
+	m := make(map[string]any)
+	if x == nil {
+		return m, nil
+	}
+	if !slices.Contains(skip, "_id") {
+		m["_id"] = x.UserID
+	}
+	if !slices.Contains(skip, "email") {
+		m["email"] = x.Email
+	}
+	if x.NickName != nil && !slices.Contains(skip, "nick_name") {
+		m["nick_name"] = *x.NickName
+	}
+	if !slices.Contains(skip, "balance") {
+		m["balance"] = x.Balance
+	}
+	if !slices.Contains(skip, "seen_at") {
+		m["seen_at"] = x.SeenAt
+	}
+	if !slices.Contains(skip, "avatar") {
+		m["avatar"] = x.Avatar
+	}
+	if !slices.Contains(skip, "homepage") {
+		m["homepage"] = x.Homepage
+	}
+	if !slices.Contains(skip, "token") {
+		m["token"] = x.Token
+	}
+	return m, nil
+}
+
+// MongoUpdateMap returns the map of dbu.BuildMongoUpdateMap(x, skip)
+// without reflection.
+func (x *Account) MongoUpdateMap(skip ...string) map[string]any {
+	m := make(map[string]any)
+	if x == nil {
+		return m
+	}
+	if !slices.Contains(skip, "_id") {
+		m["_id"] = x.UserID
+	}
+	if !slices.Contains(skip, "mail") {
+		m["mail"] = x.Email
+	}
+	if x.NickName != nil && !slices.Contains(skip, "nickName") {
+		m["nickName"] = *x.NickName
+	}
+	if !slices.Contains(skip, "balance") {
+		m["balance"] = x.Balance
+	}
+	if !slices.Contains(skip, "seen_at") {
+		m["seen_at"] = x.SeenAt
+	}
+	if !slices.Contains(skip, "avatar") {
+		m["avatar"] = x.Avatar
+	}
+	if !slices.Contains(skip, "homepage") {
+		m["homepage"] = x.Homepage
+	}
+	if !slices.Contains(skip, "token") {
+		m["token"] = x.Token
+	}
+	return m
+}
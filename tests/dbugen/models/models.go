@@ -0,0 +1,84 @@
+// Package models holds structs covering the tag rules of the dbu builders,
+// with update-map methods generated by nbx dbugen to check their parity
+// with the reflective builders.
+package models
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/byte4cat/nbx/v2/pkg/dbu"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:generate go run ../../.. dbugen --type Travel,Account
+
+type Address struct {
+	Street string  `json:"street"`
+	City   *string `json:"city"`
+}
+
+type Details struct {
+	Text *string `json:"text"`
+	PDF  string  `json:"pdf"`
+	Geo  *Geo    `gorm:"embedded;embeddedPrefix:geo_"`
+}
+
+type Geo struct {
+	Lat float64
+	Lng float64
+}
+
+type Revision struct {
+	Version int    `bson:"version"`
+	Editor  *Geo   `bson:"editor_geo"`
+	Comment string `json:"comment"`
+}
+
+type audit struct {
+	CreatedBy string
+}
+
+type Travel struct {
+	ID          string     `gorm:"column:travel_id" json:"id"`
+	Name        *string    `json:"name"`
+	IsPublic    *bool      `json:"isPublic"`
+	StartAt     *time.Time `json:"startAt"`
+	Category    int        `bson:"category_code" json:"category"`
+	Destination string     `bson:"-" json:"destination"`
+	Note        string     `json:",omitempty"`
+	Hidden      string     `json:"-"`
+	Origin      Address    `gorm:"type:jsonb" json:"origin"`
+	Stops       *[]Address `gorm:"type:jsonb" json:"stops"`
+	Extra       any        `gorm:"type:jsonb" json:"-"`
+	Details     *Details   `gorm:"embedded;embeddedPrefix:details_" json:"details"`
+	Address     `gorm:"embedded"`
+	Labels      map[string]string `form:"labels"`
+	Meta        any
+	Revision    `bson:",inline"`
+	Previous    *Travel                `bson:"previous"`
+	StartGeo    *Geo                   `bson:"start_geo" dbu:"setOnInsert"`
+	Nickname    dbu.Nullable[string]   `json:"nickname"`
+	Budget      *dbu.Nullable[float64] `json:"budget"`
+	Itinerary   dbu.Nullable[[]string] `gorm:"type:jsonb" json:"itinerary"`
+	audit
+	secret string
+}
+
+// Token has no exported fields, so it is set as a whole.
+type Token struct {
+	value string
+}
+
+func NewToken(value string) Token { return Token{value: value} }
+
+type Account struct {
+	UserID   string `bson:"_id"`
+	Email    string `form:"mail"`
+	NickName *string
+	Balance  primitive.Decimal128 `bson:"balance"`
+	SeenAt   primitive.Timestamp  `bson:"seen_at"`
+	Avatar   primitive.Binary     `bson:"avatar"`
+	Homepage url.URL              `bson:"homepage"`
+	Token    Token                `bson:"token"`
+}
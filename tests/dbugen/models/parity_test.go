@@ -0,0 +1,112 @@
+package models_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/byte4cat/nbx/v2/pkg/dbu"
+	"github.com/byte4cat/nbx/v2/tests/dbugen/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestGeneratedParity(t *testing.T) {
+	full := &models.Travel{
+		ID:          "t1",
+		Name:        ptr("Taipei"),
+		IsPublic:    ptr(false),
+		StartAt:     ptr(time.Date(2025, 10, 25, 0, 0, 0, 0, time.UTC)),
+		Category:    2,
+		Destination: "Kaohsiung",
+		Note:        "note",
+		Hidden:      "hidden",
+		Origin:      models.Address{Street: "Main St", City: ptr("Taipei")},
+		Stops:       &[]models.Address{{Street: "A"}},
+		Extra:       map[string]int{"a": 1},
+		Details:     &models.Details{Text: ptr("text"), PDF: "a.pdf", Geo: &models.Geo{Lat: 25, Lng: 121}},
+		Address:     models.Address{Street: "Side St"},
+		Labels:      map[string]string{"k": "v"},
+		Meta:        []int{1},
+		Revision:    models.Revision{Version: 3, Editor: &models.Geo{Lat: 1}},
+		Previous:    &models.Travel{ID: "t0"},
+		StartGeo:    &models.Geo{Lat: 24},
+		Nickname:    dbu.NewNullable("tpe"),
+		Budget:      ptr(dbu.NewNullable(1.5)),
+		Itinerary:   dbu.NewNullable([]string{"a"}),
+	}
+	partial := &models.Travel{
+		ID:        "t2",
+		Details:   &models.Details{PDF: "b.pdf"},
+		Nickname:  dbu.NewNull[string](),
+		Budget:    ptr(dbu.NewNull[float64]()),
+		Itinerary: dbu.NewNull[[]string](),
+	}
+
+	travels := map[string]*models.Travel{
+		"full":    full,
+		"partial": partial,
+		"zero":    {},
+		"nil":     nil,
+	}
+	skips := [][]string{
+		nil,
+		{"travel_id", "details_pdf", "details_geo_lat", "origin", "id", "details", "mail"},
+		{"origin.city", "details.geo", "address", "editor_geo.lng", "version"},
+	}
+
+	for name, travel := range travels {
+		for _, skip := range skips {
+			t.Run("[SUCCESS] should build the RDB map of "+name, func(t *testing.T) {
+				expected, err := dbu.BuildRDBUpdateMap(travel, skip)
+				require.NoError(t, err)
+				actual, err := travel.RDBUpdateMap(skip...)
+				require.NoError(t, err)
+				assert.Equal(t, expected, actual)
+			})
+
+			t.Run("[SUCCESS] should build the Mongo map of "+name, func(t *testing.T) {
+				expected, err := dbu.BuildMongoUpdateMap(travel, skip)
+				require.NoError(t, err)
+				assert.Equal(t, expected, travel.MongoUpdateMap(skip...))
+			})
+		}
+	}
+
+	t.Run("[SUCCESS] should name the fields of other types the same", func(t *testing.T) {
+		balance, err := primitive.ParseDecimal128("12.50")
+		require.NoError(t, err)
+		account := &models.Account{
+			UserID:   "u1",
+			Email:    "a@b.c",
+			NickName: ptr("neil"),
+			Balance:  balance,
+			SeenAt:   primitive.Timestamp{T: 1700000000, I: 1},
+			Avatar:   primitive.Binary{Subtype: 0, Data: []byte{1}},
+			Homepage: url.URL{Scheme: "https", Host: "example.com"},
+			Token:    models.NewToken("t"),
+		}
+
+		expected, err := dbu.BuildRDBUpdateMap(account, []string{"email"})
+		require.NoError(t, err)
+		actual, err := account.RDBUpdateMap("email")
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+
+		expectedMongo, err := dbu.BuildMongoUpdateMap(account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, expectedMongo, account.MongoUpdateMap())
+	})
+
+	t.Run("[FAILURE] should return the same marshaling errors", func(t *testing.T) {
+		travel := &models.Travel{Extra: make(chan int)}
+
+		_, expected := dbu.BuildRDBUpdateMap(travel, nil)
+		_, actual := travel.RDBUpdateMap()
+		require.Error(t, expected)
+		assert.EqualError(t, actual, expected.Error())
+	})
+}